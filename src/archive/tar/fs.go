@@ -0,0 +1,338 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tar
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxSymlinks is the maximum number of symbolic links
+// followed when resolving a single path in an FS.
+const maxSymlinks = 40
+
+// An FS is a read-only file system backed by a tar archive.
+// It implements fs.FS.
+//
+// Unlike Reader, which provides only sequential access to an archive,
+// FS indexes the archive once, when it is created, and then reads file
+// contents directly from the underlying io.ReaderAt. An FS is safe for
+// concurrent use by multiple goroutines.
+//
+// Entry names are cleaned, and leading "/" and "../" elements are
+// removed, so that every entry has a valid fs.FS path. Directories that
+// are implied by entry names but have no header of their own are
+// synthesized. If the archive contains more than one entry with the
+// same name, the last one wins, as it would when extracting the archive.
+//
+// Symbolic links are followed when opening a file, as long as their
+// target stays within the archive. Hard links are resolved when the
+// archive is indexed: a hard link is presented as a regular file with
+// the content of its target. The fs.FileInfo of an entry returns the
+// entry's *Header from its Sys method, which makes the entry's PAX
+// records, extended attributes, and ownership available.
+type FS struct {
+	r     io.ReaderAt
+	files map[string]*fsEntry
+}
+
+// An fsEntry is a file in an FS.
+type fsEntry struct {
+	name string  // cleaned path of the file
+	hdr  *Header // header of the file; synthesized for implicit directories
+
+	// Location of the file's data in the archive.
+	offset int64       // offset of the first byte of data
+	size   int64       // number of bytes of data in the archive
+	sp     sparseHoles // holes of a sparse file; nil if not sparse
+
+	children []*fsEntry // entries of a directory, sorted by name
+}
+
+// NewFS returns an FS reading from r, which is assumed to be
+// a tar archive of the given size in bytes.
+//
+// NewFS reads the headers of every entry in the archive, and returns
+// an error if any of them is invalid.
+func NewFS(r io.ReaderAt, size int64) (*FS, error) {
+	sr := io.NewSectionReader(r, 0, size)
+	tr := NewReader(sr)
+	fsys := &FS{
+		r:     r,
+		files: make(map[string]*fsEntry),
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == TypeXGlobalHeader {
+			continue
+		}
+		// The reader consumes exactly the bytes of each header,
+		// so the current offset is the start of the entry's data.
+		offset, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		e := &fsEntry{
+			name:   toValidName(hdr.Name),
+			hdr:    hdr,
+			offset: offset,
+			size:   tr.curr.PhysicalRemaining(),
+		}
+		if sr, ok := tr.curr.(*sparseFileReader); ok {
+			e.sp = sr.sp
+		}
+		if hdr.Typeflag == TypeLink {
+			target, ok := fsys.files[toValidName(hdr.Linkname)]
+			if !ok || target.isDir() {
+				// A hard link must refer to a non-directory entry
+				// earlier in the archive. Ignore any that do not,
+				// since there is nothing to present them as.
+				continue
+			}
+			h := *target.hdr
+			h.Name = hdr.Name
+			e.hdr = &h
+			e.offset, e.size, e.sp = target.offset, target.size, target.sp
+		}
+		fsys.files[e.name] = e
+	}
+	fsys.index()
+	return fsys, nil
+}
+
+// index synthesizes the implicit directories of fsys
+// and records the children of every directory.
+func (fsys *FS) index() {
+	root, ok := fsys.files["."]
+	if !ok || !root.isDir() {
+		root = implicitDir(".")
+		fsys.files["."] = root
+	}
+	names := make([]string, 0, len(fsys.files))
+	for name := range fsys.files {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		// A file may be shadowed by a directory implied by a later
+		// entry. Directories win, so that every path stays reachable.
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if e, ok := fsys.files[dir]; ok && e.isDir() {
+				break
+			}
+			fsys.files[dir] = implicitDir(dir)
+		}
+	}
+	for name, e := range fsys.files {
+		if name == "." {
+			continue
+		}
+		parent := fsys.files[path.Dir(name)]
+		parent.children = append(parent.children, e)
+	}
+	for _, e := range fsys.files {
+		sort.Slice(e.children, func(i, j int) bool { return e.children[i].name < e.children[j].name })
+	}
+}
+
+func implicitDir(name string) *fsEntry {
+	return &fsEntry{
+		name: name,
+		hdr: &Header{
+			Typeflag: TypeDir,
+			Name:     name + "/",
+			Mode:     0555,
+		},
+	}
+}
+
+func (e *fsEntry) isDir() bool {
+	return e.stat().IsDir()
+}
+
+func (e *fsEntry) isSymlink() bool {
+	return e.stat().Mode()&fs.ModeSymlink != 0
+}
+
+func (e *fsEntry) stat() fsFileInfo {
+	return fsFileInfo{headerFileInfo{e.hdr}, e.name}
+}
+
+// fsFileInfo implements fs.FileInfo and fs.DirEntry.
+// Its name is derived from the cleaned path of the entry,
+// which may differ from the name recorded in the header.
+type fsFileInfo struct {
+	headerFileInfo
+	name string
+}
+
+func (fi fsFileInfo) Name() string { return path.Base(fi.name) }
+
+func (fi fsFileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi fsFileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+// toValidName coerces name to be a valid name for fs.FS.Open.
+func toValidName(name string) string {
+	p := path.Clean(name)
+	if strings.HasPrefix(p, "/") {
+		p = p[len("/"):]
+	}
+	for strings.HasPrefix(p, "../") {
+		p = p[len("../"):]
+	}
+	if p == "" || p == ".." {
+		p = "."
+	}
+	return p
+}
+
+// Open opens the named file in the archive,
+// using the semantics of fs.FS.Open:
+// paths are always slash separated, with no
+// leading / or ../ elements.
+// Symbolic links in name are followed.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, err := fsys.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if e.isDir() {
+		return &fsDir{name: name, e: e}, nil
+	}
+	// Entries without data, such as devices and FIFOs, have size 0.
+	r := io.NewSectionReader(fsys.r, e.offset, e.size)
+	if e.sp != nil {
+		return &fsSparseFile{
+			name: name,
+			e:    e,
+			r: &sparseFileReader{
+				fr: &regFileReader{r: r, nb: e.size},
+				sp: e.sp,
+			},
+		}, nil
+	}
+	return &fsFile{r, e}, nil
+}
+
+// lookup returns the entry for the valid path name, following
+// symbolic links.
+func (fsys *FS) lookup(name string) (*fsEntry, error) {
+	dir := "."
+	rest := name
+	links := 0
+	for {
+		if rest == "." {
+			return fsys.files[dir], nil
+		}
+		elem := rest
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			elem, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = ""
+		}
+		p := path.Join(dir, elem)
+		e, ok := fsys.files[p]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		if e.isSymlink() {
+			links++
+			if links > maxSymlinks {
+				return nil, errors.New("too many levels of symbolic links")
+			}
+			target := e.hdr.Linkname
+			if path.IsAbs(target) {
+				return nil, fs.ErrNotExist
+			}
+			target = path.Join(dir, target, rest)
+			if !fs.ValidPath(target) {
+				// The link points outside the archive.
+				return nil, fs.ErrNotExist
+			}
+			dir, rest = ".", target
+			continue
+		}
+		if rest == "" {
+			return e, nil
+		}
+		if !e.isDir() {
+			return nil, fs.ErrNotExist
+		}
+		dir = p
+	}
+}
+
+// An fsFile is a non-directory file opened from an FS.
+// It implements io.Seeker and io.ReaderAt as well as fs.File.
+type fsFile struct {
+	*io.SectionReader
+	e *fsEntry
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.e.stat(), nil }
+func (f *fsFile) Close() error               { return nil }
+
+// An fsSparseFile is a sparse file opened from an FS.
+type fsSparseFile struct {
+	name string
+	e    *fsEntry
+	r    *sparseFileReader
+}
+
+func (f *fsSparseFile) Stat() (fs.FileInfo, error) { return f.e.stat(), nil }
+func (f *fsSparseFile) Close() error               { return nil }
+
+func (f *fsSparseFile) Read(b []byte) (int, error) {
+	n, err := f.r.Read(b)
+	if err != nil && err != io.EOF {
+		err = &fs.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	return n, err
+}
+
+// An fsDir is a directory opened from an FS.
+type fsDir struct {
+	name   string
+	e      *fsEntry
+	offset int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return d.e.stat(), nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *fsDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	n := len(d.e.children) - d.offset
+	if count > 0 && n > count {
+		n = count
+	}
+	if n == 0 {
+		if count <= 0 {
+			return nil, nil
+		}
+		return nil, io.EOF
+	}
+	list := make([]fs.DirEntry, n)
+	for i := range list {
+		list[i] = d.e.children[d.offset+i].stat()
+	}
+	d.offset += n
+	return list, nil
+}
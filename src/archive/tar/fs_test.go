@@ -0,0 +1,331 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tar
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// writeTestArchive returns a tar archive containing the given entries.
+// The data of an entry is written after its header, and determines
+// the Size of a TypeReg header.
+func writeTestArchive(t *testing.T, entries []fsTestEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	for _, e := range entries {
+		hdr := e.hdr
+		if hdr.Typeflag == TypeReg {
+			hdr.Size = int64(len(e.data))
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatalf("WriteHeader(%q): %v", hdr.Name, err)
+		}
+		if _, err := io.WriteString(tw, e.data); err != nil {
+			t.Fatalf("Write(%q): %v", hdr.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type fsTestEntry struct {
+	hdr  Header
+	data string
+}
+
+func newTestFS(t *testing.T, entries []fsTestEntry) *FS {
+	t.Helper()
+	b := writeTestArchive(t, entries)
+	fsys, err := NewFS(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("NewFS: %v", err)
+	}
+	return fsys
+}
+
+func TestFS(t *testing.T) {
+	modTime := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	fsys := newTestFS(t, []fsTestEntry{
+		{hdr: Header{Typeflag: TypeDir, Name: "./", Mode: 0755, ModTime: modTime}},
+		{hdr: Header{Typeflag: TypeDir, Name: "./dir/", Mode: 0755, ModTime: modTime}},
+		{hdr: Header{Typeflag: TypeReg, Name: "./dir/file", Mode: 0644, ModTime: modTime}, data: "hello, world\n"},
+		{hdr: Header{Typeflag: TypeReg, Name: "/abs/file", Mode: 0644, ModTime: modTime}, data: "absolute\n"},
+		{hdr: Header{Typeflag: TypeReg, Name: "../dotdot", Mode: 0644, ModTime: modTime}, data: "dotdot\n"},
+		{hdr: Header{Typeflag: TypeReg, Name: "implicit/a/b", Mode: 0644, ModTime: modTime}, data: "b\n"},
+		{hdr: Header{Typeflag: TypeReg, Name: "dup", Mode: 0644, ModTime: modTime}, data: "first\n"},
+		{hdr: Header{Typeflag: TypeReg, Name: "dup", Mode: 0600, ModTime: modTime}, data: "second\n"},
+		{hdr: Header{Typeflag: TypeLink, Name: "hardlink", Linkname: "dir/file", ModTime: modTime}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "symlink", Linkname: "dir/file", ModTime: modTime}},
+		{hdr: Header{Typeflag: TypeFifo, Name: "fifo", Mode: 0644, ModTime: modTime}},
+		{hdr: Header{
+			Typeflag:   TypeReg,
+			Name:       "pax",
+			Mode:       0644,
+			ModTime:    modTime,
+			PAXRecords: map[string]string{"GOLANG.pkg": "tar", "SCHILY.xattr.user.test": "value"},
+		}, data: "pax\n"},
+	})
+
+	if err := fstest.TestFS(fsys,
+		"dir/file", "abs/file", "dotdot", "implicit/a/b", "dup",
+		"hardlink", "symlink", "fifo", "pax",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"dir/file": "hello, world\n",
+		"abs/file": "absolute\n",
+		"dotdot":   "dotdot\n",
+		"dup":      "second\n",
+		"hardlink": "hello, world\n",
+		"symlink":  "hello, world\n",
+		"fifo":     "",
+		"pax":      "pax\n",
+	} {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Errorf("ReadFile(%q): %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("ReadFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	info, err := fs.Stat(fsys, "dup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode(), fs.FileMode(0600); got != want {
+		t.Errorf("Stat(dup).Mode() = %v, want %v", got, want)
+	}
+
+	info, err = fs.Stat(fsys, "hardlink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Mode().IsRegular() || info.Name() != "hardlink" {
+		t.Errorf("Stat(hardlink) = %v %q, want regular file named hardlink", info.Mode(), info.Name())
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() == "symlink" {
+			if e.Type() != fs.ModeSymlink {
+				t.Errorf("ReadDir entry symlink has type %v, want %v", e.Type(), fs.ModeSymlink)
+			}
+			info, _ := e.Info()
+			if h := info.Sys().(*Header); h.Linkname != "dir/file" {
+				t.Errorf("symlink Linkname = %q, want %q", h.Linkname, "dir/file")
+			}
+		}
+	}
+
+	info, err = fs.Stat(fsys, "pax")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := info.Sys().(*Header)
+	if got, want := h.PAXRecords["GOLANG.pkg"], "tar"; got != want {
+		t.Errorf("PAXRecords[GOLANG.pkg] = %q, want %q", got, want)
+	}
+	if got, want := h.Xattrs, map[string]string{"user.test": "value"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Xattrs = %v, want %v", got, want)
+	}
+}
+
+func TestFSSeek(t *testing.T) {
+	fsys := newTestFS(t, []fsTestEntry{
+		{hdr: Header{Typeflag: TypeReg, Name: "a", Mode: 0644}, data: "0123456789"},
+		{hdr: Header{Typeflag: TypeReg, Name: "b", Mode: 0644}, data: "abcdefghij"},
+	})
+	f, err := fsys.Open("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		t.Fatalf("file %T does not implement io.ReadSeeker", f)
+	}
+	if _, err := rs.Seek(-3, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "hij"; got != want {
+		t.Errorf("read after Seek = %q, want %q", got, want)
+	}
+	buf := make([]byte, 2)
+	if _, err := f.(io.ReaderAt).ReadAt(buf, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(buf), "ef"; got != want {
+		t.Errorf("ReadAt = %q, want %q", got, want)
+	}
+}
+
+func TestFSSymlinks(t *testing.T) {
+	fsys := newTestFS(t, []fsTestEntry{
+		{hdr: Header{Typeflag: TypeReg, Name: "dir/file", Mode: 0644}, data: "data"},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "dirlink", Linkname: "dir"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "a/b/up", Linkname: "../../dir/"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "a/chain", Linkname: "b/up/file"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "root", Linkname: "."}},
+	})
+	for _, name := range []string{"dirlink/file", "a/b/up/file", "a/chain", "root/dir/file", "root/root/dirlink/file"} {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Errorf("ReadFile(%q): %v", name, err)
+			continue
+		}
+		if string(got) != "data" {
+			t.Errorf("ReadFile(%q) = %q, want %q", name, got, "data")
+		}
+	}
+	for _, name := range []string{"dirlink", "root"} {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			t.Errorf("Stat(%q): %v", name, err)
+			continue
+		}
+		if !info.IsDir() {
+			t.Errorf("Stat(%q).IsDir() = false, want true", name)
+		}
+	}
+}
+
+func TestFSSymlinkErrors(t *testing.T) {
+	fsys := newTestFS(t, []fsTestEntry{
+		{hdr: Header{Typeflag: TypeSymlink, Name: "loop1", Linkname: "loop2"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "loop2", Linkname: "loop1"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "outside", Linkname: "../etc/passwd"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "absolute", Linkname: "/etc/passwd"}},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "dangling", Linkname: "missing"}},
+		{hdr: Header{Typeflag: TypeReg, Name: "file", Mode: 0644}, data: "data"},
+	})
+	for _, name := range []string{"loop1", "outside", "absolute", "dangling", "file/x", "missing", "../file", "/file"} {
+		f, err := fsys.Open(name)
+		if err == nil {
+			f.Close()
+			t.Errorf("Open(%q) succeeded, want error", name)
+			continue
+		}
+		var pe *fs.PathError
+		if !errors.As(err, &pe) || pe.Path != name {
+			t.Errorf("Open(%q) error = %v, want *fs.PathError for %q", name, err, name)
+		}
+	}
+}
+
+// TestFSTestdata checks that an FS of each archive in testdata
+// presents the same file contents as reading it with a Reader.
+func TestFSTestdata(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names, want, skipFSTest, ok := readTestdataContents(b)
+			fsys, err := NewFS(bytes.NewReader(b), int64(len(b)))
+			if !ok {
+				// The archive is invalid; NewFS need not reject it,
+				// since it does not read file contents.
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewFS: %v", err)
+			}
+			for name, data := range want {
+				got, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					t.Errorf("ReadFile(%q): %v", name, err)
+					continue
+				}
+				if !bytes.Equal(got, data) {
+					t.Errorf("ReadFile(%q) = %d bytes, want %d bytes", name, len(got), len(data))
+				}
+			}
+			// fstest.TestFS reads every entry, which is impractical for
+			// very large sparse files and impossible for symbolic links
+			// that leave the archive or names that are not valid UTF-8.
+			if !skipFSTest {
+				if err := fstest.TestFS(fsys, names...); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
+
+// readTestdataContents reads the archive in b sequentially, returning the
+// FS names of its entries and the contents of its regular files. It also
+// reports whether the archive contains entries that fstest.TestFS cannot
+// check, and whether it could be read at all.
+func readTestdataContents(b []byte) (names []string, contents map[string][]byte, skipFSTest, ok bool) {
+	contents = make(map[string][]byte)
+	tr := NewReader(bytes.NewReader(b))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names, contents, skipFSTest, true
+		}
+		if err != nil {
+			return nil, nil, false, false
+		}
+		if hdr.Typeflag == TypeXGlobalHeader {
+			continue
+		}
+		name := toValidName(hdr.Name)
+		if !fs.ValidPath(name) {
+			skipFSTest = true
+			continue
+		}
+		if name != "." {
+			names = append(names, name)
+		}
+		switch hdr.Typeflag {
+		case TypeReg, TypeGNUSparse:
+			if hdr.Size > 1<<20 {
+				skipFSTest = true
+				continue
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, nil, false, false
+			}
+			contents[name] = data
+		case TypeLink:
+			if data, ok := contents[toValidName(hdr.Linkname)]; ok {
+				contents[name] = data
+			}
+		case TypeSymlink:
+			skipFSTest = true
+		}
+	}
+}
@@ -7,6 +7,7 @@ package tar
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"path"
 	"sort"
 	"strings"
@@ -107,6 +108,62 @@ func (tw *Writer) WriteHeader(hdr *Header) error {
 	}
 }
 
+// AddFS adds the files from fsys to the archive.
+// It walks the directory tree starting at the root of the file system,
+// adding each file and directory under its path in fsys.
+//
+// Regular files and directories are always supported. Since fs.FS
+// provides no way to read the target of a symbolic link, a symbolic link
+// is only supported if its fs.FileInfo returns a *Header from its Sys
+// method, as those of an FS do. Other file types result in an error.
+func (tw *Writer) AddFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch mode := info.Mode(); {
+		case mode.IsRegular(), mode.IsDir():
+		case mode&fs.ModeSymlink != 0:
+			sys, ok := info.Sys().(*Header)
+			if !ok {
+				return fmt.Errorf("archive/tar: cannot add symbolic link %s: unknown target", name)
+			}
+			link = sys.Linkname
+		default:
+			return fmt.Errorf("archive/tar: cannot add %s: unsupported file mode %v", name, mode)
+		}
+		h, err := FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		h.Name = name
+		if info.IsDir() {
+			h.Name += "/"
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if h.Typeflag != TypeReg {
+			return nil
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
 func (tw *Writer) writeUSTARHeader(hdr *Header) error {
 	// Check if we can use USTAR prefix/suffix splitting.
 	var namePrefix string
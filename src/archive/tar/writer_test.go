@@ -9,12 +9,14 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"testing/iotest"
 	"time"
 )
@@ -1308,3 +1310,104 @@ func TestFileWriter(t *testing.T) {
 		}
 	}
 }
+
+func TestWriterAddFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.go":       {Data: []byte("hello")},
+		"subfolder/two": {Data: []byte("two"), Mode: 0600},
+		"empty":         {Mode: fs.ModeDir | 0755},
+	}
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	if err := tw.AddFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the archive back and compare it against fsys.
+	want := []struct {
+		name     string
+		typeflag byte
+		data     string
+	}{
+		{"empty/", TypeDir, ""},
+		{"file.go", TypeReg, "hello"},
+		{"subfolder/", TypeDir, ""},
+		{"subfolder/two", TypeReg, "two"},
+	}
+	tr := NewReader(&buf)
+	for _, w := range want {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("Next: %v, want header for %q", err, w.name)
+		}
+		if hdr.Name != w.name || hdr.Typeflag != w.typeflag {
+			t.Errorf("got header %q (type %q), want %q (type %q)", hdr.Name, hdr.Typeflag, w.name, w.typeflag)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != w.data {
+			t.Errorf("%s: data = %q, want %q", hdr.Name, data, w.data)
+		}
+		if m := fsys[strings.TrimSuffix(w.name, "/")]; m != nil && m.Mode.Perm() != 0 {
+			if got := fs.FileMode(hdr.Mode).Perm(); got != m.Mode.Perm() {
+				t.Errorf("%s: mode = %v, want %v", hdr.Name, got, m.Mode.Perm())
+			}
+		}
+	}
+	if hdr, err := tr.Next(); err != io.EOF {
+		t.Fatalf("Next = %v, %v, want io.EOF", hdr, err)
+	}
+}
+
+func TestWriterAddFSNonRegular(t *testing.T) {
+	fsys := fstest.MapFS{
+		"device":  {Data: []byte("hello"), Mode: 0755 | fs.ModeDevice},
+		"symlink": {Data: []byte("target"), Mode: 0755 | fs.ModeSymlink},
+	}
+	for name := range fsys {
+		tw := NewWriter(io.Discard)
+		if err := tw.AddFS(fstest.MapFS{name: fsys[name]}); err == nil {
+			t.Errorf("AddFS with %s succeeded, want error", name)
+		}
+	}
+}
+
+// TestWriterAddFSRoundTrip checks that an archive written from an FS
+// preserves its symbolic links and PAX records.
+func TestWriterAddFSRoundTrip(t *testing.T) {
+	fsys := newTestFS(t, []fsTestEntry{
+		{hdr: Header{Typeflag: TypeReg, Name: "dir/file", Mode: 0644, PAXRecords: map[string]string{"GOLANG.pkg": "tar"}}, data: "data"},
+		{hdr: Header{Typeflag: TypeSymlink, Name: "link", Linkname: "dir/file"}},
+	})
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	if err := tw.AddFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewFS(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(got, "dir/file", "link"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fs.ReadFile(got, "link"); err != nil || string(data) != "data" {
+		t.Errorf("ReadFile(link) = %q, %v, want %q, nil", data, err, "data")
+	}
+	info, err := fs.Stat(got, "dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := info.Sys().(*Header).PAXRecords["GOLANG.pkg"]; rec != "tar" {
+		t.Errorf("PAXRecords[GOLANG.pkg] = %q, want %q", rec, "tar")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -353,6 +354,67 @@ func TestWriterDirAttributes(t *testing.T) {
 	}
 }
 
+func TestWriterAddFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.go":              {Data: []byte("hello")},
+		"subfolder/another.go": {Data: []byte("world"), Mode: 0600},
+		"empty":                {Mode: fs.ModeDir | 0755},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.AddFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		if !strings.HasSuffix(f.Name, "/") && f.Method != Deflate {
+			t.Errorf("%s: Method = %d, want %d", f.Name, f.Method, Deflate)
+		}
+	}
+	want := []string{"empty/", "file.go", "subfolder/", "subfolder/another.go"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("archive contains %q, want %q", names, want)
+	}
+	if err := fstest.TestFS(r, "file.go", "subfolder/another.go", "empty"); err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range fsys {
+		if f.Mode.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(r, name)
+		if err != nil {
+			t.Errorf("ReadFile(%q): %v", name, err)
+			continue
+		}
+		if !bytes.Equal(data, f.Data) {
+			t.Errorf("ReadFile(%q) = %q, want %q", name, data, f.Data)
+		}
+	}
+}
+
+func TestWriterAddFSNonRegular(t *testing.T) {
+	fsys := fstest.MapFS{
+		"device":  {Data: []byte("hello"), Mode: 0755 | fs.ModeDevice},
+		"symlink": {Data: []byte("target"), Mode: 0755 | fs.ModeSymlink},
+	}
+	for name := range fsys {
+		w := NewWriter(io.Discard)
+		if err := w.AddFS(fstest.MapFS{name: fsys[name]}); err == nil {
+			t.Errorf("AddFS with %s succeeded, want error", name)
+		}
+	}
+}
+
 func testCreate(t *testing.T, w *Writer, wt *WriteTest) {
 	header := &FileHeader{
 		Name:   wt.Name,
@@ -133,6 +133,19 @@
 // 		arguments to pass on each go tool asm invocation.
 // 	-buildmode mode
 // 		build mode to use. See 'go help buildmode' for more.
+// 	-buildvcs
+// 		whether to stamp binaries with version control information
+// 		("true", "false", or "auto"). By default ("auto"), version control
+// 		information is stamped into a binary built in module mode if the main
+// 		package, the main module containing it, and the current directory are
+// 		all in the same Git repository and the git command is available.
+// 		Use -buildvcs=false to always omit version control information, or
+// 		-buildvcs=true to fail if version control information cannot be
+// 		obtained. The information, along with the build flags and environment
+// 		settings that influenced the build, can be read with
+// 		'go version -m' or runtime/debug.ReadBuildInfo.
+// 		Only 'go build', 'go install' and 'go test' stamp version control
+// 		information; other commands never run version control tools.
 // 	-compiler name
 // 		name of compiler to use, as in runtime.Compiler (gccgo or gc).
 // 	-gccgoflags '[pattern=]arg list'
@@ -1504,7 +1517,9 @@
 // The -m flag causes go version to print each executable's embedded
 // module version information, when available. In the output, the module
 // information consists of multiple lines following the version line, each
-// indented by a leading tab character.
+// indented by a leading tab character. The build settings and version
+// control information recorded by the go command are printed as "build"
+// lines, in the same key=value form as runtime/debug.BuildSetting.
 //
 // See also: go doc runtime/debug.BuildInfo.
 //
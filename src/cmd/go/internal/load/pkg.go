@@ -14,15 +14,18 @@ import (
 	"go/build"
 	"go/scanner"
 	"go/token"
+	exec "internal/execabs"
 	"io/fs"
 	"os"
 	"path"
 	pathpkg "path"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -35,6 +38,7 @@ import (
 	"cmd/go/internal/search"
 	"cmd/go/internal/str"
 	"cmd/go/internal/trace"
+	"cmd/go/internal/vcs"
 	"cmd/internal/sys"
 
 	"golang.org/x/mod/module"
@@ -42,6 +46,12 @@ import (
 
 var IgnoreImports bool // control whether we ignore imports in packages
 
+// StampVCS controls whether main packages are stamped with version control
+// information. It is set by the commands that link binaries (build, install
+// and test), so that read-only commands such as 'go list' do not run
+// version control tools.
+var StampVCS bool
+
 // A Package describes a single package found in a directory.
 type Package struct {
 	PackagePublic                 // visible in 'go list'
@@ -1915,9 +1925,157 @@ func (p *Package) load(ctx context.Context, path string, stk *ImportStack, impor
 		}
 		p.Module = modload.PackageModuleInfo(mainPath)
 		if p.Name == "main" && len(p.DepsErrors) == 0 {
-			p.Internal.BuildInfo = modload.PackageBuildInfo(mainPath, p.Deps)
+			if err := p.setBuildInfo(mainPath); err != nil {
+				setError(err)
+			}
+		}
+	}
+}
+
+// setBuildInfo computes the build information for a main package,
+// including the module information and the settings used to build it,
+// and records it in p.Internal.BuildInfo.
+//
+// If -buildvcs is not "false" and the main package is in the main module,
+// setBuildInfo also stamps version control information for the repository
+// containing the main module. An error is returned only if that
+// information was explicitly requested with -buildvcs=true and cannot
+// be obtained.
+func (p *Package) setBuildInfo(mainPath string) error {
+	info := modload.PackageBuildInfo(mainPath, p.Deps)
+	if info == nil {
+		return nil
+	}
+
+	appendSetting := func(key, value string) {
+		value = strings.ReplaceAll(value, "\n", " ") // make value safe
+		info.Settings = append(info.Settings, debug.BuildSetting{Key: key, Value: value})
+	}
+
+	// Add command-line flags relevant to the build.
+	// This is informational, not an exhaustive list.
+	// Please keep the list sorted.
+	if BuildAsmflags.present {
+		appendSetting("-asmflags", BuildAsmflags.String())
+	}
+	appendSetting("-compiler", cfg.BuildContext.Compiler)
+	if BuildGccgoflags.present && cfg.BuildContext.Compiler == "gccgo" {
+		appendSetting("-gccgoflags", BuildGccgoflags.String())
+	}
+	if BuildGcflags.present && cfg.BuildContext.Compiler == "gc" {
+		appendSetting("-gcflags", BuildGcflags.String())
+	}
+	if BuildLdflags.present {
+		// The go command may generate -X flags for the main module's
+		// linker, and -ldflags frequently contains absolute paths,
+		// so omit it when -trimpath is set.
+		if !cfg.BuildTrimpath {
+			appendSetting("-ldflags", BuildLdflags.String())
 		}
 	}
+	if cfg.BuildMSan {
+		appendSetting("-msan", "true")
+	}
+	if cfg.BuildRace {
+		appendSetting("-race", "true")
+	}
+	if tags := cfg.BuildContext.BuildTags; len(tags) > 0 {
+		appendSetting("-tags", strings.Join(tags, ","))
+	}
+	if cfg.BuildTrimpath {
+		appendSetting("-trimpath", "true")
+	}
+	cgo := "0"
+	if cfg.BuildContext.CgoEnabled {
+		cgo = "1"
+	}
+	appendSetting("CGO_ENABLED", cgo)
+	if cfg.BuildContext.CgoEnabled {
+		for _, name := range []string{"CGO_CFLAGS", "CGO_CPPFLAGS", "CGO_CXXFLAGS", "CGO_LDFLAGS"} {
+			appendSetting(name, cfg.Getenv(name))
+		}
+	}
+	appendSetting("GOARCH", cfg.BuildContext.GOARCH)
+	if key, val := cfg.GetArchEnv(); key != "" && val != "" {
+		appendSetting(key, val)
+	}
+	appendSetting("GOOS", cfg.BuildContext.GOOS)
+
+	// Add VCS status if all conditions are true:
+	//
+	// - -buildvcs is enabled.
+	// - The go command is linking a binary (see StampVCS).
+	// - p is not a test or a set of files named on the command line.
+	// - p is contained within the main module.
+	// - The main module, p, and the current directory are all in the same
+	//   Git repository.
+	if cfg.BuildBuildvcs != "false" && StampVCS && !p.Internal.CmdlineFiles && p.ForTest == "" &&
+		p.Module != nil && p.Module.Main {
+		st, err := vcsStatus(p.Dir)
+		if err != nil {
+			if cfg.BuildBuildvcs == "true" {
+				return fmt.Errorf("error obtaining VCS status: %v\n\tUse -buildvcs=false to disable VCS stamping.", err)
+			}
+		} else {
+			appendSetting("vcs", st.vcs)
+			if st.Revision != "" {
+				appendSetting("vcs.revision", st.Revision)
+			}
+			if !st.CommitTime.IsZero() {
+				appendSetting("vcs.time", st.CommitTime.UTC().Format(time.RFC3339Nano))
+			}
+			appendSetting("vcs.modified", strconv.FormatBool(st.Uncommitted))
+		}
+	}
+
+	p.Internal.BuildInfo = info.String()
+	return nil
+}
+
+// repoStatus is the version control status of a repository root,
+// together with the name of the version control system in use there.
+type repoStatus struct {
+	vcs.Status
+	vcs string
+}
+
+var vcsStatusCache par.Cache // repo root → vcsStatusResult
+
+type vcsStatusResult struct {
+	st  repoStatus
+	err error
+}
+
+// vcsStatus returns the status of the version control repository
+// containing dir. It returns an error if dir, the main module, and the
+// current directory are not all in the same repository, or if the
+// repository does not use Git.
+func vcsStatus(dir string) (repoStatus, error) {
+	root, vcsCmd, err := vcs.RepoRootForDir(dir)
+	if err != nil {
+		return repoStatus{}, err
+	}
+	for _, d := range []string{modload.ModRoot(), base.Cwd} {
+		droot, _, err := vcs.RepoRootForDir(d)
+		if err != nil {
+			return repoStatus{}, err
+		}
+		if droot != root {
+			return repoStatus{}, fmt.Errorf("%s and %s are in different repositories (%s and %s)", base.ShortPath(dir), base.ShortPath(d), root, droot)
+		}
+	}
+	if vcsCmd.Status == nil {
+		return repoStatus{}, fmt.Errorf("%s status is not supported", vcsCmd.Name)
+	}
+	if _, err := exec.LookPath(vcsCmd.Cmd); err != nil {
+		return repoStatus{}, err
+	}
+
+	r := vcsStatusCache.Do(root, func() interface{} {
+		st, err := vcsCmd.Status(vcsCmd, root)
+		return vcsStatusResult{repoStatus{st, vcsCmd.Cmd}, err}
+	}).(vcsStatusResult)
+	return r.st, r.err
 }
 
 // An EmbedError indicates a problem with a go:embed directive.
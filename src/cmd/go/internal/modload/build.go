@@ -5,7 +5,6 @@
 package modload
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
@@ -13,6 +12,7 @@ import (
 	"internal/goroot"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 
 	"cmd/go/internal/base"
@@ -247,12 +247,16 @@ func moduleInfo(ctx context.Context, m module.Version, fromBuildList, listRetrac
 	return info
 }
 
-// PackageBuildInfo returns a string containing module version information
-// for modules providing packages named by path and deps. path and deps must
-// name packages that were resolved successfully with LoadPackages.
-func PackageBuildInfo(path string, deps []string) string {
+// PackageBuildInfo returns the module version information for modules
+// providing packages named by path and deps, or nil if path does not name
+// a package in a module. path and deps must name packages that were
+// resolved successfully with LoadPackages.
+//
+// The caller may add further build information, such as build settings,
+// before formatting the result with (*debug.BuildInfo).String.
+func PackageBuildInfo(path string, deps []string) *debug.BuildInfo {
 	if isStandardImportPath(path) || !Enabled() {
-		return ""
+		return nil
 	}
 
 	target := mustFindModule(path, path)
@@ -269,28 +273,35 @@ func PackageBuildInfo(path string, deps []string) string {
 	}
 	module.Sort(mods)
 
-	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "path\t%s\n", path)
-
-	writeEntry := func(token string, m module.Version) {
-		mv := m.Version
-		if mv == "" {
-			mv = "(devel)"
+	debugModFromModinfo := func(m module.Version) *debug.Module {
+		version := m.Version
+		if version == "" {
+			version = "(devel)"
+		}
+		dm := &debug.Module{
+			Path:    m.Path,
+			Version: version,
 		}
-		fmt.Fprintf(&buf, "%s\t%s\t%s", token, m.Path, mv)
 		if r := Replacement(m); r.Path == "" {
-			fmt.Fprintf(&buf, "\t%s\n", modfetch.Sum(m))
+			dm.Sum = modfetch.Sum(m)
 		} else {
-			fmt.Fprintf(&buf, "\n=>\t%s\t%s\t%s\n", r.Path, r.Version, modfetch.Sum(r))
+			dm.Replace = &debug.Module{
+				Path:    r.Path,
+				Version: r.Version,
+				Sum:     modfetch.Sum(r),
+			}
 		}
+		return dm
 	}
 
-	writeEntry("mod", target)
+	info := &debug.BuildInfo{
+		Path: path,
+		Main: *debugModFromModinfo(target),
+	}
 	for _, mod := range mods {
-		writeEntry("dep", mod)
+		info.Deps = append(info.Deps, debugModFromModinfo(mod))
 	}
-
-	return buf.String()
+	return info
 }
 
 // mustFindModule is like findModule, but it calls base.Fatalf if the
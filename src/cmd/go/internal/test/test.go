@@ -569,6 +569,7 @@ var defaultVetFlags = []string{
 
 func runTest(ctx context.Context, cmd *base.Command, args []string) {
 	load.ModResolveTests = true
+	load.StampVCS = true
 
 	pkgArgs, testArgs = testFlags(args)
 
@@ -5,6 +5,7 @@
 package vcs
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,8 +18,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"cmd/go/internal/base"
 	"cmd/go/internal/cfg"
@@ -48,6 +51,14 @@ type Cmd struct {
 
 	RemoteRepo  func(v *Cmd, rootDir string) (remoteRepo string, err error)
 	ResolveRepo func(v *Cmd, rootDir, remoteRepo string) (realRepo string, err error)
+	Status      func(v *Cmd, rootDir string) (Status, error)
+}
+
+// Status is the current state of a local repository.
+type Status struct {
+	Revision    string    // Optional.
+	CommitTime  time.Time // Optional.
+	Uncommitted bool      // Required.
 }
 
 var defaultSecureScheme = map[string]bool{
@@ -182,6 +193,54 @@ var vcsGit = &Cmd{
 	PingCmd: "ls-remote {scheme}://{repo}",
 
 	RemoteRepo: gitRemoteRepo,
+	Status:     gitStatus,
+}
+
+func gitStatus(vcsGit *Cmd, rootDir string) (Status, error) {
+	out, err := vcsGit.runOutputVerboseOnly(rootDir, "status --porcelain")
+	if err != nil {
+		return Status{}, err
+	}
+	uncommitted := len(out) > 0
+
+	// "git status" works for empty repositories, but "git show" does not.
+	// Assume there are no commits in the repo when "git show" fails with
+	// uncommitted files and skip tagging revision / committime.
+	var rev string
+	var commitTime time.Time
+	out, err = vcsGit.runOutputVerboseOnly(rootDir, "-c log.showsignature=false show -s --format=%H:%ct")
+	if err != nil && !uncommitted {
+		return Status{}, err
+	} else if err == nil {
+		rev, commitTime, err = parseRevTime(out)
+		if err != nil {
+			return Status{}, err
+		}
+	}
+
+	return Status{
+		Revision:    rev,
+		CommitTime:  commitTime,
+		Uncommitted: uncommitted,
+	}, nil
+}
+
+// parseRevTime parses commit details in "revision:seconds" format.
+func parseRevTime(out []byte) (string, time.Time, error) {
+	buf := string(bytes.TrimSpace(out))
+
+	i := strings.IndexByte(buf, ':')
+	if i < 1 {
+		return "", time.Time{}, errors.New("unrecognized VCS tool output")
+	}
+	rev := buf[:i]
+
+	secs, err := strconv.ParseInt(buf[i+1:], 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unrecognized VCS tool output: %v", err)
+	}
+
+	return rev, time.Unix(secs, 0), nil
 }
 
 // scpSyntaxRe matches the SCP-like addresses used by Git to access
@@ -395,6 +454,12 @@ func (v *Cmd) runOutput(dir string, cmd string, keyval ...string) ([]byte, error
 	return v.run1(dir, cmd, keyval, true)
 }
 
+// runOutputVerboseOnly is like runOutput but only generates error output to
+// standard error in verbose mode.
+func (v *Cmd) runOutputVerboseOnly(dir string, cmd string, keyval ...string) ([]byte, error) {
+	return v.run1(dir, cmd, keyval, false)
+}
+
 // run1 is the generalized implementation of run and runOutput.
 func (v *Cmd) run1(dir string, cmdline string, keyval []string, verbose bool) ([]byte, error) {
 	m := make(map[string]string)
@@ -604,6 +669,52 @@ func FromDir(dir, srcRoot string) (vcs *Cmd, root string, err error) {
 	return nil, "", fmt.Errorf("directory %q is not using a known version control system", origDir)
 }
 
+// RepoRootForDir finds the root directory of the version control repository
+// containing dir, searching dir and each of its parents up to the root of the
+// file system. It returns the absolute root directory and the version control
+// system in use there. Nested Git repositories (such as submodules) resolve
+// to the innermost repository; any other nesting is an error.
+//
+// If dir is not inside a known repository, RepoRootForDir returns an error
+// for which errors.Is(err, fs.ErrNotExist) is true.
+//
+// Unlike FromDir, RepoRootForDir does not consult GOVCS: it is used to read
+// the status of a local checkout, not to download code.
+func RepoRootForDir(dir string) (root string, vcs *Cmd, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	origDir := dir
+	for {
+		for _, v := range vcsList {
+			if _, err := os.Stat(filepath.Join(dir, "."+v.Cmd)); err == nil {
+				if vcs == nil {
+					vcs = v
+					root = dir
+					continue
+				}
+				// Allow .git inside .git, which can arise due to submodules.
+				if vcs == v && v.Cmd == "git" {
+					continue
+				}
+				return "", nil, fmt.Errorf("directory %q uses %s, but parent %q uses %s",
+					root, vcs.Cmd, dir, v.Cmd)
+			}
+		}
+
+		ndir := filepath.Dir(dir)
+		if len(ndir) >= len(dir) {
+			break
+		}
+		dir = ndir
+	}
+	if vcs == nil {
+		return "", nil, &fs.PathError{Op: "find repository root", Path: origDir, Err: fs.ErrNotExist}
+	}
+	return root, vcs, nil
+}
+
 // A govcsRule is a single GOVCS rule like private:hg|svn.
 type govcsRule struct {
 	pattern string
@@ -15,6 +15,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"cmd/go/internal/base"
@@ -93,6 +94,19 @@ and test commands:
 		arguments to pass on each go tool asm invocation.
 	-buildmode mode
 		build mode to use. See 'go help buildmode' for more.
+	-buildvcs
+		whether to stamp binaries with version control information
+		("true", "false", or "auto"). By default ("auto"), version control
+		information is stamped into a binary built in module mode if the main
+		package, the main module containing it, and the current directory are
+		all in the same Git repository and the git command is available.
+		Use -buildvcs=false to always omit version control information, or
+		-buildvcs=true to fail if version control information cannot be
+		obtained. The information, along with the build flags and environment
+		settings that influenced the build, can be read with
+		'go version -m' or runtime/debug.ReadBuildInfo.
+		Only 'go build', 'go install' and 'go test' stamp version control
+		information; other commands never run version control tools.
 	-compiler name
 		name of compiler to use, as in runtime.Compiler (gccgo or gc).
 	-gccgoflags '[pattern=]arg list'
@@ -287,6 +301,7 @@ func AddBuildFlags(cmd *base.Command, mask BuildFlagMask) {
 		// consistency.
 		cmd.Flag.StringVar(&fsys.OverlayFile, "overlay", "", "")
 	}
+	cmd.Flag.Var((*buildvcsFlag)(&cfg.BuildBuildvcs), "buildvcs", "")
 	cmd.Flag.StringVar(&cfg.BuildContext.InstallSuffix, "installsuffix", "", "")
 	cmd.Flag.Var(&load.BuildLdflags, "ldflags", "")
 	cmd.Flag.BoolVar(&cfg.BuildLinkshared, "linkshared", false, "")
@@ -326,6 +341,33 @@ func (v *tagsFlag) String() string {
 	return "<TagsFlag>"
 }
 
+// buildvcsFlag is the implementation of the -buildvcs flag.
+type buildvcsFlag string
+
+func (f *buildvcsFlag) IsBoolFlag() bool { return true } // allow -buildvcs (without arguments)
+
+func (f *buildvcsFlag) Set(s string) error {
+	// Allow "-buildvcs=auto", in addition to the usual "true" and "false".
+	if s == "" || s == "auto" {
+		*f = "auto"
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("value is neither 'auto' nor a valid bool")
+	}
+	*f = buildvcsFlag(strconv.FormatBool(b)) // convert to canonical "true" or "false"
+	return nil
+}
+
+func (f *buildvcsFlag) String() string {
+	if *f == "" {
+		return "auto"
+	}
+	return string(*f)
+}
+
 // fileExtSplit expects a filename and returns the name
 // and ext (without the dot). If the file has no
 // extension, ext will be empty.
@@ -368,6 +410,7 @@ var pkgsFilter = func(pkgs []*load.Package) []*load.Package { return pkgs }
 var runtimeVersion = runtime.Version()
 
 func runBuild(ctx context.Context, cmd *base.Command, args []string) {
+	load.StampVCS = true
 	BuildInit()
 	var b Builder
 	b.Init()
@@ -576,6 +619,7 @@ func libname(args []string, pkgs []*load.Package) (string, error) {
 }
 
 func runInstall(ctx context.Context, cmd *base.Command, args []string) {
+	load.StampVCS = true
 	// TODO(golang.org/issue/41696): print a deprecation message for the -i flag
 	// whenever it's set (or just remove it). For now, we don't print a message
 	// if all named packages are in GOROOT. cmd/dist (run by make.bash) uses
@@ -36,6 +36,7 @@ type Writer struct {
 	closed      bool
 	buf         [10]byte
 	err         error
+	par         *blockWriter // set by SetConcurrency
 }
 
 // NewWriter returns a new Writer.
@@ -71,6 +72,10 @@ func (z *Writer) init(w io.Writer, level int) {
 	if compressor != nil {
 		compressor.Reset(w)
 	}
+	par := z.par
+	if par != nil {
+		par.reset()
+	}
 	*z = Writer{
 		Header: Header{
 			OS: 255, // unknown
@@ -78,13 +83,14 @@ func (z *Writer) init(w io.Writer, level int) {
 		w:          w,
 		level:      level,
 		compressor: compressor,
+		par:        par,
 	}
 }
 
 // Reset discards the Writer z's state and makes it equivalent to the
 // result of its original state from NewWriter or NewWriterLevel, but
 // writing to w instead. This permits reusing a Writer rather than
-// allocating a new one.
+// allocating a new one. Settings made by SetConcurrency are kept.
 func (z *Writer) Reset(w io.Writer) {
 	z.init(w, z.level)
 }
@@ -187,12 +193,16 @@ func (z *Writer) Write(p []byte) (int, error) {
 				return 0, z.err
 			}
 		}
-		if z.compressor == nil {
+		if z.compressor == nil && z.par == nil {
 			z.compressor, _ = flate.NewWriter(z.w, z.level)
 		}
 	}
 	z.size += uint32(len(p))
 	z.digest = crc32.Update(z.digest, crc32.IEEETable, p)
+	if z.par != nil {
+		n, z.err = z.par.write(z.w, p)
+		return n, z.err
+	}
 	n, z.err = z.compressor.Write(p)
 	return n, z.err
 }
@@ -218,6 +228,10 @@ func (z *Writer) Flush() error {
 			return z.err
 		}
 	}
+	if z.par != nil {
+		z.err = z.par.flush(z.w, false)
+		return z.err
+	}
 	z.err = z.compressor.Flush()
 	return z.err
 }
@@ -239,7 +253,11 @@ func (z *Writer) Close() error {
 			return z.err
 		}
 	}
-	z.err = z.compressor.Close()
+	if z.par != nil {
+		z.err = z.par.flush(z.w, true)
+	} else {
+		z.err = z.compressor.Close()
+	}
 	if z.err != nil {
 		return z.err
 	}
@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"os"
 	"reflect"
 	"testing"
 	"time"
@@ -273,3 +274,145 @@ func TestLimitedWrite(t *testing.T) {
 		}
 	}
 }
+
+func TestWriterConcurrency(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/Isaac.Newton-Opticks.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serial bytes.Buffer
+	w := NewWriter(&serial)
+	w.Write(data)
+	w.Close()
+
+	for _, tt := range []struct {
+		level, blockSize, blocks int
+	}{
+		{DefaultCompression, 1 << 20, 4},
+		{DefaultCompression, 100000, 4},
+		{BestSpeed, 10000, 1},
+		{BestCompression, 1000, 8},
+		{NoCompression, 50000, 2},
+		{HuffmanOnly, 50000, 2},
+	} {
+		var buf bytes.Buffer
+		w, err := NewWriterLevel(&buf, tt.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.SetConcurrency(tt.blockSize, tt.blocks); err != nil {
+			t.Fatal(err)
+		}
+		w.Name = "opticks"
+		for p := data; len(p) > 0; {
+			n := 12345
+			if n > len(p) {
+				n = len(p)
+			}
+			if _, err := w.Write(p[:n]); err != nil {
+				t.Fatal(err)
+			}
+			p = p[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if tt.level == DefaultCompression && tt.blockSize >= 100000 {
+			// Priming each block with the previous one keeps the
+			// output close to the size of serial compression.
+			if max := serial.Len() + serial.Len()/50; buf.Len() > max {
+				t.Errorf("block size %d: compressed to %d bytes, serially to %d", tt.blockSize, buf.Len(), serial.Len())
+			}
+		}
+
+		r, err := NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%+v: round trip mismatch", tt)
+		}
+		if r.Name != "opticks" {
+			t.Errorf("%+v: Name = %q, want %q", tt, r.Name, "opticks")
+		}
+	}
+}
+
+func TestWriterConcurrencyFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.SetConcurrency(16, 2); err != nil {
+		t.Fatal(err)
+	}
+	var r *Reader
+	for _, msg := range []string{"first message, spanning blocks", "second", "", "third"} {
+		if _, err := io.WriteString(w, msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if r == nil {
+			var err error
+			if r, err = NewReader(&buf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got := make([]byte, len(msg))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatalf("reading %q after Flush: %v", msg, err)
+		}
+		if string(got) != msg {
+			t.Fatalf("read %q after Flush, want %q", got, msg)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("Read after Close = %d, %v; want 0, io.EOF", n, err)
+	}
+
+	// Reset keeps the settings, and an empty stream is still valid.
+	buf.Reset()
+	w.Reset(&buf)
+	if w.par == nil {
+		t.Fatal("Reset discarded SetConcurrency settings")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reset(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(r); len(got) != 0 || err != nil {
+		t.Fatalf("ReadAll of empty stream = %q, %v", got, err)
+	}
+}
+
+func TestWriterConcurrencyErrors(t *testing.T) {
+	w := NewWriter(io.Discard)
+	for _, args := range [][2]int{{0, 1}, {-1, 1}, {1 << 20, 0}} {
+		if err := w.SetConcurrency(args[0], args[1]); err == nil {
+			t.Errorf("SetConcurrency(%d, %d) succeeded", args[0], args[1])
+		}
+	}
+	w.Write([]byte("x"))
+	if err := w.SetConcurrency(1<<20, 4); err == nil {
+		t.Errorf("SetConcurrency after Write succeeded")
+	}
+
+	w = NewWriter(&limitedWriter{100})
+	w.SetConcurrency(1000, 2)
+	_, err := w.Write(make([]byte, 10000))
+	if err == nil {
+		err = w.Close()
+	}
+	if err != io.ErrShortWrite {
+		t.Errorf("writing to limited writer: got %v, want io.ErrShortWrite", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gzip
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+)
+
+// windowSize is the size of the DEFLATE window: matches in a block may
+// refer to at most this many bytes of the input before it.
+const windowSize = 1 << 15
+
+// SetConcurrency makes z compress its input in independent blocks of
+// blockSize bytes, with up to blocks of them being compressed at a time
+// on separate goroutines. Each block is primed with the last 32 KiB of
+// the input before it, so the loss in compression ratio is small for
+// block sizes of a few hundred KiB or more. The output is a single
+// standard gzip stream, which any gzip reader can decompress.
+//
+// SetConcurrency must be called before the first call to Write, Flush,
+// or Close. The settings remain in effect after Reset.
+func (z *Writer) SetConcurrency(blockSize, blocks int) error {
+	if z.wroteHeader {
+		return errors.New("gzip: SetConcurrency called after Write")
+	}
+	if blockSize <= 0 {
+		return errors.New("gzip: block size must be positive")
+	}
+	if blocks <= 0 {
+		return errors.New("gzip: number of blocks must be positive")
+	}
+	z.par = &blockWriter{
+		level:     z.level,
+		blockSize: blockSize,
+		blocks:    blocks,
+	}
+	return nil
+}
+
+// A blockWriter compresses its input in blocks, concurrently, and writes
+// them in order as a single DEFLATE stream. Every block but the last
+// ends with a sync flush, which aligns it to a byte boundary so that the
+// next block's output can follow it directly.
+type blockWriter struct {
+	level     int
+	blockSize int
+	blocks    int
+
+	buf  []byte   // input of the block being filled
+	dict []byte   // up to windowSize bytes of input before buf
+	jobs []*block // blocks being compressed, in output order
+	free [][]byte // input buffers for reuse
+}
+
+// A block is a unit of input compressed on its own goroutine.
+type block struct {
+	in   []byte
+	dict []byte
+	last bool
+	out  bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
+func (b *block) compress(level int) {
+	defer close(b.done)
+	fw, err := flate.NewWriterDict(&b.out, level, b.dict)
+	if err != nil {
+		b.err = err
+		return
+	}
+	if _, err := fw.Write(b.in); err != nil {
+		b.err = err
+		return
+	}
+	if b.last {
+		b.err = fw.Close()
+	} else {
+		b.err = fw.Flush()
+	}
+}
+
+// reset discards any pending state, waiting for blocks still
+// being compressed.
+func (bw *blockWriter) reset() {
+	for _, b := range bw.jobs {
+		<-b.done
+	}
+	bw.jobs = nil
+	bw.buf = bw.buf[:0]
+	bw.dict = nil
+}
+
+// write adds p to the input, handing out each block as it fills up.
+func (bw *blockWriter) write(w io.Writer, p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if bw.buf == nil {
+			bw.buf = bw.newBuf()
+		}
+		m := bw.blockSize - len(bw.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		bw.buf = append(bw.buf, p[:m]...)
+		p = p[m:]
+		if len(bw.buf) == bw.blockSize {
+			if err := bw.start(w, false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush compresses the pending input and writes out all blocks.
+// If last is set, the stream is ended.
+func (bw *blockWriter) flush(w io.Writer, last bool) error {
+	if err := bw.start(w, last); err != nil {
+		return err
+	}
+	return bw.drain(w, 0)
+}
+
+// start hands the block being filled to a new goroutine, first writing
+// out the oldest block if the limit on blocks in flight has been reached.
+func (bw *blockWriter) start(w io.Writer, last bool) error {
+	if err := bw.drain(w, bw.blocks-1); err != nil {
+		return err
+	}
+	b := &block{
+		in:   bw.buf,
+		dict: bw.dict,
+		last: last,
+		done: make(chan struct{}),
+	}
+	go b.compress(bw.level)
+	bw.jobs = append(bw.jobs, b)
+	bw.dict = appendWindow(b.dict, b.in)
+	bw.buf = nil
+	return nil
+}
+
+// drain waits for the oldest blocks and writes their output to w,
+// until at most n blocks remain in flight.
+func (bw *blockWriter) drain(w io.Writer, n int) error {
+	for len(bw.jobs) > n {
+		b := bw.jobs[0]
+		<-b.done
+		bw.jobs[0] = nil
+		bw.jobs = bw.jobs[1:]
+		if b.err != nil {
+			return b.err
+		}
+		if _, err := w.Write(b.out.Bytes()); err != nil {
+			return err
+		}
+		if cap(b.in) == bw.blockSize {
+			bw.free = append(bw.free, b.in[:0])
+		}
+	}
+	return nil
+}
+
+func (bw *blockWriter) newBuf() []byte {
+	if n := len(bw.free); n > 0 {
+		buf := bw.free[n-1]
+		bw.free = bw.free[:n-1]
+		return buf
+	}
+	return make([]byte, 0, bw.blockSize)
+}
+
+// appendWindow returns the last windowSize bytes of dict followed by in,
+// in a newly allocated slice.
+func appendWindow(dict, in []byte) []byte {
+	if len(in) >= windowSize {
+		return append([]byte(nil), in[len(in)-windowSize:]...)
+	}
+	if keep := windowSize - len(in); len(dict) > keep {
+		dict = dict[len(dict)-keep:]
+	}
+	w := make([]byte, 0, len(dict)+len(in))
+	w = append(w, dict...)
+	return append(w, in...)
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import "math/bits"
+
+// A forwardBitReader reads bits from the start of a byte slice,
+// least significant bit first. It is used for FSE table descriptions.
+// Reading past the end of the data yields zero bits; callers check
+// overrun when they are done.
+type forwardBitReader struct {
+	data []byte
+	off  int // number of bits consumed
+}
+
+// peek returns the next n bits without consuming them.
+func (br *forwardBitReader) peek(n uint) uint32 {
+	var v uint32
+	for i := uint(0); i < n; i++ {
+		pos := br.off + int(i)
+		if pos>>3 < len(br.data) && br.data[pos>>3]>>(pos&7)&1 != 0 {
+			v |= 1 << i
+		}
+	}
+	return v
+}
+
+func (br *forwardBitReader) skip(n uint) {
+	br.off += int(n)
+}
+
+func (br *forwardBitReader) read(n uint) uint32 {
+	v := br.peek(n)
+	br.skip(n)
+	return v
+}
+
+// overrun reports whether more bits were consumed than are in the data.
+func (br *forwardBitReader) overrun() bool {
+	return br.off > len(br.data)*8
+}
+
+// bytesRead returns the number of bytes, including partial ones,
+// that contain consumed bits.
+func (br *forwardBitReader) bytesRead() int {
+	return (br.off + 7) >> 3
+}
+
+// A reverseBitReader reads bits from the end of a byte slice towards its
+// start, most significant bit first. This is how FSE and Huffman coded
+// bitstreams are read. The highest set bit of the last byte marks the
+// start of the stream and is not part of the data.
+type reverseBitReader struct {
+	data []byte
+	off  int    // index of the last byte loaded into bits
+	bits uint64 // buffered bits; only the low cnt bits are valid
+	cnt  uint   // number of valid bits in bits
+}
+
+// init prepares to read data.
+// It reports whether data is a valid stream.
+func (br *reverseBitReader) init(data []byte) bool {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return false
+	}
+	last := data[len(data)-1]
+	br.data = data
+	br.off = len(data) - 1
+	br.bits = uint64(last)
+	br.cnt = uint(bits.Len8(last)) - 1
+	return true
+}
+
+// fill buffers at least n bits, if there are that many left.
+// n must be at most 32.
+func (br *reverseBitReader) fill(n uint) {
+	for br.cnt < n && br.off > 0 {
+		br.off--
+		br.bits = br.bits<<8 | uint64(br.data[br.off])
+		br.cnt += 8
+	}
+}
+
+// read reads n bits, which must be at most 32.
+// It reports false if fewer than n bits are left.
+func (br *reverseBitReader) read(n uint) (uint32, bool) {
+	br.fill(n)
+	if br.cnt < n {
+		return 0, false
+	}
+	br.cnt -= n
+	return uint32(br.bits >> br.cnt & (1<<n - 1)), true
+}
+
+// peek returns the next n bits without consuming them,
+// padding with zero bits past the start of the stream.
+func (br *reverseBitReader) peek(n uint) uint32 {
+	br.fill(n)
+	if br.cnt >= n {
+		return uint32(br.bits >> (br.cnt - n) & (1<<n - 1))
+	}
+	return uint32(br.bits << (n - br.cnt) & (1<<n - 1))
+}
+
+// skip consumes n bits after a peek.
+// It reports false if fewer than n bits are left.
+func (br *reverseBitReader) skip(n uint) bool {
+	if br.cnt < n {
+		return false
+	}
+	br.cnt -= n
+	return true
+}
+
+// done reports whether every bit of the stream has been read.
+func (br *reverseBitReader) done() bool {
+	return br.off == 0 && br.cnt == 0
+}
+
+// A bitWriter writes a stream that a reverseBitReader reads backwards:
+// bits are appended least significant bit first, and the last value
+// written is the first one read.
+type bitWriter struct {
+	out  []byte
+	bits uint64
+	cnt  uint
+}
+
+func (bw *bitWriter) reset(out []byte) {
+	bw.out = out
+	bw.bits = 0
+	bw.cnt = 0
+}
+
+// add writes the low n bits of v. n must be at most 32.
+func (bw *bitWriter) add(v uint32, n uint) {
+	bw.bits |= uint64(v) & (1<<n - 1) << bw.cnt
+	bw.cnt += n
+	for bw.cnt >= 8 {
+		bw.out = append(bw.out, byte(bw.bits))
+		bw.bits >>= 8
+		bw.cnt -= 8
+	}
+}
+
+// close writes the end-of-stream marker and any remaining bits,
+// and returns the output.
+func (bw *bitWriter) close() []byte {
+	bw.add(1, 1)
+	if bw.cnt > 0 {
+		bw.out = append(bw.out, byte(bw.bits))
+	}
+	bw.bits = 0
+	bw.cnt = 0
+	return bw.out
+}
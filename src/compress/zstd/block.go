@@ -0,0 +1,406 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import "encoding/binary"
+
+// Block types.
+const (
+	blockRaw        = 0
+	blockRLE        = 1
+	blockCompressed = 2
+)
+
+// Literals block types.
+const (
+	litRaw        = 0
+	litRLE        = 1
+	litCompressed = 2
+	litTreeless   = 3
+)
+
+// Sequence compression modes.
+const (
+	modePredefined = 0
+	modeRLE        = 1
+	modeFSE        = 2
+	modeRepeat     = 3
+)
+
+// A blockDecoder holds the state that carries over from one compressed
+// block to the next within a frame: the entropy tables and the repeat
+// offsets. The tables may point into a dictionary or to the predefined
+// tables, which are never modified; tables read from the input are
+// built in buffers owned by the blockDecoder.
+type blockDecoder struct {
+	huff     []uint16 // Huffman table; nil if none yet
+	huffBits int
+	ll       fseTable
+	of       fseTable
+	ml       fseTable
+	reps     [3]uint32
+
+	huffBuf [1 << maxHuffBits]uint16
+	llBuf   [1 << maxLLLog]fseEntry
+	ofBuf   [1 << maxOFLog]fseEntry
+	mlBuf   [1 << maxMLLog]fseEntry
+	litBuf  []byte
+}
+
+// reset prepares to decode a new frame, starting from the entropy
+// tables and repeat offsets of d, if not nil.
+func (bd *blockDecoder) reset(d *dict) {
+	if d != nil {
+		bd.huff, bd.huffBits = d.huff, d.huffBits
+		bd.ll, bd.of, bd.ml = d.ll, d.of, d.ml
+		bd.reps = d.reps
+		return
+	}
+	bd.huff, bd.huffBits = nil, 0
+	bd.ll, bd.of, bd.ml = fseTable{}, fseTable{}, fseTable{}
+	bd.reps = [3]uint32{1, 4, 8}
+}
+
+// decode decodes the compressed block data, appending the result to
+// out. The data already in out is the history that matches refer to.
+// maxSize is the largest size the block may decompress to.
+// On error decode returns a description of the problem.
+func (bd *blockDecoder) decode(out, data []byte, maxSize int) ([]byte, string) {
+	lits, n, reason := bd.readLiterals(data, maxSize)
+	if reason != "" {
+		return out, reason
+	}
+	data = data[n:]
+
+	if len(data) == 0 {
+		return out, "missing sequences section"
+	}
+	nseq := int(data[0])
+	switch {
+	case nseq == 0:
+		data = data[1:]
+	case nseq < 128:
+		data = data[1:]
+	case nseq < 255:
+		if len(data) < 2 {
+			return out, "truncated sequences header"
+		}
+		nseq = (nseq-128)<<8 + int(data[1])
+		data = data[2:]
+	default:
+		if len(data) < 3 {
+			return out, "truncated sequences header"
+		}
+		nseq = int(binary.LittleEndian.Uint16(data[1:])) + 0x7F00
+		data = data[3:]
+	}
+	if nseq == 0 {
+		if len(data) != 0 {
+			return out, "extra data after sequences header"
+		}
+		if len(lits) > maxSize {
+			return out, "block too large"
+		}
+		return append(out, lits...), ""
+	}
+
+	if len(data) == 0 {
+		return out, "missing sequence compression modes"
+	}
+	modes := data[0]
+	if modes&3 != 0 {
+		return out, "reserved bits set in sequence compression modes"
+	}
+	data = data[1:]
+	n, reason = bd.readTable(&bd.ll, data, modes>>6, maxLLCode, maxLLLog, predefLL, predefLLLog, bd.llBuf[:])
+	if reason != "" {
+		return out, reason
+	}
+	data = data[n:]
+	n, reason = bd.readTable(&bd.of, data, modes>>4&3, maxOFCode, maxOFLog, predefOF, predefOFLog, bd.ofBuf[:])
+	if reason != "" {
+		return out, reason
+	}
+	data = data[n:]
+	n, reason = bd.readTable(&bd.ml, data, modes>>2&3, maxMLCode, maxMLLog, predefML, predefMLLog, bd.mlBuf[:])
+	if reason != "" {
+		return out, reason
+	}
+	data = data[n:]
+
+	return bd.execSequences(out, data, lits, nseq, maxSize)
+}
+
+// readLiterals reads the literals section at the start of data.
+// It returns the literals and the size of the section.
+func (bd *blockDecoder) readLiterals(data []byte, maxSize int) (lits []byte, n int, reason string) {
+	if len(data) == 0 {
+		return nil, 0, "missing literals section"
+	}
+	typ := data[0] & 3
+	sizeFormat := data[0] >> 2 & 3
+
+	if typ == litRaw || typ == litRLE {
+		var size int
+		switch sizeFormat {
+		case 0, 2:
+			size = int(data[0] >> 3)
+			n = 1
+		case 1:
+			if len(data) < 2 {
+				return nil, 0, "truncated literals header"
+			}
+			size = int(data[0]>>4) + int(data[1])<<4
+			n = 2
+		case 3:
+			if len(data) < 3 {
+				return nil, 0, "truncated literals header"
+			}
+			size = int(data[0]>>4) + int(data[1])<<4 + int(data[2])<<12
+			n = 3
+		}
+		if size > maxSize {
+			return nil, 0, "literals too large"
+		}
+		if typ == litRaw {
+			if n+size > len(data) {
+				return nil, 0, "truncated literals"
+			}
+			return data[n : n+size], n + size, ""
+		}
+		if n >= len(data) {
+			return nil, 0, "truncated literals"
+		}
+		lits = bd.litBuffer(size)
+		for i := range lits {
+			lits[i] = data[n]
+		}
+		return lits, n + 1, ""
+	}
+
+	var regen, comp int
+	streams := 4
+	switch sizeFormat {
+	case 0, 1:
+		if len(data) < 3 {
+			return nil, 0, "truncated literals header"
+		}
+		v := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+		regen = int(v >> 4 & 0x3ff)
+		comp = int(v >> 14 & 0x3ff)
+		n = 3
+		if sizeFormat == 0 {
+			streams = 1
+		}
+	case 2:
+		if len(data) < 4 {
+			return nil, 0, "truncated literals header"
+		}
+		v := binary.LittleEndian.Uint32(data)
+		regen = int(v >> 4 & 0x3fff)
+		comp = int(v >> 18)
+		n = 4
+	case 3:
+		if len(data) < 5 {
+			return nil, 0, "truncated literals header"
+		}
+		v := uint64(binary.LittleEndian.Uint32(data)) | uint64(data[4])<<32
+		regen = int(v >> 4 & 0x3ffff)
+		comp = int(v >> 22 & 0x3ffff)
+		n = 5
+	}
+	if regen > maxSize {
+		return nil, 0, "literals too large"
+	}
+	if n+comp > len(data) {
+		return nil, 0, "truncated literals"
+	}
+	src := data[n : n+comp]
+	n += comp
+
+	if typ == litCompressed {
+		tableBits, tn, reason := readHuff(src, bd.huffBuf[:])
+		if reason != "" {
+			return nil, 0, reason
+		}
+		bd.huff, bd.huffBits = bd.huffBuf[:], tableBits
+		src = src[tn:]
+	} else if bd.huff == nil {
+		return nil, 0, "treeless literals without a previous Huffman table"
+	}
+
+	lits = bd.litBuffer(regen)
+	var ok bool
+	if streams == 1 {
+		ok = decodeHuff(lits, src, bd.huff, bd.huffBits)
+	} else {
+		ok = decodeHuff4(lits, src, bd.huff, bd.huffBits)
+	}
+	if !ok {
+		return nil, 0, "invalid Huffman-coded literals"
+	}
+	return lits, n, ""
+}
+
+// litBuffer returns a buffer for n literals.
+func (bd *blockDecoder) litBuffer(n int) []byte {
+	if cap(bd.litBuf) < n {
+		bd.litBuf = make([]byte, n, maxBlockSize)
+	}
+	return bd.litBuf[:n]
+}
+
+// readTable reads the description of a sequence decoding table with
+// the given mode from the start of data into t, and returns the number
+// of bytes read.
+func (bd *blockDecoder) readTable(t *fseTable, data []byte, mode uint8, maxSym, maxLog int, predef []fseEntry, predefLog int, buf []fseEntry) (int, string) {
+	switch mode {
+	case modePredefined:
+		*t = fseTable{log: predefLog, table: predef}
+		return 0, ""
+	case modeRLE:
+		if len(data) == 0 {
+			return 0, "truncated RLE sequence table"
+		}
+		if int(data[0]) > maxSym {
+			return 0, "invalid RLE sequence code"
+		}
+		buf[0] = fseEntry{sym: data[0]}
+		*t = fseTable{log: 0, table: buf[:1]}
+		return 1, ""
+	case modeFSE:
+		var norm [maxMLCode + 1]int16
+		tableLog, n, reason := readFSE(data, maxSym, maxLog, norm[:maxSym+1])
+		if reason != "" {
+			return 0, reason
+		}
+		if !buildFSE(norm[:maxSym+1], tableLog, buf) {
+			return 0, "invalid sequence FSE table"
+		}
+		*t = fseTable{log: tableLog, table: buf[:1<<tableLog]}
+		return n, ""
+	default:
+		if t.table == nil {
+			return 0, "repeated sequence table without a previous table"
+		}
+		return 0, ""
+	}
+}
+
+// execSequences decodes nseq sequences from the bitstream data and
+// executes them, appending the literals and matches to out.
+func (bd *blockDecoder) execSequences(out, data, lits []byte, nseq, maxSize int) ([]byte, string) {
+	var br reverseBitReader
+	if !br.init(data) {
+		return out, "invalid sequences bitstream"
+	}
+	llState, ok1 := br.read(uint(bd.ll.log))
+	ofState, ok2 := br.read(uint(bd.of.log))
+	mlState, ok3 := br.read(uint(bd.ml.log))
+	if !ok1 || !ok2 || !ok3 {
+		return out, "truncated sequences bitstream"
+	}
+
+	start := len(out)
+	for i := 0; i < nseq; i++ {
+		llEntry := bd.ll.table[llState]
+		ofEntry := bd.of.table[ofState]
+		mlEntry := bd.ml.table[mlState]
+
+		code := ofEntry.sym
+		if code > maxOFCode {
+			return out, "invalid offset code"
+		}
+		v, ok := br.read(uint(code))
+		if !ok {
+			return out, "truncated sequences bitstream"
+		}
+		ofValue := 1<<code + v
+
+		code = mlEntry.sym
+		v, ok = br.read(uint(mlBits[code]))
+		if !ok {
+			return out, "truncated sequences bitstream"
+		}
+		ml := mlBase[code] + v
+
+		code = llEntry.sym
+		v, ok = br.read(uint(llBits[code]))
+		if !ok {
+			return out, "truncated sequences bitstream"
+		}
+		ll := llBase[code] + v
+
+		var offset uint32
+		if ofValue > 3 {
+			offset = ofValue - 3
+			bd.reps = [3]uint32{offset, bd.reps[0], bd.reps[1]}
+		} else {
+			idx := ofValue
+			if ll == 0 {
+				idx++
+			}
+			switch idx {
+			case 1:
+				offset = bd.reps[0]
+			case 2:
+				offset = bd.reps[1]
+				bd.reps = [3]uint32{offset, bd.reps[0], bd.reps[2]}
+			case 3:
+				offset = bd.reps[2]
+				bd.reps = [3]uint32{offset, bd.reps[0], bd.reps[1]}
+			case 4:
+				offset = bd.reps[0] - 1
+				if offset == 0 {
+					return out, "invalid repeat offset"
+				}
+				bd.reps = [3]uint32{offset, bd.reps[0], bd.reps[1]}
+			}
+		}
+
+		if int(ll) > len(lits) {
+			return out, "literals length exceeds literals"
+		}
+		if len(out)-start+int(ll)+int(ml) > maxSize {
+			return out, "block too large"
+		}
+		out = append(out, lits[:ll]...)
+		lits = lits[ll:]
+		if int(offset) > len(out) {
+			return out, "match offset beyond history"
+		}
+		// Copy the match, which may overlap the bytes being written.
+		from := len(out) - int(offset)
+		for m := int(ml); m > 0; {
+			n := m
+			if n > int(offset) {
+				n = int(offset)
+			}
+			out = append(out, out[from:from+n]...)
+			from += n
+			m -= n
+		}
+
+		if i == nseq-1 {
+			break
+		}
+		v, ok1 = br.read(uint(llEntry.bits))
+		llState = uint32(llEntry.base) + v
+		v, ok2 = br.read(uint(mlEntry.bits))
+		mlState = uint32(mlEntry.base) + v
+		v, ok3 = br.read(uint(ofEntry.bits))
+		ofState = uint32(ofEntry.base) + v
+		if !ok1 || !ok2 || !ok3 {
+			return out, "truncated sequences bitstream"
+		}
+	}
+	if !br.done() {
+		return out, "extra bits in sequences bitstream"
+	}
+	if len(out)-start+len(lits) > maxSize {
+		return out, "block too large"
+	}
+	return append(out, lits...), ""
+}
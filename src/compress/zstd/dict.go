@@ -0,0 +1,86 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// A dict is a parsed dictionary, RFC 8878 section 5.
+//
+// A dictionary in the standard format starts with a magic number and
+// an ID, followed by entropy tables and repeat offsets that frames
+// using the dictionary start with, and the content that their matches
+// may refer to. Any other data is used as raw content, with an ID of 0
+// and the default tables and repeat offsets.
+type dict struct {
+	id      uint32
+	content []byte
+
+	huff     []uint16
+	huffBits int
+	ll       fseTable
+	of       fseTable
+	ml       fseTable
+	reps     [3]uint32
+}
+
+var errDictionaryFormat = errors.New("zstd: invalid dictionary")
+
+// parseDict parses the dictionary data.
+// The returned dict refers to data, which must not be modified.
+func parseDict(data []byte) (*dict, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint32(data) != dictMagic {
+		return &dict{content: data, reps: [3]uint32{1, 4, 8}}, nil
+	}
+	d := &dict{id: binary.LittleEndian.Uint32(data[4:])}
+	if d.id == 0 {
+		return nil, errDictionaryFormat
+	}
+	data = data[8:]
+
+	d.huff = make([]uint16, 1<<maxHuffBits)
+	tableBits, n, reason := readHuff(data, d.huff)
+	if reason != "" {
+		return nil, errDictionaryFormat
+	}
+	d.huffBits = tableBits
+	data = data[n:]
+
+	for _, t := range []struct {
+		t      *fseTable
+		maxSym int
+		maxLog int
+	}{
+		{&d.of, maxOFCode, maxOFLog},
+		{&d.ml, maxMLCode, maxMLLog},
+		{&d.ll, maxLLCode, maxLLLog},
+	} {
+		norm := make([]int16, t.maxSym+1)
+		tableLog, n, reason := readFSE(data, t.maxSym, t.maxLog, norm)
+		if reason != "" {
+			return nil, errDictionaryFormat
+		}
+		table := make([]fseEntry, 1<<tableLog)
+		if !buildFSE(norm, tableLog, table) {
+			return nil, errDictionaryFormat
+		}
+		*t.t = fseTable{log: tableLog, table: table}
+		data = data[n:]
+	}
+
+	if len(data) < 12 {
+		return nil, errDictionaryFormat
+	}
+	for i := range d.reps {
+		d.reps[i] = binary.LittleEndian.Uint32(data[4*i:])
+		if d.reps[i] == 0 || int(d.reps[i]) > len(data)-12 {
+			return nil, errDictionaryFormat
+		}
+	}
+	d.content = data[12:]
+	return d, nil
+}
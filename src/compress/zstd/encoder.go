@@ -0,0 +1,389 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// levelParams are the match finding parameters of a compression level.
+type levelParams struct {
+	lookback int  // maximum distance to a match from the start of a block
+	depth    int  // maximum number of hash chain entries to try
+	nice     int  // stop searching at a match at least this long
+	lazy     bool // check whether the next position has a longer match
+}
+
+var levels = [...]levelParams{
+	1: {1 << 16, 1, 32, false},
+	2: {1 << 17, 2, 64, false},
+	3: {1 << 17, 4, 128, false},
+	4: {1 << 18, 8, 128, true},
+	5: {1 << 18, 16, 192, true},
+	6: {1 << 19, 32, 256, true},
+	7: {1 << 19, 64, 512, true},
+	8: {1 << 20, 128, 1024, true},
+	9: {1 << 20, 256, 4096, true},
+}
+
+// windowLog returns the base 2 logarithm of the window a decoder needs:
+// a match may reach lookback bytes before the start of a block, from
+// any position within the block.
+func (p *levelParams) windowLog() int {
+	return bits.Len(uint(p.lookback + maxBlockSize - 1))
+}
+
+const (
+	minMatch = 4
+	hashLog  = 17
+)
+
+func hash4(u uint32) uint32 {
+	return (u * 2654435761) >> (32 - hashLog)
+}
+
+// A sequence is a run of literals followed by a match.
+type sequence struct {
+	litLen   uint32
+	matchLen uint32
+	offset   uint32
+}
+
+// An encoder compresses blocks. Each block is compressed independently
+// of how the blocks before it were compressed, so that several encoders
+// can compress consecutive blocks concurrently.
+type encoder struct {
+	p     levelParams
+	head  []int32 // most recent position with each hash, or -1
+	chain []int32 // previous position with the same hash, or -1
+	next  int     // next position to insert into the hash chains
+	lo    int     // position that head and chain entries are relative to
+
+	// litCost is the estimated cost of a literal in 1/16ths of a bit.
+	litCost int
+
+	lits []byte
+	seqs []sequence
+	huff huffEncoder
+	tmp  []byte
+}
+
+func newEncoder(p levelParams) *encoder {
+	return &encoder{p: p, head: make([]int32, 1<<hashLog)}
+}
+
+// encodeBlock appends to dst the compressed block holding src[start:end],
+// which must be at most maxBlockSize bytes. Matches may refer to the
+// history in src[:start] up to lookback bytes before start.
+func (e *encoder) encodeBlock(dst, src []byte, start, end int, last bool) []byte {
+	hdr := len(dst)
+	dst = append(dst, 0, 0, 0)
+	block := src[start:end]
+
+	if len(block) > 0 && isRLE(block) {
+		dst = append(dst[:hdr], blockHeader(last, blockRLE, len(block))...)
+		return append(dst, block[0])
+	}
+
+	e.findSequences(src, start, end)
+	dst = e.writeLiterals(dst)
+	dst = e.writeSequences(dst)
+	if n := len(dst) - hdr - 3; n < len(block) {
+		copy(dst[hdr:], blockHeader(last, blockCompressed, n))
+		return dst
+	}
+	dst = append(dst[:hdr], blockHeader(last, blockRaw, len(block))...)
+	return append(dst, block...)
+}
+
+func blockHeader(last bool, typ, size int) []byte {
+	v := typ<<1 | size<<3
+	if last {
+		v |= 1
+	}
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16)}
+}
+
+// isRLE reports whether all bytes of b are the same.
+func isRLE(b []byte) bool {
+	for _, c := range b[1:] {
+		if c != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// findSequences splits src[start:end] into the sequences e.seqs
+// and the literals e.lits.
+func (e *encoder) findSequences(src []byte, start, end int) {
+	e.lits = e.lits[:0]
+	e.seqs = e.seqs[:0]
+
+	e.lo = start - e.p.lookback
+	if e.lo < 0 {
+		e.lo = 0
+	}
+	e.next = e.lo
+	for i := range e.head {
+		e.head[i] = -1
+	}
+	if n := end - e.lo; cap(e.chain) < n {
+		e.chain = make([]int32, n)
+	} else {
+		e.chain = e.chain[:n]
+	}
+	e.insertTo(src, start, end)
+	e.litCost = literalCost(src[start:end])
+
+	litStart := start
+	for p := start; p+minMatch <= end; {
+		off, n := e.findMatch(src, p, end)
+		e.insertTo(src, p+1, end)
+		if !e.worthwhile(off, n) {
+			p++
+			continue
+		}
+		if e.p.lazy && n < e.p.nice && p+1+minMatch <= end {
+			if off2, n2 := e.findMatch(src, p+1, end); n2 > n && e.worthwhile(off2, n2) {
+				e.insertTo(src, p+2, end)
+				p, off, n = p+1, off2, n2
+			}
+		}
+		e.lits = append(e.lits, src[litStart:p]...)
+		e.seqs = append(e.seqs, sequence{
+			litLen:   uint32(p - litStart),
+			matchLen: uint32(n),
+			offset:   uint32(off),
+		})
+		p += n
+		e.insertTo(src, p, end)
+		litStart = p
+	}
+	e.lits = append(e.lits, src[litStart:end]...)
+}
+
+// literalCost estimates the cost of each literal in b, in 1/16ths of
+// a bit, as the entropy of the bytes of b.
+func literalCost(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	var hist [256]int
+	for _, c := range b {
+		hist[c]++
+	}
+	var bits float64
+	for _, c := range hist {
+		if c > 0 {
+			bits += float64(c) * math.Log2(float64(len(b))/float64(c))
+		}
+	}
+	return int(16*bits)/len(b) + 1
+}
+
+// worthwhile reports whether a match of length n at the offset costs
+// fewer bits than coding its bytes as literals. The cost of a match is
+// its offset's extra bits plus a rough allowance for its codes.
+func (e *encoder) worthwhile(offset, n int) bool {
+	if n < minMatch {
+		return false
+	}
+	cost := 16 * (bits.Len(uint(offset+3)) + 12)
+	return cost < n*e.litCost
+}
+
+// insertTo adds the positions before q to the hash chains.
+func (e *encoder) insertTo(src []byte, q, end int) {
+	if q > end-minMatch+1 {
+		q = end - minMatch + 1
+	}
+	for ; e.next < q; e.next++ {
+		h := hash4(binary.LittleEndian.Uint32(src[e.next:]))
+		e.chain[e.next-e.lo] = e.head[h]
+		e.head[h] = int32(e.next - e.lo)
+	}
+}
+
+// findMatch returns the offset and length of the longest match for the
+// data at p found in the hash chains, which must hold the positions
+// before p. The match is at most end-p bytes long.
+func (e *encoder) findMatch(src []byte, p, end int) (offset, length int) {
+	max := end - p
+	cand := e.head[hash4(binary.LittleEndian.Uint32(src[p:]))]
+	for depth := e.p.depth; cand >= 0 && depth > 0; depth-- {
+		c := e.lo + int(cand)
+		if src[c+length] == src[p+length] {
+			if n := matchLen(src[c:end], src[p:end]); n > length {
+				offset, length = p-c, n
+				if n >= e.p.nice || n == max {
+					break
+				}
+			}
+		}
+		cand = e.chain[cand]
+	}
+	return offset, length
+}
+
+// matchLen returns the length of the common prefix of a and b.
+func matchLen(a, b []byte) int {
+	n := 0
+	for len(b)-n >= 8 {
+		if x := binary.LittleEndian.Uint64(a[n:]) ^ binary.LittleEndian.Uint64(b[n:]); x != 0 {
+			return n + bits.TrailingZeros64(x)>>3
+		}
+		n += 8
+	}
+	for n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// writeLiterals appends the literals section for e.lits.
+func (e *encoder) writeLiterals(dst []byte) []byte {
+	lits := e.lits
+	n := len(lits)
+	if n > 0 && isRLE(lits) {
+		return append(appendLiteralsHeader(dst, litRLE, n), lits[0])
+	}
+	if n >= 64 {
+		if out, ok := e.huffLiterals(dst, lits); ok {
+			return out
+		}
+	}
+	return append(appendLiteralsHeader(dst, litRaw, n), lits...)
+}
+
+// appendLiteralsHeader appends the header of raw or RLE literals.
+func appendLiteralsHeader(dst []byte, typ, n int) []byte {
+	switch {
+	case n < 1<<5:
+		return append(dst, byte(typ|n<<3))
+	case n < 1<<12:
+		v := typ | 1<<2 | n<<4
+		return append(dst, byte(v), byte(v>>8))
+	default:
+		v := typ | 3<<2 | n<<4
+		return append(dst, byte(v), byte(v>>8), byte(v>>16))
+	}
+}
+
+// huffLiterals appends Huffman-coded literals, and reports whether
+// they are smaller than raw literals.
+func (e *encoder) huffLiterals(dst, lits []byte) ([]byte, bool) {
+	var hist [256]uint32
+	for _, c := range lits {
+		hist[c]++
+	}
+	if !e.huff.build(&hist) {
+		return dst, false
+	}
+
+	// Encode the tree and streams into tmp first,
+	// since the header depends on their size.
+	body := e.huff.writeTree(e.tmp[:0])
+	streams := 1
+	if len(lits) < 1024 {
+		body = e.huff.encode(body, lits)
+	} else {
+		streams = 4
+		jump := len(body)
+		body = append(body, 0, 0, 0, 0, 0, 0)
+		seg := (len(lits) + 3) / 4
+		for i := 0; i < 4; i++ {
+			s := lits[i*seg:]
+			if i < 3 {
+				s = s[:seg]
+			}
+			n := len(body)
+			body = e.huff.encode(body, s)
+			if i < 3 {
+				size := len(body) - n
+				if size > 0xffff {
+					e.tmp = body
+					return dst, false
+				}
+				binary.LittleEndian.PutUint16(body[jump+2*i:], uint16(size))
+			}
+		}
+	}
+	e.tmp = body
+
+	regen, comp := len(lits), len(body)
+	var hdr []byte
+	switch {
+	case regen < 1<<10 && comp < 1<<10:
+		sizeFormat := 1
+		if streams == 1 {
+			sizeFormat = 0
+		}
+		v := uint32(litCompressed) | uint32(sizeFormat)<<2 | uint32(regen)<<4 | uint32(comp)<<14
+		hdr = []byte{byte(v), byte(v >> 8), byte(v >> 16)}
+	case streams == 1:
+		return dst, false
+	case regen < 1<<14 && comp < 1<<14:
+		v := uint32(litCompressed) | 2<<2 | uint32(regen)<<4 | uint32(comp)<<18
+		hdr = []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+	default:
+		v := uint64(litCompressed) | 3<<2 | uint64(regen)<<4 | uint64(comp)<<22
+		hdr = []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24), byte(v >> 32)}
+	}
+	if len(hdr)+comp >= len(appendLiteralsHeader(nil, litRaw, regen))+regen {
+		return dst, false
+	}
+	dst = append(dst, hdr...)
+	return append(dst, body...), true
+}
+
+// writeSequences appends the sequences section for e.seqs,
+// coded with the predefined distributions.
+func (e *encoder) writeSequences(dst []byte) []byte {
+	seqs := e.seqs
+	n := len(seqs)
+	switch {
+	case n < 128:
+		dst = append(dst, byte(n))
+	case n < 0x7F00:
+		dst = append(dst, byte(n>>8+128), byte(n))
+	default:
+		dst = append(dst, 255, byte(n-0x7F00), byte((n-0x7F00)>>8))
+	}
+	if n == 0 {
+		return dst
+	}
+	dst = append(dst, modePredefined<<6|modePredefined<<4|modePredefined<<2)
+
+	// The decoder reads the bitstream backwards,
+	// so write the sequences from last to first.
+	var bw bitWriter
+	bw.reset(dst)
+	var llState, mlState, ofState fseState
+	for i := n - 1; i >= 0; i-- {
+		s := seqs[i]
+		llc, llExtra := llCode(s.litLen)
+		mlc, mlExtra := mlCode(s.matchLen)
+		ofc, ofExtra := ofCode(s.offset + 3)
+		if i == n-1 {
+			mlState.init(predefMLEnc, mlc)
+			ofState.init(predefOFEnc, ofc)
+			llState.init(predefLLEnc, llc)
+		} else {
+			ofState.encode(&bw, ofc)
+			mlState.encode(&bw, mlc)
+			llState.encode(&bw, llc)
+		}
+		bw.add(llExtra, uint(llBits[llc]))
+		bw.add(mlExtra, uint(mlBits[mlc]))
+		bw.add(ofExtra, uint(ofc))
+	}
+	mlState.flush(&bw)
+	ofState.flush(&bw)
+	llState.flush(&bw)
+	return bw.close()
+}
@@ -0,0 +1,248 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import "math/bits"
+
+// Finite State Entropy (FSE) coding, RFC 8878 section 4.1.
+
+// readFSE reads an FSE table description from the start of data, for
+// symbols up to maxSym and an accuracy log up to maxLog. It stores the
+// normalized counts in norm, which must have room for maxSym+1 entries,
+// and returns the accuracy log and the number of bytes read.
+// A normalized count of -1 means "less than 1".
+func readFSE(data []byte, maxSym, maxLog int, norm []int16) (tableLog, n int, reason string) {
+	br := forwardBitReader{data: data}
+	tableLog = int(br.read(4)) + 5
+	if tableLog > maxLog {
+		return 0, 0, "FSE accuracy log too large"
+	}
+
+	remaining := (1 << tableLog) + 1
+	threshold := 1 << tableLog
+	nbBits := uint(tableLog + 1)
+	sym := 0
+	prev0 := false
+	for remaining > 1 && sym <= maxSym {
+		if prev0 {
+			// A count of zero is followed by a 2-bit repeat count
+			// of further zero counts; 3 means that more follow.
+			n0 := sym
+			for br.peek(2) == 3 {
+				n0 += 3
+				br.skip(2)
+				if n0 > maxSym+1 || br.overrun() {
+					return 0, 0, "FSE zero count repeat overflow"
+				}
+			}
+			n0 += int(br.read(2))
+			if n0 > maxSym+1 {
+				return 0, 0, "FSE zero count repeat overflow"
+			}
+			for ; sym < n0; sym++ {
+				norm[sym] = 0
+			}
+			if sym > maxSym {
+				break
+			}
+		}
+
+		max := (2*threshold - 1) - remaining
+		var count int
+		if v := int(br.peek(nbBits - 1)); v < max {
+			count = v
+			br.skip(nbBits - 1)
+		} else {
+			count = int(br.peek(nbBits))
+			if count >= threshold {
+				count -= max
+			}
+			br.skip(nbBits)
+		}
+		count--
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+		if remaining < 1 {
+			return 0, 0, "FSE counts exceed table size"
+		}
+		norm[sym] = int16(count)
+		sym++
+		prev0 = count == 0
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	if remaining != 1 || br.overrun() {
+		return 0, 0, "invalid FSE table description"
+	}
+	for ; sym <= maxSym; sym++ {
+		norm[sym] = 0
+	}
+	return tableLog, br.bytesRead(), ""
+}
+
+// An fseEntry is an entry in an FSE decoding table.
+type fseEntry struct {
+	sym  uint8  // symbol decoded in this state
+	bits uint8  // number of bits to read for the next state
+	base uint16 // the next state is base plus the bits read
+}
+
+// spreadFSE assigns a symbol to each state of a table with the given
+// normalized counts, storing the symbols in syms.
+// It reports whether the counts are valid for the table size.
+func spreadFSE(norm []int16, tableLog int, syms []uint8) bool {
+	size := 1 << tableLog
+	high := size - 1
+	for s, c := range norm {
+		if c == -1 {
+			syms[high] = uint8(s)
+			high--
+		}
+	}
+	mask := size - 1
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, c := range norm {
+		for i := 0; i < int(c); i++ {
+			syms[pos] = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+	return pos == 0
+}
+
+// buildFSE builds the decoding table for the normalized counts norm
+// into table, which must have room for 1<<tableLog entries.
+// It reports whether the counts are valid.
+func buildFSE(norm []int16, tableLog int, table []fseEntry) bool {
+	size := 1 << tableLog
+	var syms [1 << maxFSELog]uint8
+	if !spreadFSE(norm, tableLog, syms[:size]) {
+		return false
+	}
+	var next [256]uint16
+	for s, c := range norm {
+		if c == -1 {
+			next[s] = 1
+		} else {
+			next[s] = uint16(c)
+		}
+	}
+	for u := 0; u < size; u++ {
+		s := syms[u]
+		ns := next[s]
+		next[s]++
+		nb := tableLog + 1 - bits.Len16(ns)
+		table[u] = fseEntry{
+			sym:  s,
+			bits: uint8(nb),
+			base: ns<<nb - uint16(size),
+		}
+	}
+	return true
+}
+
+// maxFSELog is the largest accuracy log of any FSE table.
+const maxFSELog = 9
+
+// An fseEncoder is an FSE encoding table, built from the same
+// normalized counts as the corresponding decoding table.
+type fseEncoder struct {
+	tableLog   uint
+	stateTable []uint16
+	symTT      []fseSymbolTransform
+}
+
+type fseSymbolTransform struct {
+	deltaNbBits    uint32
+	deltaFindState int32
+}
+
+func newFSEEncoder(norm []int16, tableLog int) *fseEncoder {
+	size := 1 << tableLog
+	syms := make([]uint8, size)
+	if !spreadFSE(norm, tableLog, syms) {
+		panic("zstd: invalid FSE table")
+	}
+	e := &fseEncoder{
+		tableLog:   uint(tableLog),
+		stateTable: make([]uint16, size),
+		symTT:      make([]fseSymbolTransform, len(norm)),
+	}
+
+	// Each symbol owns a range of the state table, in order of
+	// symbol value, holding the states that decode to the symbol.
+	cumul := make([]int, len(norm)+1)
+	for s, c := range norm {
+		if c == -1 {
+			c = 1
+		}
+		cumul[s+1] = cumul[s] + int(c)
+	}
+	for u := 0; u < size; u++ {
+		s := syms[u]
+		e.stateTable[cumul[s]] = uint16(size + u)
+		cumul[s]++
+	}
+
+	total := int32(0)
+	for s, c := range norm {
+		tt := &e.symTT[s]
+		switch c {
+		case 0:
+			tt.deltaNbBits = uint32(tableLog+1)<<16 - uint32(size)
+		case -1, 1:
+			tt.deltaNbBits = uint32(tableLog)<<16 - uint32(size)
+			tt.deltaFindState = total - 1
+			total++
+		default:
+			maxBitsOut := uint32(tableLog - (bits.Len16(uint16(c-1)) - 1))
+			minStatePlus := uint32(c) << maxBitsOut
+			tt.deltaNbBits = maxBitsOut<<16 - minStatePlus
+			tt.deltaFindState = total - int32(c)
+			total += int32(c)
+		}
+	}
+	return e
+}
+
+// An fseState is the state of an FSE encoder.
+// Symbols are encoded in the reverse of the order they are decoded.
+type fseState struct {
+	enc   *fseEncoder
+	value uint32
+}
+
+// init sets the state to one that decodes to sym,
+// the last symbol to be decoded.
+func (st *fseState) init(enc *fseEncoder, sym uint8) {
+	st.enc = enc
+	tt := enc.symTT[sym]
+	nbBitsOut := (tt.deltaNbBits + 1<<15) >> 16
+	v := nbBitsOut<<16 - tt.deltaNbBits
+	st.value = uint32(enc.stateTable[int32(v>>nbBitsOut)+tt.deltaFindState])
+}
+
+// encode writes the bits that lead from the state for sym
+// to the current state, and moves to the state for sym.
+func (st *fseState) encode(bw *bitWriter, sym uint8) {
+	tt := st.enc.symTT[sym]
+	nbBitsOut := (st.value + tt.deltaNbBits) >> 16
+	bw.add(st.value, uint(nbBitsOut))
+	st.value = uint32(st.enc.stateTable[int32(st.value>>nbBitsOut)+tt.deltaFindState])
+}
+
+// flush writes the final state, which the decoder reads first.
+func (st *fseState) flush(bw *bitWriter) {
+	bw.add(st.value, st.enc.tableLog)
+}
@@ -0,0 +1,369 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+// Huffman coding of literals, RFC 8878 section 4.2.
+
+// maxHuffBits is the maximum length of a Huffman code.
+const maxHuffBits = 11
+
+// A Huffman decoding table has 1<<tableBits entries. Each entry holds
+// the symbol in its high 8 bits and the code length in its low 8 bits,
+// and is indexed by the next tableBits bits of the stream.
+
+// readHuff reads a Huffman tree description from the start of data
+// and builds its decoding table into table, which must have room for
+// 1<<maxHuffBits entries. It returns the table's bit width and the
+// number of bytes read.
+func readHuff(data []byte, table []uint16) (tableBits, n int, reason string) {
+	if len(data) == 0 {
+		return 0, 0, "missing Huffman tree description"
+	}
+	var weights [256]uint8
+	var count int
+	hb := int(data[0])
+	if hb < 128 {
+		// The weights are FSE compressed in the next hb bytes.
+		if hb == 0 || 1+hb > len(data) {
+			return 0, 0, "invalid Huffman weights size"
+		}
+		var err string
+		count, err = readHuffWeightsFSE(data[1:1+hb], weights[:])
+		if err != "" {
+			return 0, 0, err
+		}
+		n = 1 + hb
+	} else {
+		// The weights are stored directly, two to a byte.
+		count = hb - 127
+		n = 1 + (count+1)/2
+		if n > len(data) {
+			return 0, 0, "truncated Huffman weights"
+		}
+		for i := 0; i < count; i++ {
+			b := data[1+i/2]
+			if i%2 == 0 {
+				b >>= 4
+			}
+			weights[i] = b & 0xf
+		}
+	}
+
+	// The weight of the last symbol is implied by the others:
+	// the weights must describe a complete tree.
+	total := uint32(0)
+	for _, w := range weights[:count] {
+		if w > maxHuffBits {
+			return 0, 0, "Huffman weight too large"
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return 0, 0, "no Huffman weights"
+	}
+	tableBits = bits.Len32(total)
+	if tableBits > maxHuffBits {
+		return 0, 0, "Huffman table too large"
+	}
+	rest := uint32(1)<<tableBits - total
+	if rest&(rest-1) != 0 {
+		return 0, 0, "incomplete Huffman tree"
+	}
+	weights[count] = uint8(bits.Len32(rest))
+	count++
+
+	// Assign each symbol a run of table entries, lowest weights
+	// (longest codes) first, in order of symbol value.
+	var start [maxHuffBits + 2]uint32
+	for _, w := range weights[:count] {
+		if w > 0 {
+			start[w] += 1 << (w - 1)
+		}
+	}
+	next := uint32(0)
+	for w := range start {
+		n := start[w]
+		start[w] = next
+		next += n
+	}
+	for s, w := range weights[:count] {
+		if w == 0 {
+			continue
+		}
+		e := uint16(s)<<8 | uint16(tableBits+1-int(w))
+		for i := start[w]; i < start[w]+1<<(w-1); i++ {
+			table[i] = e
+		}
+		start[w] += 1 << (w - 1)
+	}
+	return tableBits, n, ""
+}
+
+// readHuffWeightsFSE decodes FSE compressed Huffman weights.
+// It returns the number of weights decoded.
+func readHuffWeightsFSE(data []byte, weights []uint8) (int, string) {
+	var norm [maxHuffBits + 2]int16
+	tableLog, n, err := readFSE(data, len(norm)-1, 6, norm[:])
+	if err != "" {
+		return 0, err
+	}
+	var table [1 << 6]fseEntry
+	if !buildFSE(norm[:], tableLog, table[:]) {
+		return 0, "invalid Huffman weights FSE table"
+	}
+
+	// Two interleaved states share one bitstream.
+	// When it runs out, the other state supplies the last weight.
+	var br reverseBitReader
+	if !br.init(data[n:]) {
+		return 0, "invalid Huffman weights bitstream"
+	}
+	s1, ok1 := br.read(uint(tableLog))
+	s2, ok2 := br.read(uint(tableLog))
+	if !ok1 || !ok2 {
+		return 0, "truncated Huffman weights bitstream"
+	}
+	count := 0
+	for {
+		if count >= 254 {
+			return 0, "too many Huffman weights"
+		}
+		e := table[s1]
+		weights[count] = e.sym
+		count++
+		v, ok := br.read(uint(e.bits))
+		if !ok {
+			weights[count] = table[s2].sym
+			return count + 1, ""
+		}
+		s1 = uint32(e.base) + v
+
+		e = table[s2]
+		weights[count] = e.sym
+		count++
+		v, ok = br.read(uint(e.bits))
+		if !ok {
+			weights[count] = table[s1].sym
+			return count + 1, ""
+		}
+		s2 = uint32(e.base) + v
+	}
+}
+
+// decodeHuff decodes a single Huffman coded stream filling out.
+func decodeHuff(out, data []byte, table []uint16, tableBits int) bool {
+	var br reverseBitReader
+	if !br.init(data) {
+		return false
+	}
+	tb := uint(tableBits)
+	for i := range out {
+		e := table[br.peek(tb)]
+		out[i] = byte(e >> 8)
+		if !br.skip(uint(e & 0xff)) {
+			return false
+		}
+	}
+	return br.done()
+}
+
+// decodeHuff4 decodes four Huffman coded streams filling out,
+// with a jump table giving the sizes of the first three.
+func decodeHuff4(out, data []byte, table []uint16, tableBits int) bool {
+	if len(data) < 6 {
+		return false
+	}
+	var sizes [4]int
+	sizes[0] = int(binary.LittleEndian.Uint16(data[0:]))
+	sizes[1] = int(binary.LittleEndian.Uint16(data[2:]))
+	sizes[2] = int(binary.LittleEndian.Uint16(data[4:]))
+	sizes[3] = len(data) - 6 - sizes[0] - sizes[1] - sizes[2]
+	if sizes[3] < 1 {
+		return false
+	}
+	seg := (len(out) + 3) / 4
+	if 3*seg > len(out) {
+		return false
+	}
+	data = data[6:]
+	for i, size := range sizes {
+		o := out[i*seg:]
+		if i < 3 {
+			o = o[:seg]
+		}
+		if !decodeHuff(o, data[:size], table, tableBits) {
+			return false
+		}
+		data = data[size:]
+	}
+	return true
+}
+
+// A huffEncoder holds the Huffman code for a set of literals.
+type huffEncoder struct {
+	maxSym int // largest symbol with a code
+	codes  [256]uint16
+	lens   [256]uint8
+}
+
+// build computes a Huffman code for literals with the symbol counts in
+// hist. It reports false if the literals cannot usefully be Huffman
+// coded: they use a single symbol, or a symbol too large to describe
+// with directly stored weights.
+func (he *huffEncoder) build(hist *[256]uint32) bool {
+	maxSym, nsyms := 0, 0
+	for s, c := range hist {
+		if c > 0 {
+			maxSym = s
+			nsyms++
+		}
+	}
+	if nsyms < 2 || maxSym > 128 {
+		return false
+	}
+	he.maxSym = maxSym
+
+	var counts [256]uint32
+	copy(counts[:], hist[:])
+	for {
+		if huffLengths(counts[:maxSym+1], he.lens[:maxSym+1]) <= maxHuffBits {
+			break
+		}
+		// Flatten the distribution until the codes are short enough.
+		for s, c := range counts[:maxSym+1] {
+			if c > 0 {
+				counts[s] = (c + 1) / 2
+			}
+		}
+	}
+
+	// Assign codes the same way readHuff assigns table entries.
+	maxLen := 0
+	for _, l := range he.lens[:maxSym+1] {
+		if int(l) > maxLen {
+			maxLen = int(l)
+		}
+	}
+	var start [maxHuffBits + 2]uint32
+	for _, l := range he.lens[:maxSym+1] {
+		if l > 0 {
+			w := maxLen + 1 - int(l)
+			start[w] += 1 << (w - 1)
+		}
+	}
+	next := uint32(0)
+	for w := range start {
+		n := start[w]
+		start[w] = next
+		next += n
+	}
+	for s, l := range he.lens[:maxSym+1] {
+		if l == 0 {
+			continue
+		}
+		w := maxLen + 1 - int(l)
+		he.codes[s] = uint16(start[w] >> (maxLen - int(l)))
+		start[w] += 1 << (w - 1)
+	}
+	return true
+}
+
+// huffLengths stores in lens the Huffman code lengths for the symbols
+// with the counts in counts, and returns the longest length.
+func huffLengths(counts []uint32, lens []uint8) int {
+	type node struct {
+		count       uint64
+		left, right int // children, or -1 for a leaf
+		sym         int
+	}
+	var nodes []node
+	for s, c := range counts {
+		lens[s] = 0
+		if c > 0 {
+			nodes = append(nodes, node{count: uint64(c), left: -1, right: -1, sym: s})
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].count < nodes[j].count })
+
+	// Merge the two lightest nodes until one is left, taking them
+	// from the sorted leaves and the (also sorted) merged nodes.
+	leaves := len(nodes)
+	li, mi := 0, leaves
+	pick := func() int {
+		if li < leaves && (mi >= len(nodes) || nodes[li].count <= nodes[mi].count) {
+			li++
+			return li - 1
+		}
+		mi++
+		return mi - 1
+	}
+	for n := leaves; n > 1; n-- {
+		a := pick()
+		b := pick()
+		nodes = append(nodes, node{count: nodes[a].count + nodes[b].count, left: a, right: b})
+	}
+
+	maxLen := 0
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if nodes[i].left < 0 {
+			lens[nodes[i].sym] = uint8(depth)
+			if depth > maxLen {
+				maxLen = depth
+			}
+			return
+		}
+		walk(nodes[i].left, depth+1)
+		walk(nodes[i].right, depth+1)
+	}
+	walk(len(nodes)-1, 0)
+	return maxLen
+}
+
+// writeTree appends the description of the code, using directly
+// stored weights.
+func (he *huffEncoder) writeTree(dst []byte) []byte {
+	maxLen := 0
+	for _, l := range he.lens[:he.maxSym+1] {
+		if int(l) > maxLen {
+			maxLen = int(l)
+		}
+	}
+	// The weight of the last symbol is implied.
+	count := he.maxSym
+	dst = append(dst, byte(127+count))
+	for i := 0; i < count; i += 2 {
+		var b byte
+		if l := he.lens[i]; l > 0 {
+			b = byte(maxLen+1-int(l)) << 4
+		}
+		if i+1 < count {
+			if l := he.lens[i+1]; l > 0 {
+				b |= byte(maxLen + 1 - int(l))
+			}
+		}
+		dst = append(dst, b)
+	}
+	return dst
+}
+
+// encode appends the Huffman coded stream for src.
+func (he *huffEncoder) encode(dst []byte, src []byte) []byte {
+	var bw bitWriter
+	bw.reset(dst)
+	for i := len(src) - 1; i >= 0; i-- {
+		c := src[i]
+		bw.add(uint32(he.codes[c]), uint(he.lens[c]))
+	}
+	return bw.close()
+}
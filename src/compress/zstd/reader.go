@@ -0,0 +1,373 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var errWindowTooLarge = errors.New("zstd: window size too large")
+
+// A Reader is an io.Reader that can be read to retrieve
+// uncompressed data from a Zstandard stream.
+//
+// A stream may consist of any number of frames, whose uncompressed
+// data is concatenated, interspersed with skippable frames, which
+// are ignored.
+//
+// As with compress/gzip, the data returned by Read is not verified
+// against the frame's checksum until the end of the frame is reached,
+// so callers should treat it as tentative until they receive io.EOF.
+type Reader struct {
+	r    byteReader
+	bufr *bufio.Reader // buffers r if needed
+	dict *dict
+	err  error
+	off  int64 // offset in the input
+
+	maxWindow int // largest window accepted; 0 means maxWindowSize
+
+	// The current frame.
+	inFrame    bool
+	lastBlock  bool
+	checksum   bool
+	hasSize    bool
+	size       uint64 // declared content size
+	decoded    uint64 // content decoded so far
+	windowSize int
+	xh         xxhash64
+
+	// buf holds the dictionary content, the window of decoded data
+	// that matches may refer to, and decoded data not yet returned
+	// by Read, which starts at pos.
+	buf   []byte
+	pos   int
+	block []byte
+	bd    blockDecoder
+	hdr   [14]byte
+}
+
+// byteReader is the interface the Reader needs from its input.
+// Inputs that do not implement it are buffered, so the Reader may
+// read more data than necessary from them.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// NewReader creates a new Reader reading the given reader.
+// If r does not also implement io.ByteReader,
+// the decompressor may read more data than necessary from r.
+func NewReader(r io.Reader) *Reader {
+	z := new(Reader)
+	z.Reset(r)
+	return z
+}
+
+// NewReaderDict is like NewReader but decompresses frames using the
+// given dictionary. The dictionary is either in the standard format
+// produced by the zstd command's --train option, or raw content.
+// A frame that names a different dictionary in its header fails with
+// ErrDictionary. The Reader keeps a reference to dict, which must not
+// be modified.
+func NewReaderDict(r io.Reader, dict []byte) (*Reader, error) {
+	d, err := parseDict(dict)
+	if err != nil {
+		return nil, err
+	}
+	z := NewReader(r)
+	z.dict = d
+	return z, nil
+}
+
+// Reset discards the Reader's state and makes it equivalent to the
+// result of its original state from NewReader or NewReaderDict,
+// but reading from r instead. This permits reusing a Reader rather
+// than allocating a new one.
+func (z *Reader) Reset(r io.Reader) {
+	if rr, ok := r.(byteReader); ok {
+		z.r = rr
+	} else {
+		if z.bufr == nil {
+			z.bufr = bufio.NewReader(r)
+		} else {
+			z.bufr.Reset(r)
+		}
+		z.r = z.bufr
+	}
+	z.err = nil
+	z.off = 0
+	z.inFrame = false
+	z.buf = z.buf[:0]
+	z.pos = 0
+}
+
+// SetMaxWindowSize limits the window size of the frames z accepts to n
+// bytes, which bounds the memory z needs to decode them. Reading a frame
+// that declares a larger window fails. A limit of n <= 0, or above the
+// default of 128 MB, restores the default. The limit is kept by Reset.
+//
+// RFC 9659 limits the window of zstd used as an HTTP content coding
+// to 8 MB.
+func (z *Reader) SetMaxWindowSize(n int) {
+	if n <= 0 || n > maxWindowSize {
+		n = 0
+	}
+	z.maxWindow = n
+}
+
+// windowLimit returns the largest window size z accepts.
+func (z *Reader) windowLimit() int {
+	if z.maxWindow > 0 {
+		return z.maxWindow
+	}
+	return maxWindowSize
+}
+
+// Read implements io.Reader, reading uncompressed bytes from its underlying Reader.
+func (z *Reader) Read(p []byte) (int, error) {
+	for {
+		if z.pos < len(z.buf) {
+			n := copy(p, z.buf[z.pos:])
+			z.pos += n
+			return n, nil
+		}
+		if z.err != nil {
+			return 0, z.err
+		}
+		switch {
+		case !z.inFrame:
+			z.err = z.readFrameHeader()
+		case z.lastBlock:
+			z.err = z.finishFrame()
+		default:
+			z.err = z.readBlock()
+		}
+	}
+}
+
+// readFull reads exactly len(b) bytes of a frame.
+func (z *Reader) readFull(b []byte) error {
+	n, err := io.ReadFull(z.r, b)
+	z.off += int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (z *Reader) corrupt(off int64, reason string) error {
+	return &CorruptInputError{Offset: off, Reason: reason}
+}
+
+// readFrameHeader reads the header of the next frame, skipping any
+// skippable frames. It returns io.EOF at the end of the input.
+func (z *Reader) readFrameHeader() error {
+	for {
+		start := z.off
+		n, err := io.ReadFull(z.r, z.hdr[:4])
+		z.off += int64(n)
+		if err != nil {
+			// The input may end cleanly between frames.
+			return err
+		}
+		magic := binary.LittleEndian.Uint32(z.hdr[:4])
+		if magic&skippableMagicMask == skippableMagic {
+			if err := z.readFull(z.hdr[:4]); err != nil {
+				return err
+			}
+			size := int64(binary.LittleEndian.Uint32(z.hdr[:4]))
+			n, err := io.CopyN(io.Discard, z.r, size)
+			z.off += n
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if magic != frameMagic {
+			return z.corrupt(start, "invalid magic number")
+		}
+		return z.readFrameHeaderFields(start)
+	}
+}
+
+func (z *Reader) readFrameHeaderFields(start int64) error {
+	if err := z.readFull(z.hdr[:1]); err != nil {
+		return err
+	}
+	desc := z.hdr[0]
+	if desc&(1<<3) != 0 {
+		return z.corrupt(start, "reserved bit set in frame header")
+	}
+	fcsFlag := desc >> 6
+	singleSegment := desc&(1<<5) != 0
+	z.checksum = desc&(1<<2) != 0
+	dictIDSize := [4]int{0, 1, 2, 4}[desc&3]
+	fcsSize := [4]int{0, 2, 4, 8}[fcsFlag]
+	if fcsFlag == 0 && singleSegment {
+		fcsSize = 1
+	}
+	windowDescSize := 1
+	if singleSegment {
+		windowDescSize = 0
+	}
+	h := z.hdr[:windowDescSize+dictIDSize+fcsSize]
+	if err := z.readFull(h); err != nil {
+		return err
+	}
+
+	if !singleSegment {
+		exp := uint(h[0] >> 3)
+		mantissa := uint64(h[0] & 7)
+		base := uint64(1) << (10 + exp)
+		window := base + base/8*mantissa
+		if window > uint64(z.windowLimit()) {
+			return errWindowTooLarge
+		}
+		z.windowSize = int(window)
+		h = h[1:]
+	}
+
+	var dictID uint32
+	switch dictIDSize {
+	case 1:
+		dictID = uint32(h[0])
+	case 2:
+		dictID = uint32(binary.LittleEndian.Uint16(h))
+	case 4:
+		dictID = binary.LittleEndian.Uint32(h)
+	}
+	h = h[dictIDSize:]
+
+	z.hasSize = fcsSize > 0
+	switch fcsSize {
+	case 1:
+		z.size = uint64(h[0])
+	case 2:
+		z.size = uint64(binary.LittleEndian.Uint16(h)) + 256
+	case 4:
+		z.size = uint64(binary.LittleEndian.Uint32(h))
+	case 8:
+		z.size = binary.LittleEndian.Uint64(h)
+	}
+	if singleSegment {
+		if z.size > uint64(z.windowLimit()) {
+			return errWindowTooLarge
+		}
+		z.windowSize = int(z.size)
+	}
+
+	d := z.dict
+	if dictID != 0 && (d == nil || d.id != dictID) {
+		return ErrDictionary
+	}
+
+	z.inFrame = true
+	z.lastBlock = false
+	z.decoded = 0
+	z.xh.reset()
+	z.buf = z.buf[:0]
+	if d != nil {
+		z.buf = append(z.buf, d.content...)
+	}
+	z.pos = len(z.buf)
+	z.bd.reset(d)
+	return nil
+}
+
+// readBlock reads and decodes the next block of the frame.
+func (z *Reader) readBlock() error {
+	start := z.off
+	if err := z.readFull(z.hdr[:3]); err != nil {
+		return err
+	}
+	v := uint32(z.hdr[0]) | uint32(z.hdr[1])<<8 | uint32(z.hdr[2])<<16
+	z.lastBlock = v&1 != 0
+	typ := v >> 1 & 3
+	size := int(v >> 3)
+
+	maxSize := z.windowSize
+	if maxSize > maxBlockSize {
+		maxSize = maxBlockSize
+	}
+	if size > maxSize {
+		return z.corrupt(start, "block too large")
+	}
+
+	// Discard history that is no longer needed.
+	keep := z.windowSize
+	if z.dict != nil {
+		keep += len(z.dict.content)
+	}
+	if len(z.buf) > 2*keep {
+		z.buf = z.buf[:copy(z.buf, z.buf[len(z.buf)-keep:])]
+		z.pos = len(z.buf)
+	}
+
+	n := len(z.buf)
+	switch typ {
+	case blockRaw:
+		z.buf = append(z.buf, make([]byte, size)...)
+		if err := z.readFull(z.buf[n:]); err != nil {
+			return err
+		}
+	case blockRLE:
+		if err := z.readFull(z.hdr[:1]); err != nil {
+			return err
+		}
+		for i := 0; i < size; i++ {
+			z.buf = append(z.buf, z.hdr[0])
+		}
+	case blockCompressed:
+		if cap(z.block) < size {
+			z.block = make([]byte, size, maxBlockSize)
+		}
+		z.block = z.block[:size]
+		if err := z.readFull(z.block); err != nil {
+			return err
+		}
+		var reason string
+		z.buf, reason = z.bd.decode(z.buf, z.block, maxSize)
+		if reason != "" {
+			return z.corrupt(start, reason)
+		}
+	default:
+		return z.corrupt(start, "reserved block type")
+	}
+
+	out := z.buf[n:]
+	z.decoded += uint64(len(out))
+	if z.hasSize && z.decoded > z.size {
+		return z.corrupt(start, "frame content size exceeded")
+	}
+	if z.checksum {
+		z.xh.update(out)
+	}
+	return nil
+}
+
+// finishFrame checks the content size and checksum at the end of a frame.
+func (z *Reader) finishFrame() error {
+	start := z.off
+	if z.hasSize && z.decoded != z.size {
+		return z.corrupt(start, "frame content size mismatch")
+	}
+	if z.checksum {
+		if err := z.readFull(z.hdr[:4]); err != nil {
+			return err
+		}
+		if binary.LittleEndian.Uint32(z.hdr[:4]) != uint32(z.xh.digest()) {
+			return ErrChecksum
+		}
+	}
+	z.inFrame = false
+	return nil
+}
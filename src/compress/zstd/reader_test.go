@@ -0,0 +1,255 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"testing/iotest"
+)
+
+func readFile(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// The files in testdata were compressed by the zstd command.
+var readerTests = []struct {
+	name       string
+	compressed string
+	raw        string
+	rawSize    int // if nonzero, the size of the prefix of raw
+	dict       string
+}{
+	{
+		// zstd -19
+		name:       "huffman and fse",
+		compressed: "testdata/Isaac.Newton-Opticks.txt.zst",
+		raw:        "../../testdata/Isaac.Newton-Opticks.txt",
+		rawSize:    100000,
+	},
+	{
+		// zstd -1 --no-check
+		name:       "no checksum",
+		compressed: "testdata/gettysburg.txt.zst",
+		raw:        "../testdata/gettysburg.txt",
+	},
+	{
+		// zstd -D testdata/dict
+		name:       "dictionary",
+		compressed: "testdata/dict.txt.zst",
+		raw:        "testdata/dict.txt",
+		dict:       "testdata/dict",
+	},
+}
+
+func TestReader(t *testing.T) {
+	for _, tt := range readerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := readFile(t, tt.compressed)
+			want := readFile(t, tt.raw)
+			if tt.rawSize > 0 {
+				want = want[:tt.rawSize]
+			}
+			r := NewReader(bytes.NewReader(compressed))
+			if tt.dict != "" {
+				var err error
+				r, err = NewReaderDict(bytes.NewReader(compressed), readFile(t, tt.dict))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("got %d bytes, want %d matching bytes", len(got), len(want))
+			}
+
+			// Reading a byte at a time goes through the same states.
+			r.Reset(iotest.OneByteReader(bytes.NewReader(compressed)))
+			got, err = io.ReadAll(iotest.OneByteReader(r))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("one byte reads: got %d bytes, want %d matching bytes", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestReaderEmpty(t *testing.T) {
+	n, err := NewReader(bytes.NewReader(nil)).Read(make([]byte, 1))
+	if n != 0 || err != io.EOF {
+		t.Errorf("Read = %d, %v; want 0, io.EOF", n, err)
+	}
+}
+
+func TestReaderMultipleFrames(t *testing.T) {
+	want := readFile(t, "../testdata/gettysburg.txt")
+	frame := readFile(t, "testdata/gettysburg.txt.zst")
+
+	var skippable [8]byte
+	binary.LittleEndian.PutUint32(skippable[:], skippableMagic|7)
+	var input []byte
+	input = append(input, frame...)
+	input = append(input, skippable[:]...)
+	input = append(input, skippable[:]...) // the first one's content
+	input = append(input, frame...)
+
+	got, err := io.ReadAll(NewReader(bytes.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, append(want, want...)) {
+		t.Errorf("got %d bytes, want two copies of %d bytes", len(got), len(want))
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	compressed := readFile(t, "testdata/Isaac.Newton-Opticks.txt.zst")
+
+	bad := append([]byte(nil), compressed...)
+	bad[len(bad)-1] ^= 1
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(bad))); err != ErrChecksum {
+		t.Errorf("corrupt checksum: got %v, want ErrChecksum", err)
+	}
+
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(compressed[:len(compressed)/2]))); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated: got %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	_, err := io.ReadAll(NewReader(bytes.NewReader([]byte("not zstd data"))))
+	var cerr *CorruptInputError
+	if !errors.As(err, &cerr) || cerr.Offset != 0 {
+		t.Errorf("bad magic: got %v, want CorruptInputError at offset 0", err)
+	}
+
+	dictCompressed := readFile(t, "testdata/dict.txt.zst")
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(dictCompressed))); err != ErrDictionary {
+		t.Errorf("missing dictionary: got %v, want ErrDictionary", err)
+	}
+}
+
+func TestReaderMaxWindowSize(t *testing.T) {
+	// An empty frame declaring a 16 MB window.
+	frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 14 << 3, 0x01, 0x00, 0x00}
+
+	r := NewReader(bytes.NewReader(frame))
+	if got, err := io.ReadAll(r); err != nil || len(got) != 0 {
+		t.Errorf("default limit: got %q, %v; want empty output", got, err)
+	}
+
+	r.Reset(bytes.NewReader(frame))
+	r.SetMaxWindowSize(8 << 20)
+	if _, err := io.ReadAll(r); err != errWindowTooLarge {
+		t.Errorf("8 MB limit: got %v, want %v", err, errWindowTooLarge)
+	}
+
+	// The limit survives Reset, and a zero limit restores the default.
+	r.Reset(bytes.NewReader(frame))
+	if _, err := io.ReadAll(r); err != errWindowTooLarge {
+		t.Errorf("8 MB limit after Reset: got %v, want %v", err, errWindowTooLarge)
+	}
+	r.SetMaxWindowSize(0)
+	r.Reset(bytes.NewReader(frame))
+	if _, err := io.ReadAll(r); err != nil {
+		t.Errorf("restored default limit: got %v", err)
+	}
+}
+
+// TestReaderCorrupt checks that corrupt input is reported as an error
+// rather than causing a panic.
+func TestReaderCorrupt(t *testing.T) {
+	inputs := [][]byte{
+		readFile(t, "testdata/Isaac.Newton-Opticks.txt.zst")[:8000],
+		readFile(t, "testdata/dict.txt.zst"),
+	}
+	dict := readFile(t, "testdata/dict")
+	for _, input := range inputs {
+		for i := 0; i < len(input); i += 7 {
+			bad := append([]byte(nil), input...)
+			bad[i] ^= 0x5a
+			r, err := NewReaderDict(bytes.NewReader(bad), dict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.Copy(io.Discard, r)
+		}
+	}
+}
+
+// TestReaderCommand checks that the Reader decodes the output of the
+// zstd command, if it is installed, at a variety of settings.
+func TestReaderCommand(t *testing.T) {
+	zstd, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd command not found")
+	}
+	raw := readFile(t, "../../testdata/Isaac.Newton-Opticks.txt")
+	if testing.Short() {
+		raw = raw[:100000]
+	}
+	for _, args := range [][]string{
+		{"--fast=3"},
+		{"-1"},
+		{"-3", "--no-content-size"},
+		{"-9", "--no-check"},
+		{"-19"},
+		{"--ultra", "-22", "--long=24"},
+	} {
+		cmd := exec.Command(zstd, append(args, "-q", "-c")...)
+		cmd.Stdin = bytes.NewReader(raw)
+		compressed, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("zstd %v: %v", args, err)
+		}
+		got, err := io.ReadAll(NewReader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Errorf("zstd %v: %v", args, err)
+		} else if !bytes.Equal(got, raw) {
+			t.Errorf("zstd %v: got %d bytes, want %d matching bytes", args, len(got), len(raw))
+		}
+	}
+}
+
+func TestXXHash(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0xEF46DB3751D8E999},
+		{"a", 0xD24EC4F1A98C6E5B},
+		{"abc", 0x44BC2CF5AD770999},
+		{"Nobody inspects the spammish repetition", 0xFBCEA83C8A378BF1},
+	} {
+		var xh xxhash64
+		xh.reset()
+		xh.update([]byte(tt.in))
+		if got := xh.digest(); got != tt.want {
+			t.Errorf("xxhash64(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+
+		// The result must not depend on how the input is split.
+		xh.reset()
+		for i := 0; i < len(tt.in); i++ {
+			xh.update([]byte(tt.in[i : i+1]))
+		}
+		if got := xh.digest(); got != tt.want {
+			t.Errorf("xxhash64(%q) byte at a time = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+}
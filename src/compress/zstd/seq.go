@@ -0,0 +1,156 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import "math/bits"
+
+// Sequence codes, RFC 8878 section 3.1.1.3.2.1.
+
+// Literals length codes 0-15 stand for themselves. Larger codes have a
+// baseline and a number of extra bits that are added to it.
+var llBase = [36]uint32{
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+	16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512,
+	1024, 2048, 4096, 8192, 16384, 32768, 65536,
+}
+
+var llBits = [36]uint8{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9,
+	10, 11, 12, 13, 14, 15, 16,
+}
+
+// Match length codes 0-31 stand for lengths 3-34.
+var mlBase = [53]uint32{
+	3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+	19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+	35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515,
+	1027, 2051, 4099, 8195, 16387, 32771, 65539,
+}
+
+var mlBits = [53]uint8{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9,
+	10, 11, 12, 13, 14, 15, 16,
+}
+
+const (
+	maxLLCode = 35
+	maxMLCode = 52
+	maxOFCode = 31
+
+	maxLLLog = 9
+	maxMLLog = 9
+	maxOFLog = 8
+)
+
+// Predefined distributions, used when a block does not describe its own.
+var (
+	predefLLNorm = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}
+	predefMLNorm = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}
+	predefOFNorm = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}
+)
+
+const (
+	predefLLLog = 6
+	predefMLLog = 6
+	predefOFLog = 5
+)
+
+var (
+	predefLL = mustBuildFSE(predefLLNorm, predefLLLog)
+	predefML = mustBuildFSE(predefMLNorm, predefMLLog)
+	predefOF = mustBuildFSE(predefOFNorm, predefOFLog)
+
+	predefLLEnc = newFSEEncoder(predefLLNorm, predefLLLog)
+	predefMLEnc = newFSEEncoder(predefMLNorm, predefMLLog)
+	predefOFEnc = newFSEEncoder(predefOFNorm, predefOFLog)
+)
+
+func mustBuildFSE(norm []int16, tableLog int) []fseEntry {
+	table := make([]fseEntry, 1<<tableLog)
+	if !buildFSE(norm, tableLog, table) {
+		panic("zstd: invalid predefined FSE table")
+	}
+	return table
+}
+
+// An fseTable is a decoding table together with its accuracy log.
+type fseTable struct {
+	log   int
+	table []fseEntry
+}
+
+// llCode returns the literals length code for n and its extra bits.
+func llCode(n uint32) (code uint8, extra uint32) {
+	switch {
+	case n < 16:
+		return uint8(n), 0
+	case n < 64:
+		code = llCodeTable[n-16]
+	default:
+		code = uint8(bits.Len32(n)) - 1 + 19
+		if code > maxLLCode {
+			code = maxLLCode
+		}
+	}
+	return code, n - llBase[code]
+}
+
+// mlCode returns the match length code for n, which is at least 3,
+// and its extra bits.
+func mlCode(n uint32) (code uint8, extra uint32) {
+	v := n - 3
+	switch {
+	case v < 32:
+		return uint8(v), 0
+	case v < 128:
+		code = mlCodeTable[v-32]
+	default:
+		code = uint8(bits.Len32(v)) - 1 + 36
+	}
+	return code, n - mlBase[code]
+}
+
+// ofCode returns the offset code for an offset value and its extra bits.
+func ofCode(v uint32) (code uint8, extra uint32) {
+	code = uint8(bits.Len32(v)) - 1
+	return code, v - 1<<code
+}
+
+var (
+	llCodeTable [64 - 16]uint8
+	mlCodeTable [128 - 32]uint8
+)
+
+func init() {
+	for n := range llCodeTable {
+		c := uint8(16)
+		for c < maxLLCode && llBase[c+1] <= uint32(n+16) {
+			c++
+		}
+		llCodeTable[n] = c
+	}
+	for v := range mlCodeTable {
+		c := uint8(32)
+		for c < maxMLCode && mlBase[c+1] <= uint32(v+32+3) {
+			c++
+		}
+		mlCodeTable[v] = c
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// These constants are copied from the flate package, so that code that
+// imports "compress/zstd" does not also have to import "compress/flate".
+const (
+	BestSpeed          = 1
+	BestCompression    = 9
+	DefaultCompression = -1
+)
+
+// defaultLevel is the level that DefaultCompression stands for.
+const defaultLevel = 3
+
+// batchBlocks is the number of blocks a Writer buffers and then
+// compresses concurrently. It does not depend on the number of CPUs,
+// so that the output does not either.
+const batchBlocks = 8
+
+var errWriterClosed = errors.New("zstd: write to closed Writer")
+
+// A Writer is an io.WriteCloser.
+// Writes to a Writer are compressed and written to w.
+//
+// A Writer buffers up to 8 blocks (1 MiB) of input, and compresses
+// the buffered blocks concurrently.
+type Writer struct {
+	w    io.Writer
+	p    levelParams
+	dict *dict
+	err  error
+
+	wroteHeader bool
+	closed      bool
+	xh          xxhash64
+
+	// buf holds up to p.lookback bytes of history, followed by
+	// input not yet compressed, which starts at hist.
+	buf  []byte
+	hist int
+
+	encs []*encoder
+	outs [][]byte
+}
+
+// NewWriter returns a new Writer.
+// Writes to the returned writer are compressed and written to w.
+//
+// It is the caller's responsibility to call Close on the Writer when done.
+// Writes may be buffered and not flushed until Close.
+func NewWriter(w io.Writer) *Writer {
+	z, _ := NewWriterLevel(w, DefaultCompression)
+	return z
+}
+
+// NewWriterLevel is like NewWriter but specifies the compression level
+// instead of assuming DefaultCompression.
+//
+// The compression level can be DefaultCompression, or any integer
+// value between BestSpeed and BestCompression inclusive.
+// The error returned will be nil if the level is valid.
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	return NewWriterDict(w, level, nil)
+}
+
+// NewWriterDict is like NewWriterLevel but uses a dictionary.
+// The dictionary is either in the standard format produced by the zstd
+// command's --train option, whose ID is recorded in each frame, or raw
+// content. Data compressed with a dictionary can only be decompressed
+// with the same dictionary, using NewReaderDict.
+// The Writer keeps a reference to dict, which must not be modified.
+func NewWriterDict(w io.Writer, level int, dict []byte) (*Writer, error) {
+	if level == DefaultCompression {
+		level = defaultLevel
+	}
+	if level < BestSpeed || level > BestCompression {
+		return nil, fmt.Errorf("zstd: invalid compression level: %d", level)
+	}
+	z := &Writer{p: levels[level]}
+	if dict != nil {
+		d, err := parseDict(dict)
+		if err != nil {
+			return nil, err
+		}
+		z.dict = d
+	}
+	z.Reset(w)
+	return z, nil
+}
+
+// Reset discards the Writer z's state and makes it equivalent to the
+// result of its original state from NewWriter, NewWriterLevel or
+// NewWriterDict, but writing to w instead. This permits reusing a
+// Writer rather than allocating a new one.
+func (z *Writer) Reset(w io.Writer) {
+	z.w = w
+	z.err = nil
+	z.wroteHeader = false
+	z.closed = false
+	z.xh.reset()
+	z.buf = z.buf[:0]
+	if z.dict != nil {
+		// Matches may refer to the end of the dictionary content.
+		content := z.dict.content
+		if len(content) > z.p.lookback {
+			content = content[len(content)-z.p.lookback:]
+		}
+		z.buf = append(z.buf, content...)
+	}
+	z.hist = len(z.buf)
+}
+
+// Write writes a compressed form of p to the underlying io.Writer.
+// The compressed bytes are not necessarily flushed until the Writer
+// is closed.
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	if z.closed {
+		return 0, errWriterClosed
+	}
+	z.xh.update(p)
+	n := len(p)
+	for len(p) > 0 {
+		if len(z.buf)-z.hist == batchBlocks*maxBlockSize {
+			if z.err = z.compress(false); z.err != nil {
+				return 0, z.err
+			}
+		}
+		m := z.hist + batchBlocks*maxBlockSize - len(z.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		z.buf = append(z.buf, p[:m]...)
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// Flush compresses any pending data and writes it to the underlying
+// writer. It is useful mainly in compressed network protocols, to
+// ensure that a remote reader has enough data to reconstruct a packet.
+// Flush does not return until the data has been written.
+// If the underlying writer returns an error, Flush returns that error.
+func (z *Writer) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.closed {
+		return nil
+	}
+	z.err = z.compress(false)
+	return z.err
+}
+
+// Close closes the Writer by flushing any unwritten data to the
+// underlying io.Writer and writing the frame's checksum.
+// It does not close the underlying io.Writer.
+func (z *Writer) Close() error {
+	if z.err != nil || z.closed {
+		return z.err
+	}
+	z.closed = true
+	if z.err = z.compress(true); z.err != nil {
+		return z.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], uint32(z.xh.digest()))
+	_, z.err = z.w.Write(sum[:])
+	return z.err
+}
+
+// compress compresses and writes the pending input, writing the frame
+// header first if needed. If last is set, the last block written ends
+// the frame.
+func (z *Writer) compress(last bool) error {
+	start, end := z.hist, len(z.buf)
+	n := (end - start + maxBlockSize - 1) / maxBlockSize
+	if n == 0 && last {
+		n = 1 // an empty last block
+	}
+	for len(z.encs) < n {
+		z.encs = append(z.encs, newEncoder(z.p))
+		z.outs = append(z.outs, nil)
+	}
+
+	// Each block depends only on the input before it,
+	// so the blocks can be compressed in any order.
+	block := func(i int) {
+		s := start + i*maxBlockSize
+		e := s + maxBlockSize
+		if e > end {
+			e = end
+		}
+		z.outs[i] = z.encs[i].encodeBlock(z.outs[i][:0], z.buf, s, e, last && i == n-1)
+	}
+	if n == 1 {
+		block(0)
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+				block(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	if !z.wroteHeader {
+		z.wroteHeader = true
+		if err := z.writeHeader(last, uint64(end-start)); err != nil {
+			return err
+		}
+	}
+	for _, out := range z.outs[:n] {
+		if _, err := z.w.Write(out); err != nil {
+			return err
+		}
+	}
+
+	// Keep the end of the input as history for the next blocks.
+	keep := len(z.buf)
+	if keep > z.p.lookback {
+		keep = z.p.lookback
+	}
+	z.buf = z.buf[:copy(z.buf, z.buf[len(z.buf)-keep:])]
+	z.hist = len(z.buf)
+	return nil
+}
+
+// writeHeader writes the frame header. If the whole content is known,
+// which is the case when the frame is written in one go, the header
+// records its size, and declares it as the window.
+func (z *Writer) writeHeader(whole bool, size uint64) error {
+	hdr := make([]byte, 4, 18)
+	binary.LittleEndian.PutUint32(hdr, frameMagic)
+	desc := byte(1 << 2) // content checksum
+	if z.dict != nil && z.dict.id != 0 {
+		desc |= 3 // 4-byte dictionary ID
+	}
+	if !whole {
+		hdr = append(hdr, desc, byte(z.p.windowLog()-10)<<3)
+	} else {
+		desc |= 1 << 5 // single segment
+		switch {
+		case size < 256:
+			hdr = append(hdr, desc)
+		case size < 256+1<<16:
+			hdr = append(hdr, desc|1<<6)
+		case size < 1<<32:
+			hdr = append(hdr, desc|2<<6)
+		default:
+			hdr = append(hdr, desc|3<<6)
+		}
+	}
+	if z.dict != nil && z.dict.id != 0 {
+		hdr = append(hdr, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(hdr[len(hdr)-4:], z.dict.id)
+	}
+	if whole {
+		switch {
+		case size < 256:
+			hdr = append(hdr, byte(size))
+		case size < 256+1<<16:
+			v := size - 256
+			hdr = append(hdr, byte(v), byte(v>>8))
+		case size < 1<<32:
+			hdr = append(hdr, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(hdr[len(hdr)-4:], uint32(size))
+		default:
+			hdr = append(hdr, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint64(hdr[len(hdr)-8:], size)
+		}
+	}
+	_, err := z.w.Write(hdr)
+	return err
+}
@@ -0,0 +1,231 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os/exec"
+	"testing"
+)
+
+// testInputs returns inputs that exercise the different kinds of blocks
+// and literals the Writer produces.
+func testInputs(t testing.TB) map[string][]byte {
+	rnd := make([]byte, 300000)
+	rand.New(rand.NewSource(1)).Read(rnd)
+	text := readFile(t, "../../testdata/Isaac.Newton-Opticks.txt")
+	return map[string][]byte{
+		"empty":      nil,
+		"short":      []byte("hello, world\n"),
+		"zeros":      make([]byte, 200000),
+		"random":     rnd,
+		"digits":     readFile(t, "../testdata/e.txt"),
+		"gettysburg": readFile(t, "../testdata/gettysburg.txt"),
+		"text":       text,
+		"repeated":   bytes.Repeat(text[:70000], 40),
+	}
+}
+
+func compress(t testing.TB, data []byte, level int, dict []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriterDict(&buf, level, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decompress(t testing.TB, data []byte, dict []byte) []byte {
+	t.Helper()
+	r, err := NewReaderDict(bytes.NewReader(data), dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	inputs := testInputs(t)
+	for level := BestSpeed; level <= BestCompression; level++ {
+		if testing.Short() && level != BestSpeed && level != BestCompression {
+			continue
+		}
+		for name, data := range inputs {
+			compressed := compress(t, data, level, nil)
+			if got := decompress(t, compressed, nil); !bytes.Equal(got, data) {
+				t.Errorf("level %d, %s: round trip mismatch", level, name)
+			}
+		}
+	}
+}
+
+func TestWriterLevels(t *testing.T) {
+	for _, level := range []int{-2, 0, 10} {
+		if _, err := NewWriterLevel(io.Discard, level); err == nil {
+			t.Errorf("NewWriterLevel(%d) succeeded", level)
+		}
+	}
+	text := readFile(t, "../../testdata/Isaac.Newton-Opticks.txt")
+	fast := len(compress(t, text, BestSpeed, nil))
+	best := len(compress(t, text, BestCompression, nil))
+	if best >= fast {
+		t.Errorf("BestCompression output is %d bytes, BestSpeed %d", best, fast)
+	}
+	if fast >= len(text)/2 {
+		t.Errorf("compressed %d bytes of text to %d", len(text), fast)
+	}
+}
+
+func TestWriterDict(t *testing.T) {
+	raw := readFile(t, "testdata/dict.txt")
+	for _, dict := range [][]byte{
+		readFile(t, "testdata/dict"),                                   // standard format
+		readFile(t, "../../testdata/Isaac.Newton-Opticks.txt")[:20000], // raw content
+	} {
+		with := compress(t, raw, DefaultCompression, dict)
+		if got := decompress(t, with, dict); !bytes.Equal(got, raw) {
+			t.Errorf("round trip with dictionary mismatch")
+		}
+	}
+
+	dict := readFile(t, "testdata/dict")
+	with := compress(t, raw, DefaultCompression, dict)
+	without := compress(t, raw, DefaultCompression, nil)
+	if len(with) >= len(without) {
+		t.Errorf("compressed with dictionary to %d bytes, without to %d", len(with), len(without))
+	}
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(with))); err != ErrDictionary {
+		t.Errorf("reading without dictionary: got %v, want ErrDictionary", err)
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	r := NewReader(&buf)
+	for _, msg := range []string{"first message", "second message", "", "third"} {
+		if _, err := io.WriteString(w, msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(msg))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatalf("reading %q after Flush: %v", msg, err)
+		}
+		if string(got) != msg {
+			t.Fatalf("read %q after Flush, want %q", got, msg)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("Read after Close = %d, %v; want 0, io.EOF", n, err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Errorf("Write after Close succeeded")
+	}
+}
+
+// TestWriterChunks checks that the output does not depend on how the
+// input is split into writes, as long as the Writer is not flushed.
+func TestWriterChunks(t *testing.T) {
+	data := testInputs(t)["repeated"]
+	want := compress(t, data, DefaultCompression, nil)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	rnd := rand.New(rand.NewSource(1))
+	for p := data; len(p) > 0; {
+		n := rnd.Intn(300000)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.Write(p[:n])
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("output depends on the sizes of writes")
+	}
+
+	w.Reset(&buf)
+	buf.Reset()
+	w.Write(data)
+	w.Close()
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("output differs after Reset")
+	}
+}
+
+// TestWriterCommand checks that the zstd command, if it is installed,
+// decodes the Writer's output.
+func TestWriterCommand(t *testing.T) {
+	zstd, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd command not found")
+	}
+	for name, data := range testInputs(t) {
+		for _, level := range []int{BestSpeed, DefaultCompression, BestCompression} {
+			cmd := exec.Command(zstd, "-q", "-d", "-c")
+			cmd.Stdin = bytes.NewReader(compress(t, data, level, nil))
+			got, err := cmd.Output()
+			if err != nil {
+				t.Errorf("level %d, %s: zstd -d: %v", level, name, err)
+			} else if !bytes.Equal(got, data) {
+				t.Errorf("level %d, %s: zstd -d output mismatch", level, name)
+			}
+		}
+	}
+
+	raw := readFile(t, "testdata/dict.txt")
+	cmd := exec.Command(zstd, "-q", "-d", "-c", "-D", "testdata/dict")
+	cmd.Stdin = bytes.NewReader(compress(t, raw, DefaultCompression, readFile(t, "testdata/dict")))
+	got, err := cmd.Output()
+	if err != nil {
+		t.Errorf("dictionary: zstd -d: %v", err)
+	} else if !bytes.Equal(got, raw) {
+		t.Errorf("dictionary: zstd -d output mismatch")
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	data := readFile(b, "../../testdata/Isaac.Newton-Opticks.txt")
+	b.SetBytes(int64(len(data)))
+	w := NewWriter(io.Discard)
+	for i := 0; i < b.N; i++ {
+		w.Reset(io.Discard)
+		w.Write(data)
+		w.Close()
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	data := readFile(b, "../../testdata/Isaac.Newton-Opticks.txt")
+	compressed := compress(b, data, DefaultCompression, nil)
+	b.SetBytes(int64(len(data)))
+	r := NewReader(bytes.NewReader(compressed))
+	for i := 0; i < b.N; i++ {
+		r.Reset(bytes.NewReader(compressed))
+		io.Copy(io.Discard, r)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// xxhash64 computes the 64-bit xxHash of a stream with a seed of 0.
+// The low 32 bits of the hash are the content checksum of a frame.
+// See https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md.
+type xxhash64 struct {
+	len uint64    // total number of bytes written
+	v   [4]uint64 // accumulators
+	buf [32]byte  // pending input, not yet a full stripe
+	cnt int       // number of bytes in buf
+}
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+func (xh *xxhash64) reset() {
+	xh.len = 0
+	xh.v[0] = xxPrime1
+	xh.v[0] += xxPrime2
+	xh.v[1] = xxPrime2
+	xh.v[2] = 0
+	xh.v[3] = 0
+	xh.v[3] -= xxPrime1
+	xh.cnt = 0
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+func (xh *xxhash64) update(b []byte) {
+	xh.len += uint64(len(b))
+	if xh.cnt+len(b) < len(xh.buf) {
+		xh.cnt += copy(xh.buf[xh.cnt:], b)
+		return
+	}
+	if xh.cnt > 0 {
+		n := copy(xh.buf[xh.cnt:], b)
+		b = b[n:]
+		xh.stripe(xh.buf[:])
+		xh.cnt = 0
+	}
+	for len(b) >= len(xh.buf) {
+		xh.stripe(b[:32])
+		b = b[32:]
+	}
+	xh.cnt = copy(xh.buf[:], b)
+}
+
+func (xh *xxhash64) stripe(b []byte) {
+	xh.v[0] = xxRound(xh.v[0], binary.LittleEndian.Uint64(b[0:]))
+	xh.v[1] = xxRound(xh.v[1], binary.LittleEndian.Uint64(b[8:]))
+	xh.v[2] = xxRound(xh.v[2], binary.LittleEndian.Uint64(b[16:]))
+	xh.v[3] = xxRound(xh.v[3], binary.LittleEndian.Uint64(b[24:]))
+}
+
+// digest returns the hash of the bytes written so far.
+func (xh *xxhash64) digest() uint64 {
+	var h uint64
+	if xh.len >= 32 {
+		h = bits.RotateLeft64(xh.v[0], 1) +
+			bits.RotateLeft64(xh.v[1], 7) +
+			bits.RotateLeft64(xh.v[2], 12) +
+			bits.RotateLeft64(xh.v[3], 18)
+		for _, v := range xh.v {
+			h = xxMergeRound(h, v)
+		}
+	} else {
+		h = xxPrime5
+	}
+	h += xh.len
+
+	b := xh.buf[:xh.cnt]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
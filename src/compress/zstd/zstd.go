@@ -0,0 +1,62 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zstd implements reading and writing of Zstandard compressed data,
+// as specified in RFC 8878.
+//
+// A Reader decodes every feature of the format: compressed, raw and
+// RLE blocks, Huffman-coded literals, FSE-coded sequences, content
+// checksums, skippable frames, multiple concatenated frames, and
+// dictionaries.
+//
+// A Writer produces frames with a content checksum. It finds matches
+// with a hash chain whose depth depends on the compression level, and
+// codes literals with Huffman coding when that makes them smaller.
+// Blocks are compressed independently of each other's encoding, so that
+// a Writer can compress several blocks concurrently without changing
+// its output.
+package zstd
+
+import (
+	"errors"
+	"strconv"
+)
+
+const (
+	frameMagic         = 0xFD2FB528
+	skippableMagic     = 0x184D2A50 // low 4 bits are user-defined
+	skippableMagicMask = 0xFFFFFFF0
+	dictMagic          = 0xEC30A437
+
+	// maxBlockSize is the maximum size of the decompressed data
+	// of a block, and of the compressed data of a block.
+	maxBlockSize = 128 << 10
+
+	// minWindowSize is the smallest window a frame header can declare.
+	minWindowSize = 1 << 10
+
+	// maxWindowSize is the largest window the Reader accepts.
+	// The format allows larger windows, but they are only produced
+	// on explicit request, and require a lot of memory to decode.
+	maxWindowSize = 1 << 27
+)
+
+var (
+	// ErrChecksum is returned when reading zstd data that has an invalid checksum.
+	ErrChecksum = errors.New("zstd: invalid checksum")
+
+	// ErrDictionary is returned when reading a frame that requires
+	// a dictionary other than the one supplied to the Reader.
+	ErrDictionary = errors.New("zstd: frame requires a missing dictionary")
+)
+
+// A CorruptInputError reports the presence of corrupt input.
+type CorruptInputError struct {
+	Offset int64  // offset in the input near which the problem was found
+	Reason string // description of the problem
+}
+
+func (e *CorruptInputError) Error() string {
+	return "zstd: corrupt input at offset " + strconv.FormatInt(e.Offset, 10) + ": " + e.Reason
+}
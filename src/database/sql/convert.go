@@ -12,6 +12,8 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -537,6 +539,142 @@ func asBytes(buf []byte, rv reflect.Value) (b []byte, ok bool) {
 	return
 }
 
+// A structField is a field of a struct type that a column can be
+// scanned into.
+type structField struct {
+	name  string // db tag, or field name if untagged
+	index []int  // as for reflect.Value.FieldByIndex
+	typ   reflect.Type
+}
+
+var structFieldCache sync.Map // map[reflect.Type]map[string]*structField
+
+// cachedStructFields is like typeStructFields but uses a cache to
+// avoid repeated work.
+func cachedStructFields(t reflect.Type) map[string]*structField {
+	if f, ok := structFieldCache.Load(t); ok {
+		return f.(map[string]*structField)
+	}
+	f, _ := structFieldCache.LoadOrStore(t, typeStructFields(t))
+	return f.(map[string]*structField)
+}
+
+var (
+	scannerReflectType  = reflect.TypeOf((*Scanner)(nil)).Elem()
+	rawBytesReflectType = reflect.TypeOf(RawBytes(nil))
+)
+
+// typeStructFields returns the fields of the struct type t that columns
+// may be scanned into, keyed by their lower-cased names. The fields of
+// untagged embedded structs are promoted following the usual Go
+// visibility rules: a field hides any field of the same name at a
+// greater depth, and fields of the same name at the same depth hide
+// each other.
+func typeStructFields(t reflect.Type) map[string]*structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	fields := make(map[string]*structField)
+	hidden := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	next := []embedded{{typ: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		level := make(map[string][]*structField)
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				tag := sf.Tag.Get("db")
+				if tag == "-" {
+					continue
+				}
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+				if sf.Anonymous && tag == "" {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct && !reflect.PtrTo(ft).Implements(scannerReflectType) {
+						// A nil pointer to an unexported struct type
+						// cannot be allocated through reflection.
+						if sf.PkgPath != "" && sf.Type.Kind() == reflect.Ptr {
+							continue
+						}
+						next = append(next, embedded{ft, index})
+						continue
+					}
+				}
+				if sf.PkgPath != "" {
+					continue
+				}
+				name := tag
+				if name == "" {
+					name = sf.Name
+				}
+				key := strings.ToLower(name)
+				level[key] = append(level[key], &structField{
+					name:  name,
+					index: index,
+					typ:   sf.Type,
+				})
+			}
+		}
+		for key, fs := range level {
+			if hidden[key] {
+				continue
+			}
+			hidden[key] = true
+			if len(fs) == 1 {
+				fields[key] = fs[0]
+			}
+		}
+	}
+	return fields
+}
+
+// structColumnFields returns the fields of the struct pointed at by dest
+// that the named columns are scanned into.
+func structColumnFields(dest interface{}, columns []string) (reflect.Value, []*structField, error) {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("sql: ScanStruct destination not a non-nil pointer to a struct: %T", dest)
+	}
+	rv = rv.Elem()
+	byName := cachedStructFields(rv.Type())
+	fields := make([]*structField, len(columns))
+	for i, col := range columns {
+		f := byName[strings.ToLower(col)]
+		if f == nil {
+			return reflect.Value{}, nil, fmt.Errorf("sql: no field of %s for column index %d, name %q", rv.Type(), i, col)
+		}
+		fields[i] = f
+	}
+	return rv, fields, nil
+}
+
+// fieldByIndex returns the nested field of v at index, allocating any
+// nil embedded struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
 var valuerReflectType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
 
 // callValuerValue returns vr.Value(), with one exception:
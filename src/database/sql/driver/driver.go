@@ -19,6 +19,11 @@
 // If named parameters or context are supported, the driver's Conn should implement:
 // ExecerContext, QueryerContext, ConnPrepareContext, and ConnBeginTx.
 //
+// If the database has its own protocol for savepoints, the driver's Tx
+// should implement SavepointTx. If it can execute a statement for many
+// sets of arguments more efficiently than one at a time, the driver's
+// Stmt should implement BatchExecer.
+//
 // To support custom data types, implement NamedValueChecker. NamedValueChecker
 // also allows queries to accept per-query options as a parameter by returning
 // ErrRemoveArgument from CheckNamedValue.
@@ -363,6 +368,20 @@ type StmtExecContext interface {
 	ExecContext(ctx context.Context, args []NamedValue) (Result, error)
 }
 
+// BatchExecer may be implemented by Stmt to execute the statement for
+// many sets of arguments at once, for example with a bulk copy protocol
+// or a single multi-row VALUES clause, rather than with one round trip
+// per set. If a Stmt does not implement BatchExecer, the sql package
+// executes the statement once for each set of arguments instead.
+type BatchExecer interface {
+	// ExecBatch executes a query that doesn't return rows, such as an
+	// INSERT, once for each set of arguments in args. The returned
+	// Result reports the total number of rows affected.
+	//
+	// ExecBatch must honor the context timeout and return when it is canceled.
+	ExecBatch(ctx context.Context, args [][]NamedValue) (Result, error)
+}
+
 // StmtQueryContext enhances the Stmt interface by providing Query with context.
 type StmtQueryContext interface {
 	// QueryContext executes a query that may return rows, such as a
@@ -512,6 +531,26 @@ type Tx interface {
 	Rollback() error
 }
 
+// SavepointTx may be implemented by Tx to support savepoints natively.
+// If a Tx does not implement SavepointTx, the sql package executes the
+// equivalent SQL statements in the transaction instead.
+//
+// The sql package only passes names that are plain SQL identifiers.
+type SavepointTx interface {
+	Tx
+
+	// Savepoint sets a savepoint with the given name.
+	Savepoint(ctx context.Context, name string) error
+
+	// RollbackTo undoes the work done since the named savepoint was
+	// set, keeping the savepoint.
+	RollbackTo(ctx context.Context, name string) error
+
+	// Release discards the named savepoint, keeping the work done
+	// since it was set.
+	Release(ctx context.Context, name string) error
+}
+
 // RowsAffected implements Result for an INSERT or UPDATE operation
 // which mutates a number of rows.
 type RowsAffected int64
@@ -126,6 +126,7 @@ type fakeConn struct {
 	stmtsMade   int
 	stmtsClosed int
 	numPrepare  int
+	numBatch    int
 
 	// bad connection tests; see isBad()
 	bad       bool
@@ -624,6 +625,9 @@ func (c *fakeConn) PrepareContext(ctx context.Context, query string) (driver.Stm
 	}
 
 	c.touchMem()
+	// A BATCH prefix asks for a statement that implements driver.BatchExecer.
+	batch := strings.HasPrefix(query, "BATCH|")
+	query = strings.TrimPrefix(query, "BATCH|")
 	var firstStmt, prev *fakeStmt
 	for _, query := range strings.Split(query, ";") {
 		parts := strings.Split(query, "|")
@@ -693,9 +697,31 @@ func (c *fakeConn) PrepareContext(ctx context.Context, query string) (driver.Stm
 		}
 		prev = stmt
 	}
+	if batch {
+		return fakeBatchStmt{firstStmt}, nil
+	}
 	return firstStmt, nil
 }
 
+// fakeBatchStmt is a fakeStmt that executes batches in a single call.
+type fakeBatchStmt struct {
+	*fakeStmt
+}
+
+var _ driver.BatchExecer = fakeBatchStmt{}
+
+func (s fakeBatchStmt) ExecBatch(ctx context.Context, args [][]driver.NamedValue) (driver.Result, error) {
+	s.c.incrStat(&s.c.numBatch)
+	var n driver.RowsAffected
+	for _, a := range args {
+		if _, err := s.ExecContext(ctx, a); err != nil {
+			return nil, err
+		}
+		n++
+	}
+	return n, nil
+}
+
 func (s *fakeStmt) ColumnConverter(idx int) driver.ValueConverter {
 	if s.panic == "ColumnConverter" {
 		panic(s.panic)
@@ -1549,6 +1549,24 @@ func (db *DB) Exec(query string, args ...interface{}) (Result, error) {
 	return db.ExecContext(context.Background(), query, args...)
 }
 
+// ExecBatchContext executes a query without returning any rows, once for
+// each set of placeholder parameters in args. The query is prepared once
+// for the whole batch. See Stmt.ExecBatchContext for details.
+func (db *DB) ExecBatchContext(ctx context.Context, query string, args [][]interface{}) (Result, error) {
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	return stmt.ExecBatchContext(ctx, args)
+}
+
+// ExecBatch executes a query without returning any rows, once for each
+// set of placeholder parameters in args.
+func (db *DB) ExecBatch(query string, args [][]interface{}) (Result, error) {
+	return db.ExecBatchContext(context.Background(), query, args)
+}
+
 func (db *DB) exec(ctx context.Context, query string, args []interface{}, strategy connReuseStrategy) (Result, error) {
 	dc, err := db.conn(ctx, strategy)
 	if err != nil {
@@ -2217,6 +2235,82 @@ func (tx *Tx) Rollback() error {
 	return tx.rollback(false)
 }
 
+// Savepoint sets a savepoint named name within the transaction. A later
+// call to RollbackTo with the same name undoes the work done in the
+// transaction since the savepoint was set, without ending the
+// transaction. This allows a function to make a group of changes
+// atomically inside a transaction begun by its caller.
+//
+// The name must be a valid SQL identifier: a letter or underscore
+// followed by letters, digits, and underscores. If the driver's Tx
+// implements driver.SavepointTx it is used; otherwise the SQL statement
+// "SAVEPOINT name" is executed in the transaction.
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, name, "SAVEPOINT ", func(stx driver.SavepointTx) error {
+		return stx.Savepoint(ctx, name)
+	})
+}
+
+// RollbackTo undoes the work done in the transaction since the savepoint
+// named name was set. The savepoint remains set, and savepoints set
+// after it are discarded. If the driver's Tx does not implement
+// driver.SavepointTx, the SQL statement "ROLLBACK TO SAVEPOINT name" is
+// executed in the transaction.
+func (tx *Tx) RollbackTo(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, name, "ROLLBACK TO SAVEPOINT ", func(stx driver.SavepointTx) error {
+		return stx.RollbackTo(ctx, name)
+	})
+}
+
+// Release discards the savepoint named name, and any savepoints set after
+// it, keeping the work done since it was set as part of the transaction.
+// If the driver's Tx does not implement driver.SavepointTx, the SQL
+// statement "RELEASE SAVEPOINT name" is executed in the transaction.
+func (tx *Tx) Release(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, name, "RELEASE SAVEPOINT ", func(stx driver.SavepointTx) error {
+		return stx.Release(ctx, name)
+	})
+}
+
+// savepoint calls native if the driver supports savepoints, and otherwise
+// executes the statement formed by prefix followed by name.
+func (tx *Tx) savepoint(ctx context.Context, name, prefix string, native func(driver.SavepointTx) error) error {
+	if !validSavepointName(name) {
+		return fmt.Errorf("sql: invalid savepoint name %q", name)
+	}
+	dc, release, err := tx.grabConn(ctx)
+	if err != nil {
+		return err
+	}
+	if stx, ok := tx.txi.(driver.SavepointTx); ok {
+		withLock(dc, func() {
+			err = native(stx)
+		})
+		release(err)
+		return err
+	}
+	_, err = tx.db.execDC(ctx, dc, release, prefix+name, nil)
+	return err
+}
+
+// validSavepointName reports whether name is a plain SQL identifier, so
+// that it can be used in a statement without quoting.
+func validSavepointName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // PrepareContext creates a prepared statement for use within a transaction.
 //
 // The returned statement operates within the transaction and will be closed
@@ -2530,6 +2624,91 @@ func resultFromStatement(ctx context.Context, ci driver.Conn, ds *driverStmt, ar
 	return driverResult{ds.Locker, resi}, nil
 }
 
+// ExecBatchContext executes the prepared statement once for each set of
+// arguments in args and returns a Result summarizing the effect of the
+// whole batch: its RowsAffected is the total over all the executions,
+// and its LastInsertId is that of the last one.
+//
+// If the driver's statement implements driver.BatchExecer, the batch is
+// handed to the driver in a single call. Otherwise the statement is
+// executed for each set of arguments in turn, stopping at the first
+// error. In that case the argument sets before the failing one will
+// have been executed; run ExecBatchContext in a transaction to make the
+// batch atomic.
+func (s *Stmt) ExecBatchContext(ctx context.Context, args [][]interface{}) (Result, error) {
+	if len(args) == 0 {
+		return driver.RowsAffected(0), nil
+	}
+
+	s.closemu.RLock()
+	defer s.closemu.RUnlock()
+
+	var res Result
+	strategy := cachedOrNewConn
+	for i := 0; i < maxBadConnRetries+1; i++ {
+		if i == maxBadConnRetries {
+			strategy = alwaysNewConn
+		}
+		dc, releaseConn, ds, err := s.connStmt(ctx, strategy)
+		if err != nil {
+			if err == driver.ErrBadConn {
+				continue
+			}
+			return nil, err
+		}
+
+		res, err = resultFromStatementBatch(ctx, dc.ci, ds, args)
+		releaseConn(err)
+		if err != driver.ErrBadConn {
+			return res, err
+		}
+	}
+	return nil, driver.ErrBadConn
+}
+
+// ExecBatch executes the prepared statement once for each set of
+// arguments in args and returns a Result summarizing the effect of the
+// whole batch.
+func (s *Stmt) ExecBatch(args [][]interface{}) (Result, error) {
+	return s.ExecBatchContext(context.Background(), args)
+}
+
+func resultFromStatementBatch(ctx context.Context, ci driver.Conn, ds *driverStmt, args [][]interface{}) (Result, error) {
+	ds.Lock()
+	defer ds.Unlock()
+
+	dargs := make([][]driver.NamedValue, len(args))
+	for i, a := range args {
+		var err error
+		dargs[i], err = driverArgsConnLocked(ci, ds, a)
+		if err != nil {
+			return nil, fmt.Errorf("sql: ExecBatch error on argument set %d: %w", i, err)
+		}
+	}
+
+	if be, ok := ds.si.(driver.BatchExecer); ok {
+		resi, err := be.ExecBatch(ctx, dargs)
+		if err != nil {
+			return nil, err
+		}
+		return driverResult{ds.Locker, resi}, nil
+	}
+
+	res := batchResult{Locker: ds.Locker, resi: make([]driver.Result, 0, len(dargs))}
+	for i, a := range dargs {
+		resi, err := ctxDriverStmtExec(ctx, ds.si, a)
+		if err != nil {
+			if i == 0 && err == driver.ErrBadConn {
+				// Nothing has been executed, so the batch may be retried.
+				return nil, err
+			}
+			return nil, fmt.Errorf("sql: ExecBatch error on argument set %d: %w", i, err)
+		}
+		res.resi = append(res.resi, resi)
+	}
+	return res, nil
+}
+
 // removeClosedStmtLocked removes closed conns in s.css.
 //
 // To avoid lock contention on DB.mu, we do it only when
@@ -3121,6 +3300,58 @@ func rowsColumnInfoSetupConnLocked(rowsi driver.Rows) []*ColumnType {
 // If any of the first arguments implementing Scanner returns an error,
 // that error will be wrapped in the returned error
 func (rs *Rows) Scan(dest ...interface{}) error {
+	if err := rs.checkScan("Scan"); err != nil {
+		return err
+	}
+	if len(dest) != len(rs.lastcols) {
+		return fmt.Errorf("sql: expected %d destination arguments in Scan, not %d", len(rs.lastcols), len(dest))
+	}
+	for i, sv := range rs.lastcols {
+		err := convertAssignRows(dest[i], sv, rs)
+		if err != nil {
+			return fmt.Errorf(`sql: Scan error on column index %d, name %q: %w`, i, rs.rowsi.Columns()[i], err)
+		}
+	}
+	return nil
+}
+
+// ScanStruct copies the columns in the current row into the fields of
+// the struct pointed at by dest.
+//
+// Each column is stored in the exported field with a "db" struct tag
+// matching the column name or, failing that, in the untagged exported
+// field with the column's name. Names are matched case-insensitively.
+// A field tagged "db:\"-\"" is never scanned into. The fields of
+// embedded structs are treated as fields of the outer struct,
+// following the usual Go rules for promoted fields, and nil embedded
+// struct pointers are allocated as needed. An embedded struct whose
+// pointer implements Scanner is scanned into as a single field.
+//
+// It is an error for a column to have no matching field. Fields with
+// no matching column are left unchanged. Values are converted as by
+// Scan.
+func (rs *Rows) ScanStruct(dest interface{}) error {
+	if err := rs.checkScan("ScanStruct"); err != nil {
+		return err
+	}
+	cols := rs.rowsi.Columns()
+	rv, fields, err := structColumnFields(dest, cols)
+	if err != nil {
+		return err
+	}
+	for i, sv := range rs.lastcols {
+		fv := fieldByIndex(rv, fields[i].index)
+		err := convertAssignRows(fv.Addr().Interface(), sv, rs)
+		if err != nil {
+			return fmt.Errorf(`sql: ScanStruct error on column index %d, name %q: %w`, i, cols[i], err)
+		}
+	}
+	return nil
+}
+
+// checkScan reports whether rs is positioned on a row that can be
+// scanned. The name of the calling method is used in errors.
+func (rs *Rows) checkScan(method string) error {
 	rs.closemu.RLock()
 
 	if rs.lasterr != nil && rs.lasterr != io.EOF {
@@ -3135,16 +3366,7 @@ func (rs *Rows) Scan(dest ...interface{}) error {
 	rs.closemu.RUnlock()
 
 	if rs.lastcols == nil {
-		return errors.New("sql: Scan called without calling Next")
-	}
-	if len(dest) != len(rs.lastcols) {
-		return fmt.Errorf("sql: expected %d destination arguments in Scan, not %d", len(rs.lastcols), len(dest))
-	}
-	for i, sv := range rs.lastcols {
-		err := convertAssignRows(dest[i], sv, rs)
-		if err != nil {
-			return fmt.Errorf(`sql: Scan error on column index %d, name %q: %w`, i, rs.rowsi.Columns()[i], err)
-		}
+		return errors.New("sql: " + method + " called without calling Next")
 	}
 	return nil
 }
@@ -3242,6 +3464,38 @@ func (r *Row) Scan(dest ...interface{}) error {
 	return r.rows.Close()
 }
 
+// ScanStruct copies the columns from the matched row into the fields
+// of the struct pointed at by dest. See the documentation on
+// Rows.ScanStruct for details. If more than one row matches the query,
+// ScanStruct uses the first row and discards the rest. If no row
+// matches the query, ScanStruct returns ErrNoRows.
+func (r *Row) ScanStruct(dest interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if rv := reflect.ValueOf(dest); rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Struct {
+		// See the comment in Scan.
+		for _, f := range cachedStructFields(rv.Elem().Type()) {
+			if f.typ == rawBytesReflectType {
+				return errors.New("sql: RawBytes isn't allowed on Row.ScanStruct")
+			}
+		}
+	}
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNoRows
+	}
+	err := r.rows.ScanStruct(dest)
+	if err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
+
 // Err provides a way for wrapping packages to check for
 // query errors without calling Scan.
 // Err returns the error, if any, that was encountered while running the query.
@@ -3282,6 +3536,33 @@ func (dr driverResult) RowsAffected() (int64, error) {
 	return dr.resi.RowsAffected()
 }
 
+// batchResult is the Result of executing a statement once per set of
+// arguments in a batch.
+type batchResult struct {
+	sync.Locker // the *driverConn
+	resi        []driver.Result
+}
+
+func (br batchResult) LastInsertId() (int64, error) {
+	br.Lock()
+	defer br.Unlock()
+	return br.resi[len(br.resi)-1].LastInsertId()
+}
+
+func (br batchResult) RowsAffected() (int64, error) {
+	br.Lock()
+	defer br.Unlock()
+	var total int64
+	for _, resi := range br.resi {
+		n, err := resi.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
 func stack() string {
 	var buf [2 << 10]byte
 	return string(buf[:runtime.Stack(buf[:], false)])
@@ -773,6 +773,66 @@ func TestRowsColumnTypes(t *testing.T) {
 	}
 }
 
+func TestExecBatch(t *testing.T) {
+	for _, prefix := range []string{"", "BATCH|"} {
+		db := newTestDB(t, "")
+		exec(t, db, "CREATE|t1|name=string,age=int32")
+		// A batch runs several statements on one checkout of the conn.
+		db.freeConn[0].ci.(*fakeConn).skipDirtySession = true
+		ctx := context.Background()
+
+		res, err := db.ExecBatchContext(ctx, prefix+"INSERT|t1|name=?,age=?", [][]interface{}{
+			{"Alice", 1},
+			{"Bob", 2},
+			{"Chris", 3},
+		})
+		if err != nil {
+			t.Fatalf("%q: ExecBatchContext: %v", prefix, err)
+		}
+		if n, err := res.RowsAffected(); n != 3 || err != nil {
+			t.Errorf("%q: RowsAffected = %d, %v; want 3, nil", prefix, n, err)
+		}
+		wantBatch := 0
+		if prefix != "" {
+			wantBatch = 1
+		}
+		if n := db.freeConn[0].ci.(*fakeConn).numBatch; n != wantBatch {
+			t.Errorf("%q: driver ExecBatch calls = %d; want %d", prefix, n, wantBatch)
+		}
+
+		// A bad argument set stops the batch before anything is executed.
+		_, err = db.ExecBatchContext(ctx, prefix+"INSERT|t1|name=?,age=?", [][]interface{}{
+			{"Dave", 4},
+			{"Eve"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "argument set 1") {
+			t.Errorf("%q: ExecBatchContext with bad arguments = %v; want error for argument set 1", prefix, err)
+		}
+
+		res, err = db.ExecBatch(prefix+"INSERT|t1|name=?,age=?", nil)
+		if err != nil {
+			t.Fatalf("%q: empty ExecBatch: %v", prefix, err)
+		}
+		if n, err := res.RowsAffected(); n != 0 || err != nil {
+			t.Errorf("%q: empty RowsAffected = %d, %v; want 0, nil", prefix, n, err)
+		}
+
+		var count int
+		rows, err := db.Query("SELECT|t1|name|")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			count++
+		}
+		rows.Close()
+		if count != 3 {
+			t.Errorf("%q: got %d rows; want 3", prefix, count)
+		}
+		closeDB(t, db)
+	}
+}
+
 func TestQueryRow(t *testing.T) {
 	db := newTestDB(t, "people")
 	defer closeDB(t, db)
@@ -823,6 +883,120 @@ func TestQueryRow(t *testing.T) {
 	}
 }
 
+type scanBase struct {
+	Name string
+}
+
+type ScanDates struct {
+	Bdate time.Time
+}
+
+type scanPerson struct {
+	scanBase
+	*ScanDates
+	Years   int    `db:"AGE"`
+	Photo   []byte `db:"photo"`
+	Ignored string `db:"-"`
+}
+
+func TestRowsScanStruct(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+	rows, err := db.Query("SELECT|people|age,name,photo|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var got []scanPerson
+	for rows.Next() {
+		var p scanPerson
+		if err := rows.ScanStruct(&p); err != nil {
+			t.Fatalf("ScanStruct: %v", err)
+		}
+		got = append(got, p)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	want := []scanPerson{
+		{scanBase: scanBase{"Alice"}, Years: 1, Photo: []byte("APHOTO")},
+		{scanBase: scanBase{"Bob"}, Years: 2, Photo: []byte("BPHOTO")},
+		{scanBase: scanBase{"Chris"}, Years: 3, Photo: []byte("CPHOTO")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch.\n got: %#v\nwant: %#v", got, want)
+	}
+
+	// The embedded *ScanDates is allocated to hold bdate.
+	var p scanPerson
+	if err := db.QueryRow("SELECT|people|name,bdate|age=?", 3).ScanStruct(&p); err != nil {
+		t.Fatalf("QueryRow+ScanStruct: %v", err)
+	}
+	if p.Name != "Chris" || p.ScanDates == nil || !p.Bdate.Equal(chrisBirthday) {
+		t.Errorf("got %q, %v; want %q, %v", p.Name, p.ScanDates, "Chris", chrisBirthday)
+	}
+}
+
+func TestRowsScanStructErrors(t *testing.T) {
+	db := newTestDB(t, "people")
+	defer closeDB(t, db)
+
+	type ambiguous struct {
+		scanBase
+		Other struct{ scanBase }
+		Age   int
+	}
+	type other struct {
+		Name string
+	}
+	type twoNames struct {
+		scanBase
+		other
+		Age int
+	}
+	type rawBytes struct {
+		Name RawBytes
+	}
+	tests := []struct {
+		query string
+		dest  interface{}
+		err   string
+	}{
+		{"SELECT|people|age,name|age=?", scanPerson{}, "not a non-nil pointer to a struct: sql.scanPerson"},
+		{"SELECT|people|age,name|age=?", (*scanPerson)(nil), "not a non-nil pointer to a struct"},
+		{"SELECT|people|age,name,dead|age=?", new(scanPerson), `column index 2, name "dead"`},
+		{"SELECT|people|age,name|age=?", new(ambiguous), ""},
+		{"SELECT|people|age,name|age=?", new(twoNames), `column index 1, name "name"`},
+		{"SELECT|people|name|age=?", new(rawBytes), "RawBytes isn't allowed"},
+		{"SELECT|people|age,name|age=?", new(struct{ Age, Name bool }), `ScanStruct error on column index 1, name "name"`},
+	}
+	for _, tt := range tests {
+		err := db.QueryRow(tt.query, 1).ScanStruct(tt.dest)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("ScanStruct(%T): %v", tt.dest, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ScanStruct(%T) = %v; want error containing %q", tt.dest, err, tt.err)
+		}
+	}
+
+	err := db.QueryRow("SELECT|people|age,name|age=?", 42).ScanStruct(new(scanPerson))
+	if err != ErrNoRows {
+		t.Errorf("ScanStruct with no rows = %v; want ErrNoRows", err)
+	}
+	var p scanPerson
+	rows, err := db.Query("SELECT|people|age,name|")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	defer rows.Close()
+	if err := rows.ScanStruct(&p); err == nil || !strings.Contains(err.Error(), "without calling Next") {
+		t.Errorf("ScanStruct before Next = %v; want error", err)
+	}
+}
+
 func TestRowErr(t *testing.T) {
 	db := newTestDB(t, "people")
 
@@ -4313,6 +4487,115 @@ func TestPing(t *testing.T) {
 	}
 }
 
+// savepointDriver is a driver.Driver that records the statements and
+// savepoint calls made on its transactions.
+type savepointDriver struct {
+	native bool
+	log    []string
+}
+
+func (d *savepointDriver) Open(name string) (driver.Conn, error) {
+	return savepointConn{d: d}, nil
+}
+
+type savepointConn struct {
+	badConn
+	d *savepointDriver
+}
+
+func (c savepointConn) Begin() (driver.Tx, error) {
+	if c.d.native {
+		return nativeSavepointTx{savepointTx{c.d}}, nil
+	}
+	return savepointTx{c.d}, nil
+}
+
+func (c savepointConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.log = append(c.d.log, query)
+	return driver.ResultNoRows, nil
+}
+
+type savepointTx struct {
+	d *savepointDriver
+}
+
+func (tx savepointTx) Commit() error {
+	tx.d.log = append(tx.d.log, "COMMIT")
+	return nil
+}
+
+func (tx savepointTx) Rollback() error {
+	tx.d.log = append(tx.d.log, "ROLLBACK")
+	return nil
+}
+
+type nativeSavepointTx struct {
+	savepointTx
+}
+
+func (tx nativeSavepointTx) Savepoint(ctx context.Context, name string) error {
+	tx.d.log = append(tx.d.log, "Savepoint "+name)
+	return nil
+}
+
+func (tx nativeSavepointTx) RollbackTo(ctx context.Context, name string) error {
+	tx.d.log = append(tx.d.log, "RollbackTo "+name)
+	return nil
+}
+
+func (tx nativeSavepointTx) Release(ctx context.Context, name string) error {
+	tx.d.log = append(tx.d.log, "Release "+name)
+	return nil
+}
+
+var _ driver.SavepointTx = nativeSavepointTx{}
+
+func TestTxSavepoint(t *testing.T) {
+	tests := []struct {
+		native bool
+		want   []string
+	}{
+		{false, []string{"SAVEPOINT a", "SAVEPOINT b_1", "ROLLBACK TO SAVEPOINT b_1", "RELEASE SAVEPOINT a", "COMMIT"}},
+		{true, []string{"Savepoint a", "Savepoint b_1", "RollbackTo b_1", "Release a", "COMMIT"}},
+	}
+	for _, tt := range tests {
+		d := &savepointDriver{native: tt.native}
+		db := OpenDB(dsnConnector{driver: d})
+		ctx := context.Background()
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"", "1a", "a b", "a;DROP TABLE t"} {
+			if err := tx.Savepoint(ctx, name); err == nil {
+				t.Errorf("Savepoint(%q) succeeded; want error", name)
+			}
+		}
+		if err := tx.Savepoint(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Savepoint(ctx, "b_1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.RollbackTo(ctx, "b_1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Release(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Savepoint(ctx, "c"); err != ErrTxDone {
+			t.Errorf("Savepoint after Commit = %v; want ErrTxDone", err)
+		}
+		db.Close()
+		if !reflect.DeepEqual(d.log, tt.want) {
+			t.Errorf("native = %v:\n got %q\nwant %q", tt.native, d.log, tt.want)
+		}
+	}
+}
+
 // Issue 18101.
 func TestTypedString(t *testing.T) {
 	db := newTestDB(t, "people")
@@ -0,0 +1,43 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildinfo_test
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReadFileSelf(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("os.Executable: %v", err)
+	}
+	info, err := buildinfo.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := buildinfo.Read(bytes.NewReader([]byte("not an executable, just some text"))); err == nil {
+		t.Error("Read of non-executable data succeeded unexpectedly")
+	}
+	if _, err := buildinfo.Read(bytes.NewReader(nil)); err == nil {
+		t.Error("Read of empty data succeeded unexpectedly")
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := buildinfo.ReadFile(missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile(%q) = %v, want not-exist error", missing, err)
+	}
+}
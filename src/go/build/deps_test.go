@@ -96,6 +96,10 @@ var depsRules = `
 	< sort
 	< container/heap;
 
+	RUNTIME
+	< internal/synctest
+	< testing/synctest;
+
 	RUNTIME
 	< io;
 
@@ -238,7 +242,7 @@ var depsRules = `
 
 	# compression
 	FMT, encoding/binary, hash/adler32, hash/crc32
-	< compress/bzip2, compress/flate, compress/lzw
+	< compress/bzip2, compress/flate, compress/lzw, compress/zstd
 	< archive/zip, compress/gzip, compress/zlib;
 
 	# templates
@@ -268,6 +272,9 @@ var depsRules = `
 	< debug/elf, debug/gosym, debug/macho, debug/pe, debug/plan9obj, internal/xcoff
 	< DEBUG;
 
+	DEBUG, runtime/debug
+	< debug/buildinfo;
+
 	# go parser and friends.
 	FMT
 	< go/token
@@ -312,6 +319,10 @@ var depsRules = `
 	< image/draw
 	< image/gif, image/jpeg, image/png;
 
+	image, bufio
+	< image/webp/internal/vp8, image/webp/internal/vp8l
+	< image/webp;
+
 	# cgo, delayed as long as possible.
 	# If you add a dependency on CGO, you must add the package
 	# to cgoPackages in cmd/dist/test.go as well.
@@ -443,6 +454,7 @@ var depsRules = `
 	< net/http/httptrace;
 
 	compress/gzip,
+	compress/zstd,
 	golang.org/x/net/http/httpguts,
 	golang.org/x/net/http/httpproxy,
 	golang.org/x/net/http2/hpack,
@@ -455,10 +467,10 @@ var depsRules = `
 	# HTTP-aware packages
 
 	encoding/json, net/http
-	< expvar;
+	< expvar, net/http/cookiejar;
 
 	net/http
-	< net/http/cookiejar, net/http/httputil;
+	< net/http/httputil;
 
 	net/http, flag
 	< net/http/httptest;
@@ -46,3 +46,41 @@ func ExampleDrawer_floydSteinberg() {
 		}
 	}
 }
+
+func ExampleInterpolator() {
+	// A 2x2 source image: black, red, green and blue.
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.RGBA{0x00, 0x00, 0x00, 0xff})
+	src.Set(1, 0, color.RGBA{0xff, 0x00, 0x00, 0xff})
+	src.Set(0, 1, color.RGBA{0x00, 0xff, 0x00, 0xff})
+	src.Set(1, 1, color.RGBA{0x00, 0x00, 0xff, 0xff})
+
+	// Scale it up to 4x4, and sample the middle of the top row.
+	for _, q := range []struct {
+		name   string
+		interp draw.Interpolator
+	}{
+		{"NearestNeighbor", draw.NearestNeighbor},
+		{"ApproxBiLinear", draw.ApproxBiLinear},
+		{"CatmullRom", draw.CatmullRom},
+	} {
+		dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		q.interp.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+		fmt.Printf("%-15s %v\n", q.name, dst.At(1, 0))
+	}
+
+	// Rotate it by 90 degrees clockwise about the origin, and shift it
+	// right by 2 pixels to keep it inside dst.
+	dst := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw.NearestNeighbor.Transform(dst, draw.Aff3{
+		0, -1, 2,
+		1, 0, 0,
+	}, src, src.Bounds(), draw.Src, nil)
+	fmt.Println(dst.At(0, 0), dst.At(1, 0))
+
+	// Output:
+	// NearestNeighbor {0 0 0 255}
+	// ApproxBiLinear  {63 0 0 255}
+	// CatmullRom      {57 0 0 255}
+	// {0 255 0 255} {0 0 0 255}
+}
@@ -118,6 +118,17 @@ type decoder struct {
 	// transparency, as opposed to palette transparency.
 	useTransparent bool
 	transparent    [6]byte
+
+	// anim is non-nil if the animation chunks of an APNG image are being
+	// decoded, rather than ignored. Their sequence numbers start at 0 and
+	// seqNum is the next one expected. frame holds the contents of an fcTL
+	// chunk whose image data has not been decoded yet. fdAT is set while
+	// the image data is read from fdAT chunks rather than IDAT chunks.
+	anim     *APNG
+	seenacTL bool
+	seqNum   uint32
+	frame    *frameControl
+	fdAT     bool
 }
 
 // A FormatError reports that the input is not a valid PNG.
@@ -324,7 +335,8 @@ func (d *decoder) parsetRNS(length uint32) error {
 }
 
 // Read presents one or more IDAT chunks as one continuous stream (minus the
-// intermediate chunk headers and footers). If the PNG data looked like:
+// intermediate chunk headers and footers), or one or more fdAT chunks (minus
+// their sequence numbers too) if d.fdAT is set. If the PNG data looked like:
 //   ... len0 IDAT xxx crc0 len1 IDAT yy crc1 len2 IEND crc2
 // then this reader presents xxxyy. For well-formed PNG data, the decoder state
 // immediately before the first Read call is that d.r is positioned between the
@@ -345,11 +357,20 @@ func (d *decoder) Read(p []byte) (int, error) {
 			return 0, err
 		}
 		d.idatLength = binary.BigEndian.Uint32(d.tmp[:4])
-		if string(d.tmp[4:8]) != "IDAT" {
+		name := "IDAT"
+		if d.fdAT {
+			name = "fdAT"
+		}
+		if string(d.tmp[4:8]) != name {
 			return 0, FormatError("not enough pixel data")
 		}
 		d.crc.Reset()
 		d.crc.Write(d.tmp[4:8])
+		if d.fdAT {
+			if err := d.parseSequenceNumber(&d.idatLength); err != nil {
+				return 0, err
+			}
+		}
 	}
 	if int(d.idatLength) < 0 {
 		return 0, UnsupportedError("IDAT chunk length overflow")
@@ -850,6 +871,10 @@ func (d *decoder) parseIDAT(length uint32) (err error) {
 	if err != nil {
 		return err
 	}
+	if d.frame != nil {
+		// The image is the first frame of the animation.
+		d.addFrame(d.img)
+	}
 	return d.verifyChecksum()
 }
 
@@ -857,6 +882,9 @@ func (d *decoder) parseIEND(length uint32) error {
 	if length != 0 {
 		return FormatError("bad IEND length")
 	}
+	if d.frame != nil {
+		return FormatError("missing fdAT chunk")
+	}
 	return d.verifyChecksum()
 }
 
@@ -912,6 +940,32 @@ func (d *decoder) parseChunk() error {
 		}
 		d.stage = dsSeenIEND
 		return d.parseIEND(length)
+	case "acTL":
+		if d.anim == nil {
+			break
+		}
+		if d.stage < dsSeenIHDR || d.stage >= dsSeenIDAT || d.seenacTL {
+			return chunkOrderError
+		}
+		return d.parseacTL(length)
+	case "fcTL":
+		if d.anim == nil || !d.seenacTL {
+			break
+		}
+		if d.stage < dsSeenIHDR || d.frame != nil {
+			return chunkOrderError
+		}
+		return d.parsefcTL(length)
+	case "fdAT":
+		if d.anim == nil || !d.seenacTL || d.frame == nil {
+			// Ignore trailing zero-length or garbage fdAT chunks, as
+			// for IDAT chunks.
+			break
+		}
+		if d.stage != dsSeenIDAT {
+			return chunkOrderError
+		}
+		return d.parsefdAT(length)
 	}
 	if length > 0x7fffffff {
 		return FormatError(fmt.Sprintf("Bad chunk length: %d", length))
@@ -929,6 +983,144 @@ func (d *decoder) parseChunk() error {
 	return d.verifyChecksum()
 }
 
+// parseSequenceNumber reads the sequence number at the start of an fcTL or
+// fdAT chunk, and subtracts its size from *length.
+func (d *decoder) parseSequenceNumber(length *uint32) error {
+	if *length < 4 {
+		return FormatError("bad sequence number")
+	}
+	if _, err := io.ReadFull(d.r, d.tmp[:4]); err != nil {
+		return err
+	}
+	d.crc.Write(d.tmp[:4])
+	if binary.BigEndian.Uint32(d.tmp[:4]) != d.seqNum {
+		return FormatError("chunk out of sequence")
+	}
+	d.seqNum++
+	*length -= 4
+	return nil
+}
+
+func (d *decoder) parseacTL(length uint32) error {
+	if length != 8 {
+		return FormatError("bad acTL length")
+	}
+	if _, err := io.ReadFull(d.r, d.tmp[:8]); err != nil {
+		return err
+	}
+	d.crc.Write(d.tmp[:8])
+	if binary.BigEndian.Uint32(d.tmp[0:4]) == 0 {
+		return FormatError("bad number of frames")
+	}
+	d.anim.LoopCount = int(binary.BigEndian.Uint32(d.tmp[4:8]))
+	d.seenacTL = true
+	return d.verifyChecksum()
+}
+
+// frameControl holds the contents of an fcTL chunk.
+type frameControl struct {
+	rect     image.Rectangle
+	delay    int
+	disposal byte
+	blend    byte
+}
+
+func (d *decoder) parsefcTL(length uint32) error {
+	if length != 26 {
+		return FormatError("bad fcTL length")
+	}
+	if err := d.parseSequenceNumber(&length); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(d.r, d.tmp[:22]); err != nil {
+		return err
+	}
+	d.crc.Write(d.tmp[:22])
+	w := int64(binary.BigEndian.Uint32(d.tmp[0:4]))
+	h := int64(binary.BigEndian.Uint32(d.tmp[4:8]))
+	x := int64(binary.BigEndian.Uint32(d.tmp[8:12]))
+	y := int64(binary.BigEndian.Uint32(d.tmp[12:16]))
+	if w == 0 || h == 0 || x+w > int64(d.width) || y+h > int64(d.height) {
+		return FormatError("bad frame dimensions")
+	}
+	if d.stage < dsSeenIDAT && (x != 0 || y != 0 || w != int64(d.width) || h != int64(d.height)) {
+		// The first frame is the default image.
+		return FormatError("bad frame dimensions")
+	}
+	if d.tmp[20] > DisposalPrevious || d.tmp[21] > BlendOver {
+		return FormatError("bad frame disposal or blend")
+	}
+	// The delay is a fraction of a second, in which a zero denominator
+	// stands for 100.
+	num := int(binary.BigEndian.Uint16(d.tmp[16:18]))
+	den := int(binary.BigEndian.Uint16(d.tmp[18:20]))
+	if den == 0 {
+		den = 100
+	}
+	d.frame = &frameControl{
+		rect:     image.Rect(int(x), int(y), int(x+w), int(y+h)),
+		delay:    (num*100 + den/2) / den,
+		disposal: d.tmp[20],
+		blend:    d.tmp[21],
+	}
+	return d.verifyChecksum()
+}
+
+func (d *decoder) parsefdAT(length uint32) error {
+	if err := d.parseSequenceNumber(&length); err != nil {
+		return err
+	}
+	// Decode the frame as if it were a whole image of its size.
+	width, height := d.width, d.height
+	d.width, d.height = d.frame.rect.Dx(), d.frame.rect.Dy()
+	d.fdAT = true
+	d.idatLength = length
+	m, err := d.decode()
+	d.width, d.height = width, height
+	d.fdAT = false
+	if err != nil {
+		return err
+	}
+	d.addFrame(m)
+	return d.verifyChecksum()
+}
+
+// addFrame adds m to the animation, positioned as described by the
+// preceding fcTL chunk.
+func (d *decoder) addFrame(m image.Image) {
+	f := d.frame
+	d.frame = nil
+	if f.rect.Min != (image.Point{}) {
+		setOrigin(m, f.rect.Min)
+	}
+	a := d.anim
+	a.Image = append(a.Image, m)
+	a.Delay = append(a.Delay, f.delay)
+	a.Disposal = append(a.Disposal, f.disposal)
+	a.Blend = append(a.Blend, f.blend)
+}
+
+// setOrigin moves an image returned by readImagePass so that its bounds
+// start at p.
+func setOrigin(m image.Image, p image.Point) {
+	switch m := m.(type) {
+	case *image.Gray:
+		m.Rect = m.Rect.Add(p)
+	case *image.Gray16:
+		m.Rect = m.Rect.Add(p)
+	case *image.NRGBA:
+		m.Rect = m.Rect.Add(p)
+	case *image.NRGBA64:
+		m.Rect = m.Rect.Add(p)
+	case *image.Paletted:
+		m.Rect = m.Rect.Add(p)
+	case *image.RGBA:
+		m.Rect = m.Rect.Add(p)
+	case *image.RGBA64:
+		m.Rect = m.Rect.Add(p)
+	}
+}
+
 func (d *decoder) verifyChecksum() error {
 	if _, err := io.ReadFull(d.r, d.tmp[:4]); err != nil {
 		return err
@@ -974,6 +1166,82 @@ func Decode(r io.Reader) (image.Image, error) {
 	return d.img, nil
 }
 
+// Disposal methods, as per the APNG spec.
+const (
+	DisposalNone       = 0x00
+	DisposalBackground = 0x01
+	DisposalPrevious   = 0x02
+)
+
+// Blend operations, as per the APNG spec.
+const (
+	BlendSource = 0x00
+	BlendOver   = 0x01
+)
+
+// APNG represents the possibly multiple images stored in an animated PNG
+// (APNG) file.
+//
+// The APNG specification is at https://wiki.mozilla.org/APNG_Specification.
+type APNG struct {
+	// Image is the successive frames. The bounds of each frame are its
+	// position within the canvas, whose top-left corner is at (0, 0).
+	// The first frame covers the whole canvas.
+	Image []image.Image
+	// Delay is the successive delay times, one per frame, in 100ths of
+	// a second.
+	Delay []int
+	// Disposal is the successive disposal methods, one per frame. If
+	// nil when encoding, DisposalNone is used for every frame.
+	Disposal []byte
+	// Blend is the successive blend operations, one per frame. If nil
+	// when encoding, BlendSource is used for every frame.
+	Blend []byte
+	// LoopCount is the number of times the animation is played.
+	// Zero means to loop forever.
+	LoopCount int
+	// Config is the global color model and canvas dimensions. If zero
+	// when encoding, the canvas is the size of the first frame.
+	Config image.Config
+}
+
+// DecodeAll reads a PNG image from r and returns the sequential frames of
+// its animation and timing information. A PNG image that is not animated
+// is returned as a single frame. A default image that is not part of the
+// animation is not returned.
+func DecodeAll(r io.Reader) (*APNG, error) {
+	a := new(APNG)
+	d := &decoder{
+		r:    r,
+		crc:  crc32.NewIEEE(),
+		anim: a,
+	}
+	if err := d.checkHeader(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	for d.stage != dsSeenIEND {
+		if err := d.parseChunk(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	if !d.seenacTL {
+		a.Image = []image.Image{d.img}
+		a.Delay = []int{0}
+		a.Disposal = []byte{DisposalNone}
+		a.Blend = []byte{BlendSource}
+	} else if len(a.Image) == 0 {
+		return nil, FormatError("no animation frames")
+	}
+	a.Config = d.config()
+	return a, nil
+}
+
 // DecodeConfig returns the color model and dimensions of a PNG image without
 // decoding the entire image.
 func DecodeConfig(r io.Reader) (image.Config, error) {
@@ -1002,6 +1270,12 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 			break
 		}
 	}
+	return d.config(), nil
+}
+
+// config returns the color model and dimensions given by the IHDR and
+// PLTE chunks.
+func (d *decoder) config() image.Config {
 	var cm color.Model
 	switch d.cb {
 	case cbG1, cbG2, cbG4, cbG8:
@@ -1027,7 +1301,7 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 		ColorModel: cm,
 		Width:      d.width,
 		Height:     d.height,
-	}, nil
+	}
 }
 
 func init() {
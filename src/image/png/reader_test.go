@@ -7,7 +7,9 @@ package png
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
 	"io"
@@ -823,3 +825,120 @@ func BenchmarkDecodeRGB(b *testing.B) {
 func BenchmarkDecodeInterlacing(b *testing.B) {
 	benchmarkDecode(b, "testdata/benchRGB-interlace.png", 4)
 }
+
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// splitChunks returns the chunks of a PNG image.
+func splitChunks(t *testing.T, data []byte) []pngChunk {
+	t.Helper()
+	var chunks []pngChunk
+	for data = data[len(pngHeader):]; len(data) > 0; {
+		n := binary.BigEndian.Uint32(data[:4])
+		chunks = append(chunks, pngChunk{string(data[4:8]), data[8 : 8+n]})
+		data = data[12+n:]
+	}
+	return chunks
+}
+
+// joinChunks returns a PNG image made of chunks.
+func joinChunks(chunks []pngChunk) []byte {
+	data := []byte(pngHeader)
+	for _, c := range chunks {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(len(c.data)))
+		data = append(data, b[:]...)
+		data = append(data, c.name...)
+		data = append(data, c.data...)
+		binary.BigEndian.PutUint32(b[:], crc32.ChecksumIEEE(append([]byte(c.name), c.data...)))
+		data = append(data, b[:]...)
+	}
+	return data
+}
+
+func TestDecodeAllStill(t *testing.T) {
+	data, err := os.ReadFile("testdata/pngsuite/basn6a08.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Image) != 1 || len(a.Delay) != 1 || len(a.Disposal) != 1 || len(a.Blend) != 1 {
+		t.Fatalf("got %d images, %d delays, %d disposals and %d blends, want 1 of each",
+			len(a.Image), len(a.Delay), len(a.Disposal), len(a.Blend))
+	}
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a.Image[0], m) {
+		t.Errorf("DecodeAll and Decode returned different images")
+	}
+	if a.Config.Width != 32 || a.Config.Height != 32 || a.Config.ColorModel != color.NRGBAModel {
+		t.Errorf("Config = %+v", a.Config)
+	}
+}
+
+// TestDecodeAllDefaultImage tests an APNG image whose default image is not
+// part of the animation.
+func TestDecodeAllDefaultImage(t *testing.T) {
+	frames := testFrames(func(r image.Rectangle, i int) image.Image {
+		m := image.NewGray(r)
+		for j := range m.Pix {
+			m.Pix[j] = uint8(0x40 * i)
+		}
+		return m
+	})
+	var buf bytes.Buffer
+	if err := EncodeAll(&buf, &APNG{Image: frames[:2], Delay: []int{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	// Drop the first fcTL chunk, and renumber the others.
+	var chunks []pngChunk
+	for _, c := range splitChunks(t, buf.Bytes()) {
+		switch c.name {
+		case "acTL":
+			binary.BigEndian.PutUint32(c.data, 1)
+		case "fcTL", "fdAT":
+			seq := binary.BigEndian.Uint32(c.data)
+			if seq == 0 {
+				continue
+			}
+			binary.BigEndian.PutUint32(c.data, seq-1)
+		}
+		chunks = append(chunks, c)
+	}
+	data := joinChunks(chunks)
+
+	a, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Image) != 1 || len(a.Delay) != 1 {
+		t.Fatalf("got %d images and %d delays, want 1 of each", len(a.Image), len(a.Delay))
+	}
+	if err := diff(a.Image[0], frames[1]); err != nil || a.Image[0].Bounds() != frames[1].Bounds() || a.Delay[0] != 2 {
+		t.Errorf("got frame with bounds %v, delay %d (%v); want the second frame", a.Image[0].Bounds(), a.Delay[0], err)
+	}
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := diff(m, frames[0]); err != nil {
+		t.Errorf("Decode: %v", err)
+	}
+
+	// Out of order sequence numbers are an error.
+	for _, c := range chunks {
+		if c.name == "fdAT" {
+			binary.BigEndian.PutUint32(c.data, 5)
+		}
+	}
+	if _, err := DecodeAll(bytes.NewReader(joinChunks(chunks))); err == nil {
+		t.Errorf("DecodeAll with bad sequence number succeeded")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"compress/zlib"
 	"encoding/binary"
+	"errors"
 	"hash/crc32"
 	"image"
 	"image/color"
@@ -49,6 +50,13 @@ type encoder struct {
 	zw      *zlib.Writer
 	zwLevel int
 	bw      *bufio.Writer
+
+	// fdAT is set while writing the frames of an animation after the
+	// first, whose image data goes in fdAT chunks rather than IDAT chunks.
+	// seq is the next sequence number of an fcTL or fdAT chunk.
+	fdAT bool
+	seq  uint32
+	fbuf []byte
 }
 
 type CompressionLevel int
@@ -188,19 +196,48 @@ func (e *encoder) writePLTEAndTRNS(p color.Palette) {
 }
 
 // An encoder is an io.Writer that satisfies writes by writing PNG IDAT chunks,
-// including an 8-byte header and 4-byte CRC checksum per Write call. Such calls
-// should be relatively infrequent, since writeIDATs uses a bufio.Writer.
+// or fdAT chunks if e.fdAT is set, including an 8-byte header and 4-byte CRC
+// checksum per Write call. Such calls should be relatively infrequent, since
+// writeIDATs uses a bufio.Writer.
 //
 // This method should only be called from writeIDATs (via writeImage).
 // No other code should treat an encoder as an io.Writer.
 func (e *encoder) Write(b []byte) (int, error) {
-	e.writeChunk(b, "IDAT")
+	if e.fdAT {
+		e.fbuf = append(e.fbuf[:0], 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(e.fbuf, e.seq)
+		e.seq++
+		e.fbuf = append(e.fbuf, b...)
+		e.writeChunk(e.fbuf, "fdAT")
+	} else {
+		e.writeChunk(b, "IDAT")
+	}
 	if e.err != nil {
 		return 0, e.err
 	}
 	return len(b), nil
 }
 
+func (e *encoder) writeacTL(numFrames, numPlays int) {
+	binary.BigEndian.PutUint32(e.tmp[0:4], uint32(numFrames))
+	binary.BigEndian.PutUint32(e.tmp[4:8], uint32(numPlays))
+	e.writeChunk(e.tmp[:8], "acTL")
+}
+
+func (e *encoder) writefcTL(r image.Rectangle, delay int, disposal, blend byte) {
+	binary.BigEndian.PutUint32(e.tmp[0:4], e.seq)
+	e.seq++
+	binary.BigEndian.PutUint32(e.tmp[4:8], uint32(r.Dx()))
+	binary.BigEndian.PutUint32(e.tmp[8:12], uint32(r.Dy()))
+	binary.BigEndian.PutUint32(e.tmp[12:16], uint32(r.Min.X))
+	binary.BigEndian.PutUint32(e.tmp[16:20], uint32(r.Min.Y))
+	binary.BigEndian.PutUint16(e.tmp[20:22], uint16(delay))
+	binary.BigEndian.PutUint16(e.tmp[22:24], 100)
+	e.tmp[24] = disposal
+	e.tmp[25] = blend
+	e.writeChunk(e.tmp[:26], "fcTL")
+}
+
 // Chooses the filter to use for encoding the current row, and applies it.
 // The return value is the index of the filter and also of the row in cr that has had it applied.
 func filter(cr *[nFilter][]byte, pr []byte, bpp int) int {
@@ -480,6 +517,17 @@ func (e *encoder) writeImage(w io.Writer, m image.Image, cb int, level int) erro
 				i += 6
 			}
 		case cbTCA16:
+			if nrgba != nil {
+				// Widen each 8-bit sample. This happens for animations
+				// with both NRGBA and 16-bit frames.
+				offset := (y - b.Min.Y) * nrgba.Stride
+				for _, v := range nrgba.Pix[offset : offset+b.Dx()*4] {
+					cr[0][i+0] = v
+					cr[0][i+1] = v
+					i += 2
+				}
+				break
+			}
 			// Convert from image.Image (which is alpha-premultiplied) to PNG's non-alpha-premultiplied.
 			for x := b.Min.X; x < b.Max.X; x++ {
 				c := color.NRGBA64Model.Convert(m.At(x, y)).(color.NRGBA64)
@@ -561,16 +609,21 @@ func Encode(w io.Writer, m image.Image) error {
 	return e.Encode(w, m)
 }
 
-// Encode writes the Image m to w in PNG format.
-func (enc *Encoder) Encode(w io.Writer, m image.Image) error {
+// checkSize reports whether r is a valid size for a PNG image.
+func checkSize(r image.Rectangle) error {
 	// Obviously, negative widths and heights are invalid. Furthermore, the PNG
 	// spec section 11.2.2 says that zero is invalid. Excessively large images are
 	// also rejected.
-	mw, mh := int64(m.Bounds().Dx()), int64(m.Bounds().Dy())
+	mw, mh := int64(r.Dx()), int64(r.Dy())
 	if mw <= 0 || mh <= 0 || mw >= 1<<32 || mh >= 1<<32 {
 		return FormatError("invalid image size: " + strconv.FormatInt(mw, 10) + "x" + strconv.FormatInt(mh, 10))
 	}
+	return nil
+}
 
+// newEncoder returns an encoder, from enc's BufferPool if it has one. The
+// caller must pass it to putEncoder when done.
+func (enc *Encoder) newEncoder(w io.Writer) *encoder {
 	var e *encoder
 	if enc.BufferPool != nil {
 		buffer := enc.BufferPool.Get()
@@ -580,49 +633,71 @@ func (enc *Encoder) Encode(w io.Writer, m image.Image) error {
 	if e == nil {
 		e = &encoder{}
 	}
-	if enc.BufferPool != nil {
-		defer enc.BufferPool.Put((*EncoderBuffer)(e))
-	}
-
 	e.enc = enc
 	e.w = w
-	e.m = m
+	e.err = nil
+	e.fdAT = false
+	e.seq = 0
+	return e
+}
 
-	var pal color.Palette
+func (enc *Encoder) putEncoder(e *encoder) {
+	if enc.BufferPool != nil {
+		enc.BufferPool.Put((*EncoderBuffer)(e))
+	}
+}
+
+// colorType returns the color type and bit depth that m is encoded with,
+// and its palette if it is paletted.
+func colorType(m image.Image) (cb int, pal color.Palette) {
 	// cbP8 encoding needs PalettedImage's ColorIndexAt method.
 	if _, ok := m.(image.PalettedImage); ok {
 		pal, _ = m.ColorModel().(color.Palette)
 	}
 	if pal != nil {
 		if len(pal) <= 2 {
-			e.cb = cbP1
+			cb = cbP1
 		} else if len(pal) <= 4 {
-			e.cb = cbP2
+			cb = cbP2
 		} else if len(pal) <= 16 {
-			e.cb = cbP4
+			cb = cbP4
 		} else {
-			e.cb = cbP8
+			cb = cbP8
 		}
 	} else {
 		switch m.ColorModel() {
 		case color.GrayModel:
-			e.cb = cbG8
+			cb = cbG8
 		case color.Gray16Model:
-			e.cb = cbG16
+			cb = cbG16
 		case color.RGBAModel, color.NRGBAModel, color.AlphaModel:
 			if opaque(m) {
-				e.cb = cbTC8
+				cb = cbTC8
 			} else {
-				e.cb = cbTCA8
+				cb = cbTCA8
 			}
 		default:
 			if opaque(m) {
-				e.cb = cbTC16
+				cb = cbTC16
 			} else {
-				e.cb = cbTCA16
+				cb = cbTCA16
 			}
 		}
 	}
+	return cb, pal
+}
+
+// Encode writes the Image m to w in PNG format.
+func (enc *Encoder) Encode(w io.Writer, m image.Image) error {
+	if err := checkSize(m.Bounds()); err != nil {
+		return err
+	}
+
+	e := enc.newEncoder(w)
+	defer enc.putEncoder(e)
+	e.m = m
+	var pal color.Palette
+	e.cb, pal = colorType(m)
 
 	_, e.err = io.WriteString(w, pngHeader)
 	e.writeIHDR()
@@ -633,3 +708,144 @@ func (enc *Encoder) Encode(w io.Writer, m image.Image) error {
 	e.writeIEND()
 	return e.err
 }
+
+// animationColorType returns the color type and bit depth that all of the
+// frames of an animation are encoded with, which is that of the first frame
+// if the others agree with it, and otherwise wide enough for all of them.
+func animationColorType(frames []image.Image) (cb int, pal color.Palette) {
+	cb, pal = colorType(frames[0])
+	same := true
+	for _, m := range frames[1:] {
+		cb1, pal1 := colorType(m)
+		if cb1 != cb || !samePalette(pal, pal1) {
+			same = false
+			break
+		}
+	}
+	if same {
+		return cb, pal
+	}
+
+	deep, col, alpha := false, false, false
+	for _, m := range frames {
+		switch cb, _ := colorType(m); cb {
+		case cbG8:
+		case cbG16:
+			deep = true
+		case cbTC8:
+			col = true
+		case cbTCA8:
+			col, alpha = true, true
+		case cbTC16:
+			deep, col = true, true
+		case cbTCA16:
+			deep, col, alpha = true, true, true
+		default: // Paletted.
+			col = true
+			alpha = alpha || !opaque(m)
+		}
+	}
+	switch {
+	case !col && deep:
+		return cbG16, nil
+	case !col:
+		return cbG8, nil
+	case deep && alpha:
+		return cbTCA16, nil
+	case deep:
+		return cbTC16, nil
+	case alpha:
+		return cbTCA8, nil
+	}
+	return cbTC8, nil
+}
+
+func samePalette(p, q color.Palette) bool {
+	if len(p) != len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeAll writes the images in a to w in APNG format.
+func EncodeAll(w io.Writer, a *APNG) error {
+	var e Encoder
+	return e.EncodeAll(w, a)
+}
+
+// EncodeAll writes the images in a to w in APNG format. The first frame
+// is also the image shown by decoders that do not support animation.
+func (enc *Encoder) EncodeAll(w io.Writer, a *APNG) error {
+	if len(a.Image) == 0 {
+		return errors.New("png: must provide at least one image")
+	}
+	if len(a.Image) != len(a.Delay) {
+		return errors.New("png: mismatched image and delay lengths")
+	}
+	if a.Disposal != nil && len(a.Image) != len(a.Disposal) {
+		return errors.New("png: mismatched image and disposal lengths")
+	}
+	if a.Blend != nil && len(a.Image) != len(a.Blend) {
+		return errors.New("png: mismatched image and blend lengths")
+	}
+	canvas := image.Rect(0, 0, a.Config.Width, a.Config.Height)
+	if canvas.Empty() {
+		canvas = image.Rectangle{Max: a.Image[0].Bounds().Max}
+	}
+	if err := checkSize(canvas); err != nil {
+		return err
+	}
+	if a.Image[0].Bounds() != canvas {
+		return errors.New("png: first frame does not cover the canvas")
+	}
+	for i, m := range a.Image {
+		if err := checkSize(m.Bounds()); err != nil {
+			return err
+		}
+		if !m.Bounds().In(canvas) {
+			return errors.New("png: frame not within the canvas")
+		}
+		if a.Delay[i] < 0 || a.Delay[i] > 0xffff {
+			return errors.New("png: invalid delay")
+		}
+		if a.Disposal != nil && a.Disposal[i] > DisposalPrevious {
+			return errors.New("png: invalid disposal method")
+		}
+		if a.Blend != nil && a.Blend[i] > BlendOver {
+			return errors.New("png: invalid blend operation")
+		}
+	}
+
+	e := enc.newEncoder(w)
+	defer enc.putEncoder(e)
+	e.m = a.Image[0]
+	var pal color.Palette
+	e.cb, pal = animationColorType(a.Image)
+
+	_, e.err = io.WriteString(w, pngHeader)
+	e.writeIHDR()
+	e.writeacTL(len(a.Image), a.LoopCount)
+	if pal != nil {
+		e.writePLTEAndTRNS(pal)
+	}
+	for i, m := range a.Image {
+		var disposal, blend byte
+		if a.Disposal != nil {
+			disposal = a.Disposal[i]
+		}
+		if a.Blend != nil {
+			blend = a.Blend[i]
+		}
+		e.writefcTL(m.Bounds(), a.Delay[i], disposal, blend)
+		e.m = m
+		e.fdAT = i > 0
+		e.writeIDATs()
+	}
+	e.writeIEND()
+	return e.err
+}
@@ -12,6 +12,7 @@ import (
 	"image"
 	"image/color"
 	"io"
+	"reflect"
 	"testing"
 )
 
@@ -340,3 +341,138 @@ func BenchmarkEncodeRGBA(b *testing.B) {
 		Encode(io.Discard, img)
 	}
 }
+
+// testFrames returns the frames of an animation on a 16x12 canvas. Each
+// frame after the first is drawn in a rectangle of its own.
+func testFrames(newImage func(r image.Rectangle, i int) image.Image) []image.Image {
+	rects := []image.Rectangle{
+		image.Rect(0, 0, 16, 12),
+		image.Rect(2, 3, 9, 12),
+		image.Rect(15, 0, 16, 1),
+	}
+	frames := make([]image.Image, len(rects))
+	for i, r := range rects {
+		frames[i] = newImage(r, i)
+	}
+	return frames
+}
+
+func TestWriterAnimation(t *testing.T) {
+	pal := color.Palette{
+		color.NRGBA{0x00, 0x00, 0x00, 0xff},
+		color.NRGBA{0xff, 0x40, 0x00, 0xff},
+		color.NRGBA{0x00, 0x80, 0xff, 0x80},
+		color.NRGBA{0x00, 0x00, 0x00, 0x00},
+	}
+	newPaletted := func(r image.Rectangle, i int) image.Image {
+		m := image.NewPaletted(r, pal)
+		for j := range m.Pix {
+			m.Pix[j] = uint8(i+j) % uint8(len(pal))
+		}
+		return m
+	}
+	newNRGBA := func(r image.Rectangle, i int) image.Image {
+		m := image.NewNRGBA(r)
+		for j := range m.Pix {
+			m.Pix[j] = uint8(i*31 + j*7)
+		}
+		return m
+	}
+	newGray16 := func(r image.Rectangle, i int) image.Image {
+		m := image.NewGray16(r)
+		for j := range m.Pix {
+			m.Pix[j] = uint8(i*17 + j*5)
+		}
+		return m
+	}
+	mixed := testFrames(newNRGBA)
+	mixed[1] = newPaletted(mixed[1].Bounds(), 1)
+	mixed[2] = newGray16(mixed[2].Bounds(), 2)
+
+	for _, tc := range []struct {
+		name   string
+		frames []image.Image
+		model  color.Model
+	}{
+		{"paletted", testFrames(newPaletted), pal},
+		{"nrgba", testFrames(newNRGBA), color.NRGBAModel},
+		{"gray16", testFrames(newGray16), color.Gray16Model},
+		{"mixed", mixed, color.NRGBA64Model},
+	} {
+		a := &APNG{
+			Image:     tc.frames,
+			Delay:     []int{10, 0, 250},
+			Disposal:  []byte{DisposalNone, DisposalBackground, DisposalPrevious},
+			Blend:     []byte{BlendSource, BlendOver, BlendSource},
+			LoopCount: 2,
+		}
+		var buf bytes.Buffer
+		if err := EncodeAll(&buf, a); err != nil {
+			t.Errorf("%s: EncodeAll: %v", tc.name, err)
+			continue
+		}
+		data := buf.Bytes()
+
+		got, err := DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: DecodeAll: %v", tc.name, err)
+			continue
+		}
+		if got.Config.Width != 16 || got.Config.Height != 12 {
+			t.Errorf("%s: canvas is %dx%d, want 16x12", tc.name, got.Config.Width, got.Config.Height)
+		}
+		if !reflect.DeepEqual(got.Config.ColorModel, tc.model) {
+			t.Errorf("%s: color model is %v, want %v", tc.name, got.Config.ColorModel, tc.model)
+		}
+		if got.LoopCount != a.LoopCount {
+			t.Errorf("%s: LoopCount = %d, want %d", tc.name, got.LoopCount, a.LoopCount)
+		}
+		if !reflect.DeepEqual(got.Delay, a.Delay) || !bytes.Equal(got.Disposal, a.Disposal) || !bytes.Equal(got.Blend, a.Blend) {
+			t.Errorf("%s: got delays %v, disposals %v, blends %v; want %v, %v, %v",
+				tc.name, got.Delay, got.Disposal, got.Blend, a.Delay, a.Disposal, a.Blend)
+		}
+		if len(got.Image) != len(a.Image) {
+			t.Errorf("%s: got %d frames, want %d", tc.name, len(got.Image), len(a.Image))
+			continue
+		}
+		for i, m := range got.Image {
+			if m.Bounds() != a.Image[i].Bounds() {
+				t.Errorf("%s: frame %d: bounds = %v, want %v", tc.name, i, m.Bounds(), a.Image[i].Bounds())
+			} else if err := diff(m, a.Image[i]); err != nil {
+				t.Errorf("%s: frame %d: %v", tc.name, i, err)
+			}
+		}
+
+		// Decoders that ignore the animation see the first frame.
+		m, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: Decode: %v", tc.name, err)
+		} else if err := diff(m, a.Image[0]); err != nil {
+			t.Errorf("%s: Decode: %v", tc.name, err)
+		}
+	}
+}
+
+func TestWriterAnimationErrors(t *testing.T) {
+	frames := testFrames(func(r image.Rectangle, i int) image.Image {
+		return image.NewGray(r)
+	})
+	for _, tc := range []struct {
+		name string
+		a    *APNG
+	}{
+		{"no images", &APNG{}},
+		{"mismatched delays", &APNG{Image: frames, Delay: []int{1, 2}}},
+		{"mismatched disposals", &APNG{Image: frames, Delay: []int{1, 2, 3}, Disposal: []byte{0}}},
+		{"mismatched blends", &APNG{Image: frames, Delay: []int{1, 2, 3}, Blend: []byte{0, 0, 0, 0}}},
+		{"negative delay", &APNG{Image: frames, Delay: []int{1, -2, 3}}},
+		{"bad disposal", &APNG{Image: frames, Delay: []int{1, 2, 3}, Disposal: []byte{0, 3, 0}}},
+		{"bad blend", &APNG{Image: frames, Delay: []int{1, 2, 3}, Blend: []byte{2, 0, 0}}},
+		{"frame outside canvas", &APNG{Image: frames, Delay: []int{1, 2, 3}, Config: image.Config{Width: 16, Height: 11}}},
+		{"first frame smaller than canvas", &APNG{Image: frames, Delay: []int{1, 2, 3}, Config: image.Config{Width: 20, Height: 20}}},
+	} {
+		if err := EncodeAll(io.Discard, tc.a); err == nil {
+			t.Errorf("%s: EncodeAll succeeded", tc.name)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webp implements a WEBP image decoder.
+//
+// Both the lossy (VP8) and lossless (VP8L) encodings are supported,
+// as are images with an alpha channel and animated images.
+//
+// The WEBP container format is defined at
+// https://developers.google.com/speed/webp/docs/riff_container.
+package webp
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/webp/internal/vp8"
+	"image/webp/internal/vp8l"
+	"io"
+)
+
+var errInvalidFormat = errors.New("webp: invalid format")
+
+var (
+	fccALPH = fourCC{'A', 'L', 'P', 'H'}
+	fccANIM = fourCC{'A', 'N', 'I', 'M'}
+	fccANMF = fourCC{'A', 'N', 'M', 'F'}
+	fccVP8  = fourCC{'V', 'P', '8', ' '}
+	fccVP8L = fourCC{'V', 'P', '8', 'L'}
+	fccVP8X = fourCC{'V', 'P', '8', 'X'}
+	fccWEBP = fourCC{'W', 'E', 'B', 'P'}
+)
+
+// Disposal methods.
+const (
+	DisposalNone       = 0x00
+	DisposalBackground = 0x01
+)
+
+// Blending methods.
+const (
+	BlendAlpha = 0x00
+	BlendNone  = 0x01
+)
+
+// WEBP represents the possibly multiple images stored in a WEBP file.
+type WEBP struct {
+	// Image is the successive images. The bounds of each image are its
+	// position within the canvas described by Config.
+	Image []image.Image
+	// Delay is the successive display durations, one per frame, in
+	// milliseconds.
+	Delay []int
+	// Disposal is the successive disposal methods, one per frame. It
+	// says whether the frame's rectangle is cleared to the background
+	// color before the next frame is rendered.
+	Disposal []byte
+	// Blend is the successive blending methods, one per frame. It says
+	// whether the frame is alpha-blended with the canvas or replaces
+	// the pixels in its rectangle.
+	Blend []byte
+	// LoopCount is the number of times an animation is played.
+	// Zero means to loop forever.
+	LoopCount int
+	// BackgroundColor is the color suggested for the canvas outside
+	// of the frames.
+	BackgroundColor color.NRGBA
+	// Config is the global color model and the canvas dimensions.
+	Config image.Config
+}
+
+// frameDecoder decodes the chunks that make up a single image: an
+// optional ALPH chunk followed by a VP8 chunk, or a single VP8L chunk.
+type frameDecoder struct {
+	// width and height are the image's dimensions. They are only
+	// known, and an ALPH chunk only allowed, if alphaAllowed is set.
+	width, height int
+	alphaAllowed  bool
+
+	alpha       []byte
+	alphaStride int
+}
+
+// decodeChunk decodes a chunk of an image. It returns the image once the
+// chunk holding its pixels has been decoded.
+func (f *frameDecoder) decodeChunk(chunkID fourCC, chunkLen uint32, chunkData io.Reader) (image.Image, error) {
+	switch chunkID {
+	case fccALPH:
+		if !f.alphaAllowed || f.alpha != nil {
+			return nil, errInvalidFormat
+		}
+		// Read the Pre-processing | Filter | Compression byte.
+		var buf [1]byte
+		if _, err := io.ReadFull(chunkData, buf[:]); err != nil {
+			if err == io.EOF {
+				err = errInvalidFormat
+			}
+			return nil, err
+		}
+		alpha, alphaStride, err := readAlpha(chunkData, uint32(f.width-1), uint32(f.height-1), buf[0]&0x03)
+		if err != nil {
+			return nil, err
+		}
+		unfilterAlpha(alpha, alphaStride, (buf[0]>>2)&0x03)
+		f.alpha, f.alphaStride = alpha, alphaStride
+		return nil, nil
+
+	case fccVP8:
+		if int32(chunkLen) < 0 {
+			return nil, errInvalidFormat
+		}
+		d := vp8.NewDecoder()
+		d.Init(chunkData, int(chunkLen))
+		fh, err := d.DecodeFrameHeader()
+		if err != nil {
+			return nil, err
+		}
+		if f.alpha != nil && (fh.Width != f.width || fh.Height != f.height) {
+			return nil, errInvalidFormat
+		}
+		m, err := d.DecodeFrame()
+		if err != nil {
+			return nil, err
+		}
+		if f.alpha != nil {
+			return &image.NYCbCrA{
+				YCbCr:   *m,
+				A:       f.alpha,
+				AStride: f.alphaStride,
+			}, nil
+		}
+		return m, nil
+
+	case fccVP8L:
+		if f.alpha != nil {
+			return nil, errInvalidFormat
+		}
+		return vp8l.Decode(chunkData)
+	}
+	return nil, nil
+}
+
+// decodeFrame decodes an ANMF chunk, returning its image positioned
+// within canvas, its duration and its flags.
+func decodeFrame(chunkLen uint32, chunkData io.Reader, canvas image.Rectangle) (m image.Image, duration int, flags byte, err error) {
+	var buf [16]byte
+	if chunkLen < uint32(len(buf)) {
+		return nil, 0, 0, errInvalidFormat
+	}
+	if _, err := io.ReadFull(chunkData, buf[:]); err != nil {
+		return nil, 0, 0, err
+	}
+	x := 2 * int(u24(buf[0:]))
+	y := 2 * int(u24(buf[3:]))
+	f := frameDecoder{
+		width:        int(u24(buf[6:])) + 1,
+		height:       int(u24(buf[9:])) + 1,
+		alphaAllowed: true,
+	}
+	r := image.Rect(x, y, x+f.width, y+f.height)
+	if !r.In(canvas) {
+		return nil, 0, 0, errInvalidFormat
+	}
+
+	cr := newChunkReader(chunkLen-uint32(len(buf)), chunkData)
+	for {
+		chunkID, chunkLen, chunkData, err := cr.next()
+		if err == io.EOF {
+			err = errInvalidFormat
+		}
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		m, err := f.decodeChunk(chunkID, chunkLen, chunkData)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if m == nil {
+			continue
+		}
+		if b := m.Bounds(); b.Dx() != f.width || b.Dy() != f.height {
+			return nil, 0, 0, errInvalidFormat
+		}
+		setOrigin(m, r.Min)
+		return m, int(u24(buf[12:])), buf[15], nil
+	}
+}
+
+// setOrigin moves an image decoded by decodeChunk so that its bounds
+// start at p. Frames are positioned at even coordinates, so the
+// chroma samples of a YCbCr image stay aligned with the luma samples.
+func setOrigin(m image.Image, p image.Point) {
+	switch m := m.(type) {
+	case *image.YCbCr:
+		m.Rect = m.Rect.Add(p)
+	case *image.NYCbCrA:
+		m.Rect = m.Rect.Add(p)
+	case *image.NRGBA:
+		m.Rect = m.Rect.Add(p)
+	}
+}
+
+// decode reads a WEBP file from r. If configOnly is set, it stops once
+// the Config is known. Otherwise, it stops after the first image unless
+// keepAllFrames is set.
+func decode(r io.Reader, configOnly, keepAllFrames bool) (*WEBP, error) {
+	formType, riffReader, err := newRIFFReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if formType != fccWEBP {
+		return nil, errInvalidFormat
+	}
+
+	var (
+		w         WEBP
+		f         frameDecoder
+		seenVP8X  bool
+		animated  bool
+		seenANIM  bool
+		buf       [10]byte
+		canvas    image.Rectangle
+		haveFrame bool
+	)
+	for {
+		chunkID, chunkLen, chunkData, err := riffReader.next()
+		if err == io.EOF {
+			if haveFrame {
+				return &w, nil
+			}
+			err = errInvalidFormat
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch chunkID {
+		case fccALPH, fccVP8, fccVP8L:
+			if animated {
+				return nil, errInvalidFormat
+			}
+			if configOnly {
+				// There is no VP8X chunk, so the dimensions come from
+				// the image data.
+				if chunkID == fccALPH {
+					return nil, errInvalidFormat
+				}
+				c, err := decodeConfig(chunkID, chunkLen, chunkData)
+				if err != nil {
+					return nil, err
+				}
+				w.Config = c
+				return &w, nil
+			}
+			m, err := f.decodeChunk(chunkID, chunkLen, chunkData)
+			if err != nil {
+				return nil, err
+			}
+			if m == nil {
+				continue
+			}
+			if !seenVP8X {
+				w.Config = image.Config{
+					ColorModel: m.ColorModel(),
+					Width:      m.Bounds().Dx(),
+					Height:     m.Bounds().Dy(),
+				}
+			}
+			w.Image = append(w.Image, m)
+			w.Delay = append(w.Delay, 0)
+			w.Disposal = append(w.Disposal, DisposalNone)
+			w.Blend = append(w.Blend, BlendAlpha)
+			return &w, nil
+
+		case fccANIM:
+			if !animated || seenANIM {
+				return nil, errInvalidFormat
+			}
+			seenANIM = true
+			if chunkLen != 6 {
+				return nil, errInvalidFormat
+			}
+			if _, err := io.ReadFull(chunkData, buf[:6]); err != nil {
+				return nil, err
+			}
+			// The background color is stored in blue, green, red, alpha order.
+			w.BackgroundColor = color.NRGBA{buf[2], buf[1], buf[0], buf[3]}
+			w.LoopCount = int(buf[4]) | int(buf[5])<<8
+
+		case fccANMF:
+			if !seenANIM {
+				return nil, errInvalidFormat
+			}
+			m, duration, flags, err := decodeFrame(chunkLen, chunkData, canvas)
+			if err != nil {
+				return nil, err
+			}
+			w.Image = append(w.Image, m)
+			w.Delay = append(w.Delay, duration)
+			w.Disposal = append(w.Disposal, flags&0x01)
+			w.Blend = append(w.Blend, (flags>>1)&0x01)
+			haveFrame = true
+			if !keepAllFrames {
+				return &w, nil
+			}
+
+		case fccVP8X:
+			if seenVP8X {
+				return nil, errInvalidFormat
+			}
+			seenVP8X = true
+			if chunkLen != 10 {
+				return nil, errInvalidFormat
+			}
+			if _, err := io.ReadFull(chunkData, buf[:10]); err != nil {
+				return nil, err
+			}
+			const (
+				animationBit    = 1 << 1
+				xmpMetadataBit  = 1 << 2
+				exifMetadataBit = 1 << 3
+				alphaBit        = 1 << 4
+				iccProfileBit   = 1 << 5
+			)
+			animated = buf[0]&animationBit != 0
+			f.alphaAllowed = buf[0]&alphaBit != 0
+			f.width = int(u24(buf[4:])) + 1
+			f.height = int(u24(buf[7:])) + 1
+			canvas = image.Rect(0, 0, f.width, f.height)
+			w.Config = image.Config{
+				ColorModel: color.YCbCrModel,
+				Width:      f.width,
+				Height:     f.height,
+			}
+			if f.alphaAllowed {
+				w.Config.ColorModel = color.NYCbCrAModel
+			}
+			if configOnly {
+				return &w, nil
+			}
+		}
+	}
+}
+
+// decodeConfig returns the color model and dimensions of the image whose
+// VP8 or VP8L chunk is given.
+func decodeConfig(chunkID fourCC, chunkLen uint32, chunkData io.Reader) (image.Config, error) {
+	if chunkID == fccVP8L {
+		return vp8l.DecodeConfig(chunkData)
+	}
+	if int32(chunkLen) < 0 {
+		return image.Config{}, errInvalidFormat
+	}
+	d := vp8.NewDecoder()
+	d.Init(chunkData, int(chunkLen))
+	fh, err := d.DecodeFrameHeader()
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: color.YCbCrModel,
+		Width:      fh.Width,
+		Height:     fh.Height,
+	}, nil
+}
+
+func readAlpha(chunkData io.Reader, widthMinusOne, heightMinusOne uint32, compression byte) (
+	alpha []byte, alphaStride int, err error) {
+
+	switch compression {
+	case 0:
+		w := int(widthMinusOne) + 1
+		h := int(heightMinusOne) + 1
+		alpha = make([]byte, w*h)
+		if _, err := io.ReadFull(chunkData, alpha); err != nil {
+			return nil, 0, err
+		}
+		return alpha, w, nil
+
+	case 1:
+		// Read the VP8L-compressed alpha values. First, synthesize a 5-byte VP8L header:
+		// a 1-byte magic number, a 14-bit widthMinusOne, a 14-bit heightMinusOne,
+		// a 1-bit (ignored, zero) alphaIsUsed and a 3-bit (zero) version.
+		// TODO(nigeltao): be more efficient than decoding an *image.NRGBA just to
+		// extract the green values to a separately allocated []byte. Fixing this
+		// will require changes to the vp8l package's API.
+		if widthMinusOne > 0x3fff || heightMinusOne > 0x3fff {
+			return nil, 0, errInvalidFormat
+		}
+		alphaImage, err := vp8l.Decode(io.MultiReader(
+			bytes.NewReader([]byte{
+				0x2f, // VP8L magic number.
+				uint8(widthMinusOne),
+				uint8(widthMinusOne>>8) | uint8(heightMinusOne<<6),
+				uint8(heightMinusOne >> 2),
+				uint8(heightMinusOne >> 10),
+			}),
+			chunkData,
+		))
+		if err != nil {
+			return nil, 0, err
+		}
+		// The green values of the inner NRGBA image are the alpha values of the
+		// outer NYCbCrA image.
+		pix := alphaImage.(*image.NRGBA).Pix
+		alpha = make([]byte, len(pix)/4)
+		for i := range alpha {
+			alpha[i] = pix[4*i+1]
+		}
+		return alpha, int(widthMinusOne) + 1, nil
+	}
+	return nil, 0, errInvalidFormat
+}
+
+func unfilterAlpha(alpha []byte, alphaStride int, filter byte) {
+	if len(alpha) == 0 || alphaStride == 0 {
+		return
+	}
+	switch filter {
+	case 1: // Horizontal filter.
+		for i := 1; i < alphaStride; i++ {
+			alpha[i] += alpha[i-1]
+		}
+		for i := alphaStride; i < len(alpha); i += alphaStride {
+			// The first column is equivalent to the vertical filter.
+			alpha[i] += alpha[i-alphaStride]
+
+			for j := 1; j < alphaStride; j++ {
+				alpha[i+j] += alpha[i+j-1]
+			}
+		}
+
+	case 2: // Vertical filter.
+		// The first row is equivalent to the horizontal filter.
+		for i := 1; i < alphaStride; i++ {
+			alpha[i] += alpha[i-1]
+		}
+
+		for i := alphaStride; i < len(alpha); i++ {
+			alpha[i] += alpha[i-alphaStride]
+		}
+
+	case 3: // Gradient filter.
+		// The first row is equivalent to the horizontal filter.
+		for i := 1; i < alphaStride; i++ {
+			alpha[i] += alpha[i-1]
+		}
+
+		for i := alphaStride; i < len(alpha); i += alphaStride {
+			// The first column is equivalent to the vertical filter.
+			alpha[i] += alpha[i-alphaStride]
+
+			// The interior is predicted on the three top/left pixels.
+			for j := 1; j < alphaStride; j++ {
+				c := int(alpha[i+j-alphaStride-1])
+				b := int(alpha[i+j-alphaStride])
+				a := int(alpha[i+j-1])
+				x := a + b - c
+				if x < 0 {
+					x = 0
+				} else if x > 255 {
+					x = 255
+				}
+				alpha[i+j] += uint8(x)
+			}
+		}
+	}
+}
+
+// Decode reads a WEBP image from r and returns it as an image.Image.
+// For an animated image, it returns the first frame.
+func Decode(r io.Reader) (image.Image, error) {
+	w, err := decode(r, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return w.Image[0], nil
+}
+
+// DecodeAll reads a WEBP image from r and returns the sequential frames
+// and timing information. A still image is returned as a single frame.
+func DecodeAll(r io.Reader) (*WEBP, error) {
+	return decode(r, false, true)
+}
+
+// DecodeConfig returns the color model and dimensions of a WEBP image
+// without decoding the entire image. For an animated image, the
+// dimensions are those of the canvas.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	w, err := decode(r, true, false)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return w.Config, nil
+}
+
+func init() {
+	image.RegisterFormat("webp", "RIFF????WEBPVP8", Decode, DecodeConfig)
+}
@@ -0,0 +1,491 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// hex is like fmt.Sprintf("% x", x) but also inserts dots every 16 bytes, to
+// delineate VP8 macroblock boundaries.
+func hex(x []byte) string {
+	buf := new(bytes.Buffer)
+	for len(x) > 0 {
+		n := len(x)
+		if n > 16 {
+			n = 16
+		}
+		fmt.Fprintf(buf, " . % x", x[:n])
+		x = x[n:]
+	}
+	return buf.String()
+}
+
+func testDecodeLossy(t *testing.T, tc string, withAlpha bool) {
+	webpFilename := "testdata/" + tc + ".lossy.webp"
+	pngFilename := webpFilename + ".ycbcr.png"
+	if withAlpha {
+		webpFilename = "testdata/" + tc + ".lossy-with-alpha.webp"
+		pngFilename = webpFilename + ".nycbcra.png"
+	}
+
+	f0, err := os.Open(webpFilename)
+	if err != nil {
+		t.Errorf("%s: Open WEBP: %v", tc, err)
+		return
+	}
+	defer f0.Close()
+	img0, err := Decode(f0)
+	if err != nil {
+		t.Errorf("%s: Decode WEBP: %v", tc, err)
+		return
+	}
+
+	var (
+		m0 *image.YCbCr
+		a0 *image.NYCbCrA
+		ok bool
+	)
+	if withAlpha {
+		a0, ok = img0.(*image.NYCbCrA)
+		if ok {
+			m0 = &a0.YCbCr
+		}
+	} else {
+		m0, ok = img0.(*image.YCbCr)
+	}
+	if !ok || m0.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+		t.Errorf("%s: decoded WEBP image is not a 4:2:0 YCbCr or 4:2:0 NYCbCrA", tc)
+		return
+	}
+	// w2 and h2 are the half-width and half-height, rounded up.
+	w, h := m0.Bounds().Dx(), m0.Bounds().Dy()
+	w2, h2 := int((w+1)/2), int((h+1)/2)
+
+	f1, err := os.Open(pngFilename)
+	if err != nil {
+		t.Errorf("%s: Open PNG: %v", tc, err)
+		return
+	}
+	defer f1.Close()
+	img1, err := png.Decode(f1)
+	if err != nil {
+		t.Errorf("%s: Open PNG: %v", tc, err)
+		return
+	}
+
+	// The split-into-YCbCr-planes golden image is a 2*w2 wide and h+h2 high
+	// (or 2*h+h2 high, if with Alpha) gray image arranged in IMC4 format:
+	//   YYYY
+	//   YYYY
+	//   BBRR
+	//   AAAA
+	// See http://www.fourcc.org/yuv.php#IMC4
+	pngW, pngH := 2*w2, h+h2
+	if withAlpha {
+		pngH += h
+	}
+	if got, want := img1.Bounds(), image.Rect(0, 0, pngW, pngH); got != want {
+		t.Errorf("%s: bounds0: got %v, want %v", tc, got, want)
+		return
+	}
+	m1, ok := img1.(*image.Gray)
+	if !ok {
+		t.Errorf("%s: decoded PNG image is not a Gray", tc)
+		return
+	}
+
+	type plane struct {
+		name     string
+		m0Pix    []uint8
+		m0Stride int
+		m1Rect   image.Rectangle
+	}
+	planes := []plane{
+		{"Y", m0.Y, m0.YStride, image.Rect(0, 0, w, h)},
+		{"Cb", m0.Cb, m0.CStride, image.Rect(0*w2, h, 1*w2, h+h2)},
+		{"Cr", m0.Cr, m0.CStride, image.Rect(1*w2, h, 2*w2, h+h2)},
+	}
+	if withAlpha {
+		planes = append(planes, plane{
+			"A", a0.A, a0.AStride, image.Rect(0, h+h2, w, 2*h+h2),
+		})
+	}
+
+	for _, plane := range planes {
+		dx := plane.m1Rect.Dx()
+		nDiff, diff := 0, make([]byte, dx)
+		for j, y := 0, plane.m1Rect.Min.Y; y < plane.m1Rect.Max.Y; j, y = j+1, y+1 {
+			got := plane.m0Pix[j*plane.m0Stride:][:dx]
+			want := m1.Pix[y*m1.Stride+plane.m1Rect.Min.X:][:dx]
+			if bytes.Equal(got, want) {
+				continue
+			}
+			nDiff++
+			if nDiff > 10 {
+				t.Errorf("%s: %s plane: more rows differ", tc, plane.name)
+				break
+			}
+			for i := range got {
+				diff[i] = got[i] - want[i]
+			}
+			t.Errorf("%s: %s plane: m0 row %d, m1 row %d\ngot %s\nwant%s\ndiff%s",
+				tc, plane.name, j, y, hex(got), hex(want), hex(diff))
+		}
+	}
+}
+
+func TestDecodeVP8(t *testing.T) {
+	testCases := []string{
+		"blue-purple-pink",
+		"video-001",
+		"yellow_rose",
+	}
+
+	for _, tc := range testCases {
+		testDecodeLossy(t, tc, false)
+	}
+}
+
+func TestDecodeVP8XAlpha(t *testing.T) {
+	testCases := []string{
+		"yellow_rose",
+	}
+
+	for _, tc := range testCases {
+		testDecodeLossy(t, tc, true)
+	}
+}
+
+func TestDecodeVP8L(t *testing.T) {
+	testCases := []string{
+		"blue-purple-pink",
+		"gopher-doc.1bpp",
+		"gopher-doc.2bpp",
+		"gopher-doc.4bpp",
+		"gopher-doc.8bpp",
+		"tux",
+		"yellow_rose",
+	}
+
+loop:
+	for _, tc := range testCases {
+		f0, err := os.Open("testdata/" + tc + ".lossless.webp")
+		if err != nil {
+			t.Errorf("%s: Open WEBP: %v", tc, err)
+			continue
+		}
+		defer f0.Close()
+		img0, err := Decode(f0)
+		if err != nil {
+			t.Errorf("%s: Decode WEBP: %v", tc, err)
+			continue
+		}
+		m0, ok := img0.(*image.NRGBA)
+		if !ok {
+			t.Errorf("%s: WEBP image is %T, want *image.NRGBA", tc, img0)
+			continue
+		}
+
+		f1, err := os.Open("testdata/" + tc + ".png")
+		if err != nil {
+			t.Errorf("%s: Open PNG: %v", tc, err)
+			continue
+		}
+		defer f1.Close()
+		img1, err := png.Decode(f1)
+		if err != nil {
+			t.Errorf("%s: Decode PNG: %v", tc, err)
+			continue
+		}
+		m1, ok := img1.(*image.NRGBA)
+		if !ok {
+			rgba1, ok := img1.(*image.RGBA)
+			if !ok {
+				t.Fatalf("%s: PNG image is %T, want *image.NRGBA", tc, img1)
+				continue
+			}
+			if !rgba1.Opaque() {
+				t.Fatalf("%s: PNG image is non-opaque *image.RGBA, want *image.NRGBA", tc)
+				continue
+			}
+			// The image is fully opaque, so we can re-interpret the RGBA pixels
+			// as NRGBA pixels.
+			m1 = &image.NRGBA{
+				Pix:    rgba1.Pix,
+				Stride: rgba1.Stride,
+				Rect:   rgba1.Rect,
+			}
+		}
+
+		b0, b1 := m0.Bounds(), m1.Bounds()
+		if b0 != b1 {
+			t.Errorf("%s: bounds: got %v, want %v", tc, b0, b1)
+			continue
+		}
+		for i := range m0.Pix {
+			if m0.Pix[i] != m1.Pix[i] {
+				y := i / m0.Stride
+				x := (i - y*m0.Stride) / 4
+				i = 4 * (y*m0.Stride + x)
+				t.Errorf("%s: at (%d, %d):\ngot  %02x %02x %02x %02x\nwant %02x %02x %02x %02x",
+					tc, x, y,
+					m0.Pix[i+0], m0.Pix[i+1], m0.Pix[i+2], m0.Pix[i+3],
+					m1.Pix[i+0], m1.Pix[i+1], m1.Pix[i+2], m1.Pix[i+3],
+				)
+				continue loop
+			}
+		}
+	}
+}
+
+// TestDecodePartitionTooLarge tests that decoding a malformed WEBP image
+// doesn't try to allocate an unreasonable amount of memory. This WEBP image
+// claims a RIFF chunk length of 0x12345678 bytes (291 MiB) compressed,
+// independent of the actual image size (0 pixels wide * 0 pixels high).
+//
+// This is based on golang.org/issue/10790.
+func TestDecodePartitionTooLarge(t *testing.T) {
+	data := "RIFF\xff\xff\xff\x7fWEBPVP8 " +
+		"\x78\x56\x34\x12" + // RIFF chunk length.
+		"\xbd\x01\x00\x14\x00\x00\xb2\x34\x0a\x9d\x01\x2a\x96\x00\x67\x00"
+	_, err := Decode(strings.NewReader(data))
+	if err == nil {
+		t.Fatal("got nil error, want non-nil")
+	}
+	if got, want := err.Error(), "too much data"; !strings.Contains(got, want) {
+		t.Fatalf("got error %q, want something containing %q", got, want)
+	}
+}
+
+func TestDuplicateVP8X(t *testing.T) {
+	data := []byte{'R', 'I', 'F', 'F', 49, 0, 0, 0, 'W', 'E', 'B', 'P', 'V', 'P', '8', 'X', 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 'V', 'P', '8', 'X', 10, 0, 0, 0, 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	_, err := Decode(bytes.NewReader(data))
+	if err != errInvalidFormat {
+		t.Fatalf("unexpected error: want %q, got %q", errInvalidFormat, err)
+	}
+}
+
+func benchmarkDecode(b *testing.B, filename string) {
+	data, err := os.ReadFile("testdata/" + filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := string(data)
+	cfg, err := DecodeConfig(strings.NewReader(s))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(cfg.Width * cfg.Height * 4))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Decode(strings.NewReader(s))
+	}
+}
+
+func BenchmarkDecodeVP8(b *testing.B)  { benchmarkDecode(b, "yellow_rose.lossy.webp") }
+func BenchmarkDecodeVP8L(b *testing.B) { benchmarkDecode(b, "yellow_rose.lossless.webp") }
+
+// imageChunks returns the chunks holding the image data of the WEBP file
+// in testdata, along with the image's dimensions.
+func imageChunks(t *testing.T, filename string) (chunks []byte, width, height int) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, r, err := newRIFFReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		chunkID, _, chunkData, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunkID != fccALPH && chunkID != fccVP8 && chunkID != fccVP8L {
+			continue
+		}
+		b, err := io.ReadAll(chunkData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = appendChunk(chunks, chunkID, b)
+	}
+	return chunks, c.Width, c.Height
+}
+
+func appendChunk(b []byte, chunkID fourCC, data []byte) []byte {
+	n := len(data)
+	b = append(b, chunkID[:]...)
+	b = append(b, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	b = append(b, data...)
+	if n&1 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func appendU24(b []byte, v int) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16))
+}
+
+type animFrame struct {
+	filename string
+	x, y     int
+	duration int
+	flags    byte
+}
+
+// makeAnimation returns an animated WEBP file made of the images of the
+// given files, positioned on a canvas of the given size.
+func makeAnimation(t *testing.T, width, height int, frames []animFrame) []byte {
+	t.Helper()
+	vp8x := []byte{1<<4 | 1<<1, 0, 0, 0} // alpha and animation
+	vp8x = appendU24(vp8x, width-1)
+	vp8x = appendU24(vp8x, height-1)
+	body := appendChunk([]byte("WEBP"), fccVP8X, vp8x)
+	body = appendChunk(body, fccANIM, []byte{0x30, 0x20, 0x10, 0xff, 3, 0})
+	for _, f := range frames {
+		chunks, w, h := imageChunks(t, f.filename)
+		var anmf []byte
+		anmf = appendU24(anmf, f.x/2)
+		anmf = appendU24(anmf, f.y/2)
+		anmf = appendU24(anmf, w-1)
+		anmf = appendU24(anmf, h-1)
+		anmf = appendU24(anmf, f.duration)
+		anmf = append(anmf, f.flags)
+		body = appendChunk(body, fccANMF, append(anmf, chunks...))
+	}
+	return appendChunk(nil, fourCC{'R', 'I', 'F', 'F'}, body)
+}
+
+func TestDecodeAll(t *testing.T) {
+	frames := []animFrame{
+		{"gopher-doc.8bpp.lossless.webp", 0, 0, 100, BlendAlpha<<1 | DisposalNone},
+		{"yellow_rose.lossy-with-alpha.webp", 10, 20, 200, BlendNone<<1 | DisposalNone},
+		{"blue-purple-pink.lossy.webp", 4, 2, 300, BlendAlpha<<1 | DisposalBackground},
+	}
+	const width, height = 420, 330
+	data := makeAnimation(t, width, height, frames)
+
+	w, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Config.Width != width || w.Config.Height != height {
+		t.Errorf("canvas is %dx%d, want %dx%d", w.Config.Width, w.Config.Height, width, height)
+	}
+	if w.LoopCount != 3 {
+		t.Errorf("LoopCount = %d, want 3", w.LoopCount)
+	}
+	if want := (color.NRGBA{0x10, 0x20, 0x30, 0xff}); w.BackgroundColor != want {
+		t.Errorf("BackgroundColor = %v, want %v", w.BackgroundColor, want)
+	}
+	if len(w.Image) != len(frames) || len(w.Delay) != len(frames) ||
+		len(w.Disposal) != len(frames) || len(w.Blend) != len(frames) {
+		t.Fatalf("got %d images, %d delays, %d disposals and %d blends, want %d of each",
+			len(w.Image), len(w.Delay), len(w.Disposal), len(w.Blend), len(frames))
+	}
+	for i, f := range frames {
+		if w.Delay[i] != f.duration {
+			t.Errorf("frame %d: Delay = %d, want %d", i, w.Delay[i], f.duration)
+		}
+		if w.Disposal[i] != f.flags&1 || w.Blend[i] != f.flags>>1 {
+			t.Errorf("frame %d: Disposal, Blend = %d, %d; want %d, %d", i, w.Disposal[i], w.Blend[i], f.flags&1, f.flags>>1)
+		}
+
+		file, err := os.Open("testdata/" + f.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := w.Image[i]
+		p := image.Pt(f.x, f.y)
+		if b := got.Bounds(); b != want.Bounds().Add(p) {
+			t.Errorf("frame %d: bounds = %v, want %v", i, b, want.Bounds().Add(p))
+			continue
+		}
+	loop:
+		for y := 0; y < want.Bounds().Dy(); y++ {
+			for x := 0; x < want.Bounds().Dx(); x++ {
+				if c0, c1 := got.At(f.x+x, f.y+y), want.At(x, y); c0 != c1 {
+					t.Errorf("frame %d: pixel at (%d, %d) is %v, want %v", i, x, y, c0, c1)
+					break loop
+				}
+			}
+		}
+	}
+
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Bounds() != w.Image[0].Bounds() {
+		t.Errorf("Decode returned an image with bounds %v, want the first frame", m.Bounds())
+	}
+	c, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "webp" || c.Width != width || c.Height != height {
+		t.Errorf("image.DecodeConfig = %s %dx%d, want webp %dx%d", format, c.Width, c.Height, width, height)
+	}
+}
+
+func TestDecodeAllStill(t *testing.T) {
+	data, err := os.ReadFile("testdata/yellow_rose.lossy-with-alpha.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Image) != 1 || len(w.Delay) != 1 {
+		t.Fatalf("got %d images and %d delays, want 1 of each", len(w.Image), len(w.Delay))
+	}
+	if _, ok := w.Image[0].(*image.NYCbCrA); !ok {
+		t.Errorf("image is %T, want *image.NYCbCrA", w.Image[0])
+	}
+	b := w.Image[0].Bounds()
+	if b.Dx() != w.Config.Width || b.Dy() != w.Config.Height {
+		t.Errorf("image bounds %v do not match the %dx%d Config", b, w.Config.Width, w.Config.Height)
+	}
+}
+
+func TestDecodeAllFrameOutsideCanvas(t *testing.T) {
+	_, width, height := imageChunks(t, "gopher-doc.1bpp.lossless.webp")
+	data := makeAnimation(t, width+1, height+1, []animFrame{
+		{"gopher-doc.1bpp.lossless.webp", 0, 0, 100, 0},
+		{"gopher-doc.1bpp.lossless.webp", 2, 0, 100, 0},
+	})
+	if _, err := DecodeAll(bytes.NewReader(data)); err != errInvalidFormat {
+		t.Errorf("got %v, want %v", err, errInvalidFormat)
+	}
+	// Decode stops at the first frame, which fits.
+	if _, err := Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("Decode: %v", err)
+	}
+}
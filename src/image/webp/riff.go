@@ -0,0 +1,191 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webp
+
+// This file implements reading the Resource Interchange File Format
+// container that holds a WEBP image's chunks.
+//
+// A RIFF stream contains a sequence of chunks. Each chunk consists of an
+// 8-byte header (containing a 4-byte chunk type and a 4-byte chunk length),
+// the chunk data (presented as an io.Reader), and some padding bytes.
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	errMissingPaddingByte     = errors.New("webp: missing padding byte")
+	errMissingRIFFChunkHeader = errors.New("webp: missing RIFF chunk header")
+	errSubchunkTooLong        = errors.New("webp: subchunk too long")
+	errShortChunkData         = errors.New("webp: short chunk data")
+	errShortChunkHeader       = errors.New("webp: short chunk header")
+	errStaleReader            = errors.New("webp: stale reader")
+)
+
+// u32 decodes the first four bytes of b as a little-endian integer.
+func u32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// u24 decodes the first three bytes of b as a little-endian integer.
+func u24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+const chunkHeaderSize = 8
+
+// fourCC is a four character code.
+type fourCC [4]byte
+
+// newRIFFReader returns the RIFF stream's form type, such as "WEBP", and
+// its chunks as a *riffReader.
+func newRIFFReader(r io.Reader) (formType fourCC, data *riffReader, err error) {
+	var buf [chunkHeaderSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = errMissingRIFFChunkHeader
+		}
+		return fourCC{}, nil, err
+	}
+	if buf[0] != 'R' || buf[1] != 'I' || buf[2] != 'F' || buf[3] != 'F' {
+		return fourCC{}, nil, errMissingRIFFChunkHeader
+	}
+	chunkLen := u32(buf[4:])
+	if chunkLen < 4 {
+		return fourCC{}, nil, errShortChunkData
+	}
+	if _, err := io.ReadFull(r, buf[:4]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = errShortChunkData
+		}
+		return fourCC{}, nil, err
+	}
+	return fourCC{buf[0], buf[1], buf[2], buf[3]}, newChunkReader(chunkLen-4, r), nil
+}
+
+// newChunkReader returns a *riffReader for the chunks in the chunkLen
+// bytes read from r.
+func newChunkReader(chunkLen uint32, r io.Reader) *riffReader {
+	return &riffReader{r: r, totalLen: chunkLen}
+}
+
+// riffReader reads chunks from an underlying io.Reader.
+type riffReader struct {
+	r   io.Reader
+	err error
+
+	totalLen uint32
+	chunkLen uint32
+
+	chunkReader *chunkReader
+	buf         [chunkHeaderSize]byte
+	padded      bool
+}
+
+// next returns the next chunk's ID, length and data. It returns io.EOF if
+// there are no more chunks. The io.Reader returned becomes stale after the
+// next call to next, and should no longer be used.
+//
+// It is valid to call next even if all of the previous chunk's data has
+// not been read.
+func (z *riffReader) next() (chunkID fourCC, chunkLen uint32, chunkData io.Reader, err error) {
+	if z.err != nil {
+		return fourCC{}, 0, nil, z.err
+	}
+
+	// Drain the rest of the previous chunk.
+	if z.chunkLen != 0 {
+		want := z.chunkLen
+		var got int64
+		got, z.err = io.Copy(io.Discard, z.chunkReader)
+		if z.err == nil && uint32(got) != want {
+			z.err = errShortChunkData
+		}
+		if z.err != nil {
+			return fourCC{}, 0, nil, z.err
+		}
+	}
+	z.chunkReader = nil
+	if z.padded {
+		if z.totalLen == 0 {
+			z.err = errSubchunkTooLong
+			return fourCC{}, 0, nil, z.err
+		}
+		z.totalLen--
+		_, z.err = io.ReadFull(z.r, z.buf[:1])
+		if z.err != nil {
+			if z.err == io.EOF {
+				z.err = errMissingPaddingByte
+			}
+			return fourCC{}, 0, nil, z.err
+		}
+	}
+
+	// We are done if we have no more data.
+	if z.totalLen == 0 {
+		z.err = io.EOF
+		return fourCC{}, 0, nil, z.err
+	}
+
+	// Read the next chunk header.
+	if z.totalLen < chunkHeaderSize {
+		z.err = errShortChunkHeader
+		return fourCC{}, 0, nil, z.err
+	}
+	z.totalLen -= chunkHeaderSize
+	if _, z.err = io.ReadFull(z.r, z.buf[:chunkHeaderSize]); z.err != nil {
+		if z.err == io.EOF || z.err == io.ErrUnexpectedEOF {
+			z.err = errShortChunkHeader
+		}
+		return fourCC{}, 0, nil, z.err
+	}
+	chunkID = fourCC{z.buf[0], z.buf[1], z.buf[2], z.buf[3]}
+	z.chunkLen = u32(z.buf[4:])
+	if z.chunkLen > z.totalLen {
+		z.err = errSubchunkTooLong
+		return fourCC{}, 0, nil, z.err
+	}
+	z.padded = z.chunkLen&1 == 1
+	z.chunkReader = &chunkReader{z}
+	return chunkID, z.chunkLen, z.chunkReader, nil
+}
+
+type chunkReader struct {
+	z *riffReader
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if c != c.z.chunkReader {
+		return 0, errStaleReader
+	}
+	z := c.z
+	if z.err != nil {
+		if z.err == io.EOF {
+			return 0, errStaleReader
+		}
+		return 0, z.err
+	}
+
+	n := int(z.chunkLen)
+	if n == 0 {
+		return 0, io.EOF
+	}
+	if n < 0 {
+		// Converting uint32 to int overflowed.
+		n = math.MaxInt32
+	}
+	if n > len(p) {
+		n = len(p)
+	}
+	n, err := z.r.Read(p[:n])
+	z.totalLen -= uint32(n)
+	z.chunkLen -= uint32(n)
+	if err != io.EOF {
+		z.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,15 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Export guts for testing on linux.
+// Since testing imports os and os imports internal/poll,
+// the internal/poll tests can not be in package poll.
+
+package poll
+
+// IOUringEnabled reports whether reads and writes
+// of regular files use io_uring.
+func IOUringEnabled() bool {
+	return getIOUring() != nil
+}
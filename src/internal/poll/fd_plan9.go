@@ -9,6 +9,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -231,3 +232,13 @@ func (fd *FD) RawRead(f func(uintptr) bool) error {
 func (fd *FD) RawWrite(f func(uintptr) bool) error {
 	return errors.New("not implemented")
 }
+
+// DupCloseOnExec dups fd.
+// Plan 9 has no close-on-exec flag for dup'ed descriptors.
+func DupCloseOnExec(fd int) (int, string, error) {
+	nfd, err := syscall.Dup(fd, -1)
+	if err != nil {
+		return -1, "dup", err
+	}
+	return nfd, "", nil
+}
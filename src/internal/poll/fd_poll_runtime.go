@@ -168,5 +168,5 @@ func setDeadlineImpl(fd *FD, t time.Time, mode int) error {
 // IsPollDescriptor reports whether fd is the descriptor being used by the poller.
 // This is only used for testing.
 func IsPollDescriptor(fd uintptr) bool {
-	return runtime_isPollServerDescriptor(fd)
+	return runtime_isPollServerDescriptor(fd) || isIOUringDescriptor(fd)
 }
@@ -33,6 +33,9 @@ type FD struct {
 	// Non-zero if this file has been set to blocking mode.
 	isBlocking uint32
 
+	// Whether reads and writes use io_uring; see iouring_linux.go.
+	ioUringState uint32
+
 	// Whether this is a streaming descriptor, as opposed to a
 	// packet-based descriptor like a UDP socket. Immutable.
 	IsStream bool
@@ -158,6 +161,12 @@ func (fd *FD) Read(p []byte) (int, error) {
 	if fd.IsStream && len(p) > maxRW {
 		p = p[:maxRW]
 	}
+	if n, handled, err := fd.ioUringRead(p, -1); handled {
+		if err != nil {
+			n = 0
+		}
+		return n, fd.eofError(n, err)
+	}
 	for {
 		n, err := ignoringEINTRIO(syscall.Read, fd.Sysfd, p)
 		if err != nil {
@@ -188,10 +197,13 @@ func (fd *FD) Pread(p []byte, off int64) (int, error) {
 		n   int
 		err error
 	)
-	for {
-		n, err = syscall.Pread(fd.Sysfd, p, off)
-		if err != syscall.EINTR {
-			break
+	var handled bool
+	if n, handled, err = fd.ioUringRead(p, off); !handled {
+		for {
+			n, err = syscall.Pread(fd.Sysfd, p, off)
+			if err != syscall.EINTR {
+				break
+			}
 		}
 	}
 	if err != nil {
@@ -271,7 +283,10 @@ func (fd *FD) Write(p []byte) (int, error) {
 		if fd.IsStream && max-nn > maxRW {
 			max = nn + maxRW
 		}
-		n, err := ignoringEINTRIO(syscall.Write, fd.Sysfd, p[nn:max])
+		n, handled, err := fd.ioUringWrite(p[nn:max], -1)
+		if !handled {
+			n, err = ignoringEINTRIO(syscall.Write, fd.Sysfd, p[nn:max])
+		}
 		if n > 0 {
 			nn += n
 		}
@@ -307,7 +322,10 @@ func (fd *FD) Pwrite(p []byte, off int64) (int, error) {
 		if fd.IsStream && max-nn > maxRW {
 			max = nn + maxRW
 		}
-		n, err := syscall.Pwrite(fd.Sysfd, p[nn:max], off+int64(nn))
+		n, handled, err := fd.ioUringWrite(p[nn:max], off+int64(nn))
+		if !handled {
+			n, err = syscall.Pwrite(fd.Sysfd, p[nn:max], off+int64(nn))
+		}
 		if err == syscall.EINTR {
 			continue
 		}
@@ -1065,3 +1065,19 @@ func (fd *FD) WriteMsg(p []byte, oob []byte, sa syscall.Sockaddr) (int, int, err
 	})
 	return n, int(o.msg.Control.Len), err
 }
+
+// DupCloseOnExec dups fd and marks it close-on-exec.
+// On Windows, fd is a handle and close-on-exec means not inheritable.
+func DupCloseOnExec(fd int) (int, string, error) {
+	proc, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return -1, "GetCurrentProcess", err
+	}
+
+	var nfd syscall.Handle
+	const inherit = false // analogous to CLOEXEC
+	if err := syscall.DuplicateHandle(proc, syscall.Handle(fd), proc, &nfd, 0, inherit, syscall.DUPLICATE_SAME_ACCESS); err != nil {
+		return -1, "DuplicateHandle", err
+	}
+	return int(nfd), "", nil
+}
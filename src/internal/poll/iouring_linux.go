@@ -0,0 +1,409 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Asynchronous file I/O with io_uring.
+//
+// Reads and writes of regular files, which can't be used with the
+// runtime poller, block the thread that makes them.
+// With GODEBUG=iouring=1, on Linux 5.6 and later, they are instead
+// submitted to an io_uring, and the goroutine waits for the result
+// without occupying a thread. Operations submitted by different
+// goroutines at the same time are batched into a single io_uring_enter
+// system call. This is off by default: the extra synchronization makes
+// each operation several times slower when files are in the page
+// cache, which only pays off when many goroutines block on slow I/O.
+//
+// Only regular files use io_uring. Sockets, pipes and other
+// descriptors that the runtime poller supports keep using it
+// (epoll on Linux), which already avoids blocking threads.
+//
+// Completions are signaled by an eventfd registered with the ring.
+// There is no goroutine dedicated to completions: one of the
+// goroutines waiting for an operation, the reaper, waits for the
+// eventfd using the runtime poller and delivers the results it
+// finds. The other waiters sleep on a condition variable, which the
+// reaper broadcasts after delivering results and when it gives up
+// the role, so that another waiter takes it over.
+//
+// If the ring fails, operations that were not submitted, and all
+// later ones, fall back to the read and write system calls.
+
+package poll
+
+import (
+	"internal/syscall/unix"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// ioUringEntries is the number of submission queue entries of the ring,
+// which is also the maximum number of operations in flight.
+const ioUringEntries = 64
+
+// An ioUring is an io_uring instance.
+type ioUring struct {
+	fd int
+
+	// Submission queue, shared with the kernel.
+	sqHead  *uint32
+	sqTail  *uint32
+	sqMask  uint32
+	sqArray []uint32
+	sqes    []unix.IOUringSQE
+
+	// Completion queue, shared with the kernel.
+	cqHead *uint32
+	cqTail *uint32
+	cqMask uint32
+	cqes   []unix.IOUringCQE
+
+	// eventfd is signaled by the kernel when completions are posted.
+	eventfd FD
+
+	// slots limits the number of operations in flight to the size
+	// of the submission queue, so that there is always room in both
+	// queues for a new operation.
+	slots chan struct{}
+
+	// failed is set, atomically, once io_uring_enter has failed.
+	failed uint32
+
+	mu          sync.Mutex
+	cond        sync.Cond // signaled when operations are over or there is no reaper; L is &mu
+	unsubmitted uint32    // entries added to the submission queue but not yet submitted
+	submitting  bool      // whether a goroutine is in io_uring_enter
+	reaping     bool      // whether a goroutine is the reaper
+	nextID      uint64
+	ops         map[uint64]*ioUringOp // operations in flight, by ID
+}
+
+// An ioUringOp is an operation in flight.
+type ioUringOp struct {
+	// buf is the buffer that the kernel reads or writes.
+	// Referencing it here keeps it on the heap and alive
+	// until the operation completes.
+	buf []byte
+
+	// The fields below are guarded by ioUring.mu.
+	done      bool // whether the operation is over
+	submitted bool // whether it was, or will be, submitted to the kernel
+	res       int32
+}
+
+var (
+	ioUringOnce sync.Once
+	ioUringRing *ioUring // nil if io_uring is not used
+)
+
+// getIOUring returns the process's io_uring, or nil if it is not
+// enabled or not supported by the kernel.
+func getIOUring() *ioUring {
+	ioUringOnce.Do(func() {
+		if !ioUringEnabled() {
+			return
+		}
+		r, err := newIOUring(ioUringEntries)
+		if err != nil {
+			return
+		}
+		ioUringRing = r
+	})
+	return ioUringRing
+}
+
+// isIOUringDescriptor reports whether fd is a descriptor of the io_uring.
+func isIOUringDescriptor(fd uintptr) bool {
+	r := ioUringRing
+	return r != nil && (fd == uintptr(r.fd) || fd == uintptr(r.eventfd.Sysfd))
+}
+
+// ioUringEnabled reports whether GODEBUG enables io_uring with
+// iouring=1. If iouring is set more than once, the last setting wins.
+func ioUringEnabled() bool {
+	s, _ := syscall.Getenv("GODEBUG")
+	enabled := false
+	for s != "" {
+		var f string
+		f, s = s, ""
+		for i := 0; i < len(f); i++ {
+			if f[i] == ',' {
+				f, s = f[:i], f[i+1:]
+				break
+			}
+		}
+		if len(f) > len("iouring=") && f[:len("iouring=")] == "iouring=" {
+			enabled = f == "iouring=1"
+		}
+	}
+	return enabled
+}
+
+// newIOUring sets up an io_uring with the given number of entries.
+func newIOUring(entries uint32) (*ioUring, error) {
+	var p unix.IOUringParams
+	fd, err := unix.IOUringSetup(entries, &p)
+	if err != nil {
+		return nil, err
+	}
+	// Reading and writing at the current file position requires
+	// Linux 5.6, and guaranteed delivery of completions Linux 5.5.
+	// A single mapping for both rings is available since Linux 5.4.
+	const required = unix.IORING_FEAT_SINGLE_MMAP | unix.IORING_FEAT_NODROP | unix.IORING_FEAT_RW_CUR_POS
+	if p.Features&required != required {
+		syscall.Close(fd)
+		return nil, syscall.ENOSYS
+	}
+
+	ringSize := p.SQOff.Array + p.SQEntries*4
+	if n := p.CQOff.CQEs + p.CQEntries*uint32(unsafe.Sizeof(unix.IOUringCQE{})); n > ringSize {
+		ringSize = n
+	}
+	ring, err := syscall.Mmap(fd, unix.IORING_OFF_SQ_RING, int(ringSize), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	sqes, err := syscall.Mmap(fd, unix.IORING_OFF_SQES, int(p.SQEntries)*int(unsafe.Sizeof(unix.IOUringSQE{})), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	if err != nil {
+		syscall.Munmap(ring)
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	r := &ioUring{
+		fd:      fd,
+		sqHead:  (*uint32)(unsafe.Pointer(&ring[p.SQOff.Head])),
+		sqTail:  (*uint32)(unsafe.Pointer(&ring[p.SQOff.Tail])),
+		sqMask:  *(*uint32)(unsafe.Pointer(&ring[p.SQOff.RingMask])),
+		sqArray: (*[1 << 20]uint32)(unsafe.Pointer(&ring[p.SQOff.Array]))[:p.SQEntries:p.SQEntries],
+		sqes:    (*[1 << 20]unix.IOUringSQE)(unsafe.Pointer(&sqes[0]))[:p.SQEntries:p.SQEntries],
+		cqHead:  (*uint32)(unsafe.Pointer(&ring[p.CQOff.Head])),
+		cqTail:  (*uint32)(unsafe.Pointer(&ring[p.CQOff.Tail])),
+		cqMask:  *(*uint32)(unsafe.Pointer(&ring[p.CQOff.RingMask])),
+		cqes:    (*[1 << 20]unix.IOUringCQE)(unsafe.Pointer(&ring[p.CQOff.CQEs]))[:p.CQEntries:p.CQEntries],
+		slots:   make(chan struct{}, p.SQEntries),
+		ops:     make(map[uint64]*ioUringOp),
+	}
+	r.cond.L = &r.mu
+
+	efd, err := unix.Eventfd(0, syscall.O_CLOEXEC|syscall.O_NONBLOCK)
+	if err == nil {
+		err = unix.IOUringRegister(fd, unix.IORING_REGISTER_EVENTFD, unsafe.Pointer(&efd), 1)
+		if err != nil {
+			syscall.Close(efd)
+		}
+	}
+	if err == nil {
+		r.eventfd = FD{Sysfd: efd}
+		if err = r.eventfd.Init("eventfd", true); err != nil || !r.eventfd.pd.pollable() {
+			r.eventfd.Close()
+			err = syscall.ENOSYS
+		}
+	}
+	if err != nil {
+		syscall.Munmap(sqes)
+		syscall.Munmap(ring)
+		syscall.Close(fd)
+		return nil, err
+	}
+	return r, nil
+}
+
+// rw performs a read or write operation with the given opcode on fd
+// and returns its result: the number of bytes transferred, or an
+// error. An offset of -1 means the current file position.
+// handled is false if the operation could not be submitted.
+func (r *ioUring) rw(opcode uint8, fd int, p []byte, off int64) (n int, handled bool, err error) {
+	op := &ioUringOp{buf: p}
+	sqe := unix.IOUringSQE{
+		Opcode: opcode,
+		Fd:     int32(fd),
+		Off:    uint64(off),
+		Len:    uint32(len(p)),
+	}
+	if len(p) > 0 {
+		sqe.Addr = uint64(uintptr(unsafe.Pointer(&p[0])))
+	}
+
+	r.slots <- struct{}{}
+	r.submit(&sqe, op)
+	r.wait(op)
+	<-r.slots
+
+	if !op.submitted {
+		return 0, false, nil
+	}
+	if op.res < 0 {
+		return 0, true, syscall.Errno(-op.res)
+	}
+	return int(op.res), true, nil
+}
+
+// submit adds sqe, for op, to the submission queue and makes sure it is
+// submitted to the kernel. If another goroutine is already submitting
+// entries, that goroutine submits sqe as well, in the same batch.
+// If io_uring_enter fails, the unsubmitted entries are removed from
+// the queue and their operations are over without being submitted.
+// The caller must hold a slot.
+func (r *ioUring) submit(sqe *unix.IOUringSQE, op *ioUringOp) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if atomic.LoadUint32(&r.failed) != 0 {
+		op.done = true
+		return
+	}
+
+	sqe.UserData = r.nextID
+	r.ops[r.nextID] = op
+	r.nextID++
+	op.submitted = true
+
+	// There is room in the queue: every slot holder's entry is
+	// either not yet submitted or has been consumed by the kernel.
+	tail := atomic.LoadUint32(r.sqTail)
+	i := tail & r.sqMask
+	r.sqes[i] = *sqe
+	r.sqArray[i] = i
+	atomic.StoreUint32(r.sqTail, tail+1)
+	r.unsubmitted++
+
+	if r.submitting {
+		return
+	}
+	r.submitting = true
+	for r.unsubmitted > 0 {
+		n := r.unsubmitted
+		r.mu.Unlock()
+		m, err := unix.IOUringEnter(r.fd, n, 0, 0)
+		r.mu.Lock()
+		switch err {
+		case nil:
+			r.unsubmitted -= uint32(m)
+		case syscall.EINTR, syscall.EAGAIN, syscall.EBUSY:
+			// Try again.
+		default:
+			// The kernel only reads the submission queue in
+			// io_uring_enter, so the unsubmitted entries can
+			// be taken back.
+			atomic.StoreUint32(&r.failed, 1)
+			atomic.StoreUint32(r.sqTail, atomic.LoadUint32(r.sqTail)-r.unsubmitted)
+			for id := r.nextID - uint64(r.unsubmitted); id < r.nextID; id++ {
+				op := r.ops[id]
+				delete(r.ops, id)
+				op.submitted = false
+				op.done = true
+			}
+			r.unsubmitted = 0
+			r.cond.Broadcast()
+		}
+	}
+	r.submitting = false
+}
+
+// wait waits for op to be over. If there is no reaper,
+// the calling goroutine becomes the reaper until then.
+func (r *ioUring) wait(op *ioUringOp) {
+	r.mu.Lock()
+	for !op.done && r.reaping {
+		r.cond.Wait()
+	}
+	if op.done {
+		r.mu.Unlock()
+		return
+	}
+	r.reaping = true
+	r.mu.Unlock()
+
+	var buf [8]byte
+	for !r.reap(op) {
+		// Wait for the kernel to post more completions.
+		if _, err := r.eventfd.Read(buf[:]); err != nil {
+			// Wait in the kernel instead, blocking the thread.
+			unix.IOUringEnter(r.fd, 0, 1, unix.IORING_ENTER_GETEVENTS)
+		}
+	}
+
+	r.mu.Lock()
+	r.reaping = false
+	// Let a goroutine that is still waiting take over.
+	r.cond.Broadcast()
+	r.mu.Unlock()
+}
+
+// reap delivers the results of the completed operations,
+// wakes their goroutines and reports whether op is over.
+func (r *ioUring) reap(op *ioUringOp) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	head := atomic.LoadUint32(r.cqHead)
+	tail := atomic.LoadUint32(r.cqTail)
+	if head == tail {
+		return op.done
+	}
+	for ; head != tail; head++ {
+		cqe := &r.cqes[head&r.cqMask]
+		done := r.ops[cqe.UserData]
+		delete(r.ops, cqe.UserData)
+		done.res = cqe.Res
+		done.done = true
+	}
+	atomic.StoreUint32(r.cqHead, head)
+	r.cond.Broadcast()
+	return op.done
+}
+
+// ioUringRead reads from fd at offset off, or at the current file
+// position if off is -1, using io_uring. handled reports whether
+// io_uring was used; if not, the caller should make the system call.
+func (fd *FD) ioUringRead(p []byte, off int64) (n int, handled bool, err error) {
+	return fd.ioUringRW(unix.IORING_OP_READ, p, off)
+}
+
+// ioUringWrite is like ioUringRead, but writes.
+func (fd *FD) ioUringWrite(p []byte, off int64) (n int, handled bool, err error) {
+	return fd.ioUringRW(unix.IORING_OP_WRITE, p, off)
+}
+
+// Values of FD.ioUringState.
+const (
+	ioUringUnknown = iota
+	ioUringUsed
+	ioUringUnused
+)
+
+// useIOUring reports whether fd's reads and writes use io_uring.
+// Only regular files do: other kinds of descriptors that the runtime
+// poller does not support, such as pipes in blocking mode, may have
+// been put in non-blocking mode, which io_uring would ignore.
+func (fd *FD) useIOUring() bool {
+	switch atomic.LoadUint32(&fd.ioUringState) {
+	case ioUringUsed:
+		return true
+	case ioUringUnused:
+		return false
+	}
+	state := uint32(ioUringUnused)
+	var st syscall.Stat_t
+	if fd.isFile && !fd.pd.pollable() && syscall.Fstat(fd.Sysfd, &st) == nil && st.Mode&syscall.S_IFMT == syscall.S_IFREG {
+		state = ioUringUsed
+	}
+	atomic.StoreUint32(&fd.ioUringState, state)
+	return state == ioUringUsed
+}
+
+func (fd *FD) ioUringRW(opcode uint8, p []byte, off int64) (n int, handled bool, err error) {
+	r := getIOUring()
+	if r == nil || atomic.LoadUint32(&r.failed) != 0 || !fd.useIOUring() {
+		return 0, false, nil
+	}
+	for {
+		n, handled, err = r.rw(opcode, fd.Sysfd, p, off)
+		if err != syscall.EINTR {
+			return n, handled, err
+		}
+	}
+}
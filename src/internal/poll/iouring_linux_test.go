@@ -0,0 +1,196 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll_test
+
+import (
+	"bytes"
+	"internal/poll"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withIOUring reports whether io_uring is in use. If it is not, it
+// runs the calling test again in a subprocess with GODEBUG=iouring=1,
+// so the caller must return when it reports false.
+func withIOUring(t *testing.T) bool {
+	if poll.IOUringEnabled() {
+		return true
+	}
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		t.Skip("io_uring not available")
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "GODEBUG=iouring=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if strings.Contains(string(out), "--- SKIP") {
+		t.Skip("io_uring not available")
+	}
+	return false
+}
+
+func TestIOUringFile(t *testing.T) {
+	if !withIOUring(t) {
+		return
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Many concurrent writes and reads at different offsets,
+	// so that operations are batched.
+	const (
+		chunks    = 200
+		chunkSize = 1000
+	)
+	chunk := func(i int) []byte {
+		return bytes.Repeat([]byte{byte('a' + i%26)}, chunkSize)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < chunks; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := f.WriteAt(chunk(i), int64(i*chunkSize)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < chunks; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := make([]byte, chunkSize)
+			if _, err := f.ReadAt(b, int64(i*chunkSize)); err != nil {
+				t.Error(err)
+			} else if !bytes.Equal(b, chunk(i)) {
+				t.Errorf("chunk %d: read %q...", i, b[:10])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Reads and writes at the current position.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 3)
+	if _, err := io.ReadFull(f, b); err != nil {
+		t.Fatal(err)
+	}
+	if want := string(chunk(0)[:3]); string(b) != want {
+		t.Errorf("read %q at offset 5; want %q", b, want)
+	}
+	if _, err := f.Seek(chunks*chunkSize, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := f.Read(b); n != 0 || err != io.EOF {
+		t.Errorf("read at end of file = %d, %v; want 0, EOF", n, err)
+	}
+}
+
+func TestIOUringGODEBUG(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		if poll.IOUringEnabled() {
+			os.Stdout.WriteString("enabled")
+		}
+		os.Exit(0)
+	}
+	// io_uring is only used if GODEBUG asks for it.
+	for _, godebug := range []string{"", "madvdontneed=1", "iouring=0", "iouring=1,iouring=0"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestIOUringGODEBUG$")
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "GODEBUG="+godebug)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		if strings.Contains(string(out), "enabled") {
+			t.Errorf("io_uring is used with GODEBUG=%s", godebug)
+		}
+	}
+}
+
+// The file I/O benchmarks below use io_uring if GODEBUG=iouring=1
+// is set and io_uring is available. Compare their results with those
+// obtained without it to measure its effect.
+
+func benchmarkFile(b *testing.B, size int) *os.File {
+	f, err := os.Create(filepath.Join(b.TempDir(), "file"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { f.Close() })
+	if _, err := f.Write(make([]byte, size)); err != nil {
+		b.Fatal(err)
+	}
+	return f
+}
+
+func BenchmarkFileReadAt(b *testing.B) {
+	for _, size := range []int{512, 64 << 10} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			const chunks = 64
+			f := benchmarkFile(b, chunks*size)
+			b.SetBytes(int64(size))
+			b.RunParallel(func(pb *testing.PB) {
+				buf := make([]byte, size)
+				for i := 0; pb.Next(); i++ {
+					if _, err := f.ReadAt(buf, int64(i%chunks*size)); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkFileWriteAt(b *testing.B) {
+	for _, size := range []int{512, 64 << 10} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			const chunks = 64
+			f := benchmarkFile(b, chunks*size)
+			b.SetBytes(int64(size))
+			b.RunParallel(func(pb *testing.PB) {
+				buf := make([]byte, size)
+				for i := 0; pb.Next(); i++ {
+					if _, err := f.WriteAt(buf, int64(i%chunks*size)); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkFileRead(b *testing.B) {
+	const size = 4 << 10
+	f := benchmarkFile(b, 1<<20)
+	buf := make([]byte, size)
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Read(buf); err == io.EOF {
+			f.Seek(0, io.SeekStart)
+		} else if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build aix darwin dragonfly freebsd js,wasm netbsd openbsd solaris windows
+
+package poll
+
+func (fd *FD) ioUringRead(p []byte, off int64) (n int, handled bool, err error) {
+	return 0, false, nil
+}
+
+func (fd *FD) ioUringWrite(p []byte, off int64) (n int, handled bool, err error) {
+	return 0, false, nil
+}
+
+func isIOUringDescriptor(fd uintptr) bool {
+	return false
+}
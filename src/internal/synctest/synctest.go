@@ -0,0 +1,14 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package synctest provides support for testing concurrent code.
+//
+// See the testing/synctest package for function documentation.
+package synctest
+
+// Run is implemented in package runtime.
+func Run(f func())
+
+// Wait is implemented in package runtime.
+func Wait()
@@ -56,3 +56,35 @@ func Fstatat(dirfd int, path string, stat *syscall.Stat_t, flags int) error {
 	return nil
 
 }
+
+func Mkdirat(dirfd int, path string, perm uint32) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall(mkdiratTrap, uintptr(dirfd), uintptr(unsafe.Pointer(p)), uintptr(perm))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func Readlinkat(dirfd int, path string, buf []byte) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var b unsafe.Pointer
+	if len(buf) > 0 {
+		b = unsafe.Pointer(&buf[0])
+	}
+
+	n, _, errno := syscall.Syscall6(readlinkatTrap, uintptr(dirfd), uintptr(unsafe.Pointer(p)), uintptr(b), uintptr(len(buf)), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// io_uring constants, see include/uapi/linux/io_uring.h.
+const (
+	IORING_OFF_SQ_RING = 0
+	IORING_OFF_CQ_RING = 0x8000000
+	IORING_OFF_SQES    = 0x10000000
+
+	IORING_FEAT_SINGLE_MMAP = 1 << 0
+	IORING_FEAT_NODROP      = 1 << 1
+	IORING_FEAT_RW_CUR_POS  = 1 << 3
+
+	IORING_ENTER_GETEVENTS = 1 << 0
+
+	IORING_REGISTER_EVENTFD = 4
+
+	IORING_OP_READ  = 22
+	IORING_OP_WRITE = 23
+)
+
+// IOUringSQOffsets is struct io_sqring_offsets.
+type IOUringSQOffsets struct {
+	Head        uint32
+	Tail        uint32
+	RingMask    uint32
+	RingEntries uint32
+	Flags       uint32
+	Dropped     uint32
+	Array       uint32
+	_           uint32
+	_           uint64
+}
+
+// IOUringCQOffsets is struct io_cqring_offsets.
+type IOUringCQOffsets struct {
+	Head        uint32
+	Tail        uint32
+	RingMask    uint32
+	RingEntries uint32
+	Overflow    uint32
+	CQEs        uint32
+	Flags       uint32
+	_           uint32
+	_           uint64
+}
+
+// IOUringParams is struct io_uring_params.
+type IOUringParams struct {
+	SQEntries    uint32
+	CQEntries    uint32
+	Flags        uint32
+	SQThreadCPU  uint32
+	SQThreadIdle uint32
+	Features     uint32
+	WQFd         uint32
+	_            [3]uint32
+	SQOff        IOUringSQOffsets
+	CQOff        IOUringCQOffsets
+}
+
+// IOUringSQE is struct io_uring_sqe, a submission queue entry.
+type IOUringSQE struct {
+	Opcode      uint8
+	Flags       uint8
+	IOPrio      uint16
+	Fd          int32
+	Off         uint64
+	Addr        uint64
+	Len         uint32
+	OpFlags     uint32
+	UserData    uint64
+	BufIndex    uint16
+	Personality uint16
+	SpliceFdIn  int32
+	_           [2]uint64
+}
+
+// IOUringCQE is struct io_uring_cqe, a completion queue entry.
+type IOUringCQE struct {
+	UserData uint64
+	Res      int32
+	Flags    uint32
+}
+
+// IOUringSetup is the io_uring_setup system call,
+// available since Linux 5.1.
+func IOUringSetup(entries uint32, params *IOUringParams) (int, error) {
+	fd, _, errno := syscall.Syscall(ioUringSetupTrap, uintptr(entries), uintptr(unsafe.Pointer(params)), 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// IOUringEnter is the io_uring_enter system call,
+// available since Linux 5.1.
+func IOUringEnter(fd int, toSubmit, minComplete uint32, flags uint) (int, error) {
+	n, _, errno := syscall.Syscall6(ioUringEnterTrap, uintptr(fd), uintptr(toSubmit), uintptr(minComplete), uintptr(flags), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+// IOUringRegister is the io_uring_register system call,
+// available since Linux 5.1.
+func IOUringRegister(fd int, opcode uint, arg unsafe.Pointer, nrArgs uint) error {
+	_, _, errno := syscall.Syscall6(ioUringRegisterTrap, uintptr(fd), uintptr(opcode), uintptr(arg), uintptr(nrArgs), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Eventfd is the eventfd2 system call. The EFD_CLOEXEC and
+// EFD_NONBLOCK flags have the values of O_CLOEXEC and O_NONBLOCK.
+func Eventfd(initval uint, flags int) (int, error) {
+	fd, _, errno := syscall.Syscall(syscall.SYS_EVENTFD2, uintptr(initval), uintptr(flags), 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
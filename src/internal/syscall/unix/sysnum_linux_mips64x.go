@@ -7,6 +7,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 5313
-	copyFileRangeTrap uintptr = 5320
+	getrandomTrap       uintptr = 5313
+	copyFileRangeTrap   uintptr = 5320
+	openat2Trap         uintptr = 5437
+	pidfdSendSignalTrap uintptr = 5424
+	pidfdOpenTrap       uintptr = 5434
+	ioUringSetupTrap    uintptr = 5425
+	ioUringEnterTrap    uintptr = 5426
+	ioUringRegisterTrap uintptr = 5427
 )
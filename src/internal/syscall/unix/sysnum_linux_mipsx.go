@@ -7,6 +7,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 4353
-	copyFileRangeTrap uintptr = 4360
+	getrandomTrap       uintptr = 4353
+	copyFileRangeTrap   uintptr = 4360
+	openat2Trap         uintptr = 4437
+	pidfdSendSignalTrap uintptr = 4424
+	pidfdOpenTrap       uintptr = 4434
+	ioUringSetupTrap    uintptr = 4425
+	ioUringEnterTrap    uintptr = 4426
+	ioUringRegisterTrap uintptr = 4427
 )
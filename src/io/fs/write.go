@@ -0,0 +1,193 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"path"
+)
+
+// A WriterFile is a file that can be written to.
+// The file returned by CreateFS.Create implements it.
+type WriterFile interface {
+	File
+
+	// Write writes len(p) bytes from p to the file.
+	// It returns the number of bytes written and any error encountered
+	// that caused the write to stop early.
+	Write(p []byte) (n int, err error)
+}
+
+// A CreateFS is a file system with a Create method.
+type CreateFS interface {
+	FS
+
+	// Create creates or truncates the named file and opens it for writing.
+	// If the file does not exist, it is created with permissions perm
+	// (before umask); otherwise Create truncates it without changing
+	// its permissions.
+	// If there is an error, it should be of type *PathError.
+	Create(name string, perm FileMode) (WriterFile, error)
+}
+
+// WriteFileFS is the interface implemented by a file system
+// that provides an optimized implementation of WriteFile.
+type WriteFileFS interface {
+	FS
+
+	// WriteFile writes data to the named file, creating it if necessary.
+	WriteFile(name string, data []byte, perm FileMode) error
+}
+
+// A MkdirFS is a file system with a Mkdir method.
+type MkdirFS interface {
+	FS
+
+	// Mkdir creates a new directory with the specified name
+	// and permission bits (before umask).
+	// The parent directory must already exist.
+	// If there is an error, it should be of type *PathError.
+	Mkdir(name string, perm FileMode) error
+}
+
+// A RemoveFS is a file system with a Remove method.
+type RemoveFS interface {
+	FS
+
+	// Remove removes the named file or (empty) directory.
+	// If there is an error, it should be of type *PathError.
+	Remove(name string) error
+}
+
+// A RenameFS is a file system with a Rename method.
+type RenameFS interface {
+	FS
+
+	// Rename renames (moves) oldname to newname.
+	// If newname already exists and is not a directory, Rename replaces it.
+	Rename(oldname, newname string) error
+}
+
+// A ChmodFS is a file system with a Chmod method.
+type ChmodFS interface {
+	FS
+
+	// Chmod changes the mode of the named file to mode.
+	// If the file is a symbolic link, it changes the mode of the link's target.
+	// If there is an error, it should be of type *PathError.
+	Chmod(name string, mode FileMode) error
+}
+
+// A SymlinkFS is a file system with a Symlink method.
+type SymlinkFS interface {
+	FS
+
+	// Symlink creates newname as a symbolic link to oldname.
+	// The link target oldname is stored as given and need not
+	// be a valid path name in the file system.
+	Symlink(oldname, newname string) error
+}
+
+// WriteFile writes data to the named file in the file system,
+// creating it if necessary.
+// If the file does not exist, WriteFile creates it with permissions perm
+// (before umask); otherwise WriteFile truncates it before writing,
+// without changing permissions.
+//
+// If fs implements WriteFileFS, WriteFile calls fs.WriteFile.
+// Otherwise, if fs implements CreateFS, WriteFile calls fs.Create
+// and uses Write and Close on the returned file.
+func WriteFile(fsys FS, name string, data []byte, perm FileMode) error {
+	if fsys, ok := fsys.(WriteFileFS); ok {
+		return fsys.WriteFile(name, data, perm)
+	}
+
+	cfs, ok := fsys.(CreateFS)
+	if !ok {
+		return &PathError{Op: "writefile", Path: name, Err: errors.New("not implemented")}
+	}
+	file, err := cfs.Create(name, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
+
+// MkdirAll creates the named directory in the file system,
+// along with any necessary parents.
+// The permission bits perm (before umask) are used for all
+// directories that MkdirAll creates.
+// If name is already a directory, MkdirAll does nothing
+// and returns nil.
+//
+// MkdirAll requires fs to implement MkdirFS.
+func MkdirAll(fsys FS, name string, perm FileMode) error {
+	mfs, ok := fsys.(MkdirFS)
+	if !ok {
+		return &PathError{Op: "mkdir", Path: name, Err: errors.New("not implemented")}
+	}
+	if !ValidPath(name) {
+		return &PathError{Op: "mkdir", Path: name, Err: ErrInvalid}
+	}
+	info, err := Stat(fsys, name)
+	if err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return &PathError{Op: "mkdir", Path: name, Err: ErrExist}
+	}
+	if dir := path.Dir(name); dir != "." {
+		if err := MkdirAll(fsys, dir, perm); err != nil {
+			return err
+		}
+	}
+	err = mfs.Mkdir(name, perm)
+	if err != nil {
+		// Handle a directory created concurrently.
+		if info, err1 := Stat(fsys, name); err1 == nil && info.IsDir() {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveAll removes the named file or directory from the file system,
+// along with any children it contains.
+// If the name does not exist, RemoveAll returns nil.
+//
+// RemoveAll requires fs to implement RemoveFS.
+func RemoveAll(fsys FS, name string) error {
+	rfs, ok := fsys.(RemoveFS)
+	if !ok {
+		return &PathError{Op: "removeall", Path: name, Err: errors.New("not implemented")}
+	}
+	if !ValidPath(name) || name == "." {
+		return &PathError{Op: "removeall", Path: name, Err: ErrInvalid}
+	}
+	err := rfs.Remove(name)
+	if err == nil || errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	list, err1 := ReadDir(fsys, name)
+	if err1 != nil {
+		// Not a directory, or not readable: report the Remove error.
+		return err
+	}
+	for _, d := range list {
+		if err := RemoveAll(fsys, path.Join(name, d.Name())); err != nil {
+			return err
+		}
+	}
+	err = rfs.Remove(name)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	. "io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// createOnly hides all methods of a MapFS but Open and Create,
+// so that the helpers must fall back to them.
+type createOnly struct {
+	m fstest.MapFS
+}
+
+func (c createOnly) Open(name string) (File, error) { return c.m.Open(name) }
+
+func (c createOnly) Create(name string, perm FileMode) (WriterFile, error) {
+	return c.m.Create(name, perm)
+}
+
+func TestWriteFile(t *testing.T) {
+	m := fstest.MapFS{"dir": {Mode: ModeDir}}
+	for _, fsys := range []FS{m, createOnly{m}} {
+		if err := WriteFile(fsys, "dir/file", []byte("hello, world"), 0644); err != nil {
+			t.Fatalf("WriteFile(%T): %v", fsys, err)
+		}
+		data, err := ReadFile(fsys, "dir/file")
+		if err != nil || string(data) != "hello, world" {
+			t.Fatalf("ReadFile after WriteFile(%T) = %q, %v", fsys, data, err)
+		}
+		if info, err := Stat(fsys, "dir/file"); err != nil || info.Mode() != 0644 {
+			t.Fatalf("Stat after WriteFile(%T) = %v, %v; want mode 0644", fsys, info, err)
+		}
+		if err := WriteFile(fsys, "missing/file", nil, 0644); !errors.Is(err, ErrNotExist) {
+			t.Fatalf("WriteFile(%T) with missing parent = %v; want not exist error", fsys, err)
+		}
+		delete(m, "dir/file")
+	}
+
+	if err := WriteFile(struct{ FS }{testFsys}, "file", nil, 0644); err == nil {
+		t.Fatalf("WriteFile on read-only file system succeeded")
+	}
+}
+
+func TestMkdirAllRemoveAll(t *testing.T) {
+	m := fstest.MapFS{"a/file": {Data: []byte("a")}}
+	if err := MkdirAll(m, "a/b/c", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := MkdirAll(m, "a/b/c", 0755); err != nil {
+		t.Fatalf("second MkdirAll: %v", err)
+	}
+	for _, name := range []string{"a/b", "a/b/c"} {
+		if info, err := Stat(m, name); err != nil || info.Mode() != ModeDir|0755 {
+			t.Fatalf("Stat(%s) = %v, %v; want mode %v", name, info, err, ModeDir|0755)
+		}
+	}
+	if err := MkdirAll(m, "a/file/c", 0755); !errors.Is(err, ErrExist) {
+		t.Fatalf("MkdirAll below file = %v; want exist error", err)
+	}
+	if err := MkdirAll(m, "../a", 0755); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("MkdirAll(../a) = %v; want invalid error", err)
+	}
+
+	if err := RemoveAll(m, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 0 {
+		t.Fatalf("RemoveAll left %d entries", len(m))
+	}
+	if err := RemoveAll(m, "a"); err != nil {
+		t.Fatalf("RemoveAll of missing directory: %v", err)
+	}
+	if err := RemoveAll(m, "."); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("RemoveAll(.) = %v; want invalid error", err)
+	}
+	if err := MkdirAll(struct{ FS }{testFsys}, "dir", 0755); err == nil {
+		t.Fatalf("MkdirAll on read-only file system succeeded")
+	}
+}
@@ -126,11 +126,14 @@ func (c *conf) hostLookupOrder(r *Resolver, hostname string) (ret hostLookupOrde
 			print("go package net: hostLookupOrder(", hostname, ") = ", ret.String(), "\n")
 		}()
 	}
+	// Only the Go resolver supports encrypted DNS. The options
+	// requesting it are ignored if r cannot dial TLS.
+	encrypted := c.resolv.encryptedOpt != nil && r.canDialTLS()
 	fallbackOrder := hostLookupCgo
-	if c.netGo || r.preferGo() {
+	if c.netGo || r.preferGo() || encrypted {
 		fallbackOrder = hostLookupFilesDNS
 	}
-	if c.forceCgoLookupHost || c.resolv.unknownOpt || c.goos == "android" {
+	if c.forceCgoLookupHost || c.resolv.unknownOpt || encrypted || c.goos == "android" {
 		return fallbackOrder
 	}
 	if bytealg.IndexByteString(hostname, '\\') != -1 || bytealg.IndexByteString(hostname, '%') != -1 {
@@ -7,6 +7,7 @@
 package net
 
 import (
+	"context"
 	"io/fs"
 	"strings"
 	"testing"
@@ -29,6 +30,15 @@ var defaultResolvConf = &dnsConfig{
 	err:      fs.ErrNotExist,
 }
 
+// represents a dnsConfig for a resolv.conf asking for DNS over TLS
+var encryptedResolvConf = &dnsConfig{
+	servers:      defaultNS,
+	ndots:        1,
+	timeout:      5,
+	attempts:     2,
+	encryptedOpt: &EncryptedDNS{Protocol: "tls"},
+}
+
 func TestConfHostLookupOrder(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -345,6 +355,34 @@ func TestConfHostLookupOrder(t *testing.T) {
 				{"localhost", "myhostname", hostLookupFilesDNS},
 			},
 		},
+		// Encrypted DNS options in resolv.conf are ignored
+		// unless the Resolver can dial TLS.
+		{
+			name:     "encrypted-without-dialtls",
+			resolver: &Resolver{},
+			c: &conf{
+				nss:    nssStr("hosts: files mdns4_minimal [NOTFOUND=return] dns mdns4"),
+				resolv: encryptedResolvConf,
+			},
+			hostTests: []nssHostTest{
+				{"foo.local", "myhostname", hostLookupCgo},
+			},
+		},
+		{
+			name: "encrypted-with-dialtls",
+			resolver: &Resolver{
+				DialTLS: func(ctx context.Context, network, address, serverName string) (Conn, error) {
+					panic("unreachable")
+				},
+			},
+			c: &conf{
+				nss:    nssStr("hosts: files mdns4_minimal [NOTFOUND=return] dns mdns4"),
+				resolv: encryptedResolvConf,
+			},
+			hostTests: []nssHostTest{
+				{"foo.local", "myhostname", hostLookupFilesDNS},
+			},
+		},
 	}
 
 	origGetHostname := getHostname
@@ -229,3 +229,247 @@ func (s byPref) sort() {
 type NS struct {
 	Host string
 }
+
+// An SVCB represents a single DNS SVCB or HTTPS record, as defined
+// in RFC 9460.
+//
+// A record with Priority 0 is in AliasMode: it says that the records
+// for the service are those of another domain name, Target, and has
+// no parameters. Other records are in ServiceMode and each describe
+// an alternative endpoint of the service; those with a lower Priority
+// are preferred. A ServiceMode Target of "." means the owner name of
+// the record, that is, the name that was looked up.
+type SVCB struct {
+	Priority uint16
+	Target   string
+
+	// ALPN lists the ALPN protocol identifiers supported by the
+	// endpoint, such as "h2" or "http/1.1". Unless NoDefaultALPN is
+	// set, the default protocol of the scheme is also supported.
+	ALPN          []string
+	NoDefaultALPN bool
+
+	// Port is the TCP or UDP port of the endpoint,
+	// or 0 if it is the default port of the scheme.
+	Port uint16
+
+	// IPHint lists IPv4 and IPv6 addresses of Target that may be
+	// used while its address records are being looked up.
+	IPHint []IP
+
+	// ECHConfig is the ECHConfigList to use for TLS Encrypted
+	// Client Hello with the endpoint, if any.
+	ECHConfig []byte
+}
+
+// bySVCBPriority implements sort.Interface to sort SVCB records by priority.
+type bySVCBPriority []*SVCB
+
+func (s bySVCBPriority) Len() int           { return len(s) }
+func (s bySVCBPriority) Less(i, j int) bool { return s[i].Priority < s[j].Priority }
+func (s bySVCBPriority) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// sort reorders SVCB records by priority, shuffling those
+// of equal priority as recommended by RFC 9460, section 2.4.1.
+func (s bySVCBPriority) sort() {
+	for i := range s {
+		j := randIntn(i + 1)
+		s[i], s[j] = s[j], s[i]
+	}
+	sort.Sort(s)
+}
+
+// Record types of SVCB and HTTPS records (RFC 9460, section 14.1),
+// which package dnsmessage does not know.
+const (
+	dnsTypeSVCB  dnsmessage.Type = 64
+	dnsTypeHTTPS dnsmessage.Type = 65
+)
+
+// SvcParamKeys understood by newSVCB (RFC 9460, section 14.3.2).
+const (
+	svcParamMandatory     = 0
+	svcParamALPN          = 1
+	svcParamNoDefaultALPN = 2
+	svcParamPort          = 3
+	svcParamIPv4Hint      = 4
+	svcParamECH           = 5
+	svcParamIPv6Hint      = 6
+)
+
+// answerData returns the data of the records of type qtype in the
+// answer section of the DNS message msg. It reports false if the
+// message is malformed.
+func answerData(msg []byte, qtype dnsmessage.Type) ([][]byte, bool) {
+	if len(msg) < 12 {
+		return nil, false
+	}
+	qdcount := int(msg[4])<<8 | int(msg[5])
+	ancount := int(msg[6])<<8 | int(msg[7])
+	off, ok := 12, true
+	for i := 0; i < qdcount; i++ {
+		if off, ok = skipDNSName(msg, off); !ok || off+4 > len(msg) {
+			return nil, false
+		}
+		off += 4 // type and class
+	}
+	var data [][]byte
+	for i := 0; i < ancount; i++ {
+		if off, ok = skipDNSName(msg, off); !ok || off+10 > len(msg) {
+			return nil, false
+		}
+		typ := dnsmessage.Type(msg[off])<<8 | dnsmessage.Type(msg[off+1])
+		n := int(msg[off+8])<<8 | int(msg[off+9]) // after type, class and TTL
+		off += 10
+		if off+n > len(msg) {
+			return nil, false
+		}
+		if typ == qtype {
+			data = append(data, msg[off:off+n])
+		}
+		off += n
+	}
+	return data, true
+}
+
+// skipDNSName returns the offset in msg just past the domain name
+// starting at off, which may end with a compression pointer.
+func skipDNSName(msg []byte, off int) (int, bool) {
+	for off < len(msg) {
+		c := int(msg[off])
+		switch c & 0xC0 {
+		case 0x00:
+			if c == 0 {
+				return off + 1, true
+			}
+			off += 1 + c
+		case 0xC0:
+			return off + 2, off+2 <= len(msg)
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// newSVCB returns the SVCB for the record data b of an SVCB or HTTPS
+// record. It reports false if the record is malformed or requires
+// parameters that are not understood, in which case it must be ignored
+// (RFC 9460, sections 2.2 and 8).
+func newSVCB(b []byte) (*SVCB, bool) {
+	if len(b) < 2 {
+		return nil, false
+	}
+	s := &SVCB{Priority: uint16(b[0])<<8 | uint16(b[1])}
+	target, params, ok := svcbTarget(b[2:])
+	if !ok {
+		return nil, false
+	}
+	s.Target = target
+	if s.Priority == 0 {
+		// Parameters of AliasMode records are ignored.
+		return s, true
+	}
+	lastKey := -1
+	for len(params) > 0 {
+		if len(params) < 4 {
+			return nil, false
+		}
+		key := int(params[0])<<8 | int(params[1])
+		n := int(params[2])<<8 | int(params[3])
+		if key <= lastKey || 4+n > len(params) {
+			// Keys must be in strictly increasing order.
+			return nil, false
+		}
+		lastKey = key
+		v := params[4 : 4+n]
+		params = params[4+n:]
+		switch key {
+		case svcParamMandatory:
+			if len(v) == 0 || len(v)%2 != 0 {
+				return nil, false
+			}
+			for ; len(v) > 0; v = v[2:] {
+				switch int(v[0])<<8 | int(v[1]) {
+				case svcParamALPN, svcParamNoDefaultALPN, svcParamPort,
+					svcParamIPv4Hint, svcParamECH, svcParamIPv6Hint:
+				default:
+					return nil, false
+				}
+			}
+		case svcParamALPN:
+			if len(v) == 0 {
+				return nil, false
+			}
+			for len(v) > 0 {
+				n := int(v[0])
+				if n == 0 || 1+n > len(v) {
+					return nil, false
+				}
+				s.ALPN = append(s.ALPN, string(v[1:1+n]))
+				v = v[1+n:]
+			}
+		case svcParamNoDefaultALPN:
+			if len(v) != 0 {
+				return nil, false
+			}
+			s.NoDefaultALPN = true
+		case svcParamPort:
+			if len(v) != 2 {
+				return nil, false
+			}
+			s.Port = uint16(v[0])<<8 | uint16(v[1])
+		case svcParamIPv4Hint:
+			if len(v) == 0 || len(v)%IPv4len != 0 {
+				return nil, false
+			}
+			for ; len(v) > 0; v = v[IPv4len:] {
+				s.IPHint = append(s.IPHint, IPv4(v[0], v[1], v[2], v[3]))
+			}
+		case svcParamECH:
+			if len(v) == 0 {
+				return nil, false
+			}
+			s.ECHConfig = append([]byte(nil), v...)
+		case svcParamIPv6Hint:
+			if len(v) == 0 || len(v)%IPv6len != 0 {
+				return nil, false
+			}
+			for ; len(v) > 0; v = v[IPv6len:] {
+				s.IPHint = append(s.IPHint, append(IP(nil), v[:IPv6len]...))
+			}
+		}
+	}
+	if s.NoDefaultALPN && len(s.ALPN) == 0 {
+		// No protocol left to use.
+		return nil, false
+	}
+	return s, true
+}
+
+// svcbTarget decodes the domain name at the start of b, the target of
+// an SVCB record, which is never compressed (RFC 9460, section 2.2).
+// It returns the name with a trailing dot and the rest of b.
+func svcbTarget(b []byte) (name string, rest []byte, ok bool) {
+	var buf []byte
+	for {
+		if len(b) == 0 {
+			return "", nil, false
+		}
+		n := int(b[0])
+		b = b[1:]
+		if n == 0 {
+			break
+		}
+		if n > 63 || n > len(b) || len(buf)+n+1 > 254 {
+			return "", nil, false
+		}
+		buf = append(buf, b[:n]...)
+		buf = append(buf, '.')
+		b = b[n:]
+	}
+	if len(buf) == 0 {
+		return ".", b, true
+	}
+	return string(buf), b, true
+}
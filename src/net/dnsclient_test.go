@@ -5,7 +5,11 @@
 package net
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func checkDistribution(t *testing.T, data []*SRV, margin float64) {
@@ -64,3 +68,160 @@ func testWeighting(t *testing.T, margin float64) {
 func TestWeighting(t *testing.T) {
 	testWeighting(t, 0.05)
 }
+
+// svcbData returns the data of an SVCB or HTTPS record.
+func svcbData(prio uint16, target string, params ...[]byte) []byte {
+	b := []byte{byte(prio >> 8), byte(prio)}
+	for _, l := range strings.Split(strings.TrimSuffix(target, "."), ".") {
+		if l != "" {
+			b = append(b, byte(len(l)))
+			b = append(b, l...)
+		}
+	}
+	b = append(b, 0)
+	for _, p := range params {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// svcParam returns the wire format of an SVCB parameter.
+func svcParam(key uint16, value []byte) []byte {
+	b := []byte{byte(key >> 8), byte(key), byte(len(value) >> 8), byte(len(value))}
+	return append(b, value...)
+}
+
+func TestNewSVCB(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		params [][]byte
+		want   *SVCB // nil if the record must be ignored
+	}{
+		{
+			name: "all",
+			params: [][]byte{
+				svcParam(svcParamMandatory, []byte{0, 1, 0, 3}),
+				svcParam(svcParamALPN, []byte("\x02h2\x08http/1.1")),
+				svcParam(svcParamPort, []byte{0x20, 0xfb}),
+				svcParam(svcParamIPv4Hint, []byte{192, 0, 2, 1, 192, 0, 2, 2}),
+				svcParam(svcParamECH, []byte{1, 2, 3}),
+				svcParam(svcParamIPv6Hint, ParseIP("2001:db8::1")),
+				svcParam(100, []byte("ignored")),
+			},
+			want: &SVCB{
+				Priority:  1,
+				Target:    "svc.example.",
+				ALPN:      []string{"h2", "http/1.1"},
+				Port:      8443,
+				IPHint:    []IP{IPv4(192, 0, 2, 1), IPv4(192, 0, 2, 2), ParseIP("2001:db8::1")},
+				ECHConfig: []byte{1, 2, 3},
+			},
+		},
+		{
+			name: "no-default-alpn",
+			params: [][]byte{
+				svcParam(svcParamALPN, []byte("\x02h3")),
+				svcParam(svcParamNoDefaultALPN, nil),
+			},
+			want: &SVCB{Priority: 1, Target: "svc.example.", ALPN: []string{"h3"}, NoDefaultALPN: true},
+		},
+		{
+			name:   "unknown mandatory key",
+			params: [][]byte{svcParam(svcParamMandatory, []byte{0, 100}), svcParam(100, nil)},
+		},
+		{
+			name:   "no-default-alpn without alpn",
+			params: [][]byte{svcParam(svcParamNoDefaultALPN, nil)},
+		},
+		{
+			name:   "truncated alpn",
+			params: [][]byte{svcParam(svcParamALPN, []byte("\x03h2"))},
+		},
+		{
+			name:   "empty alpn id",
+			params: [][]byte{svcParam(svcParamALPN, []byte("\x00"))},
+		},
+		{
+			name:   "short port",
+			params: [][]byte{svcParam(svcParamPort, []byte{1})},
+		},
+		{
+			name:   "bad ipv4hint",
+			params: [][]byte{svcParam(svcParamIPv4Hint, []byte{192, 0, 2})},
+		},
+		{
+			name:   "bad ipv6hint",
+			params: [][]byte{svcParam(svcParamIPv6Hint, []byte{192, 0, 2, 1})},
+		},
+		{
+			name:   "keys out of order",
+			params: [][]byte{svcParam(svcParamPort, []byte{0, 80}), svcParam(svcParamALPN, []byte("\x02h2"))},
+		},
+		{
+			name:   "duplicate key",
+			params: [][]byte{svcParam(svcParamPort, []byte{0, 80}), svcParam(svcParamPort, []byte{0, 81})},
+		},
+		{
+			name:   "truncated parameter",
+			params: [][]byte{svcParam(svcParamPort, []byte{0, 80})[:5]},
+		},
+	} {
+		got, ok := newSVCB(svcbData(1, "svc.example.", tt.params...))
+		if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, %v; want %+v", tt.name, got, ok, tt.want)
+		}
+	}
+
+	// Parameters of AliasMode records are ignored.
+	got, ok := newSVCB(svcbData(0, "svc.example.", svcParam(svcParamPort, []byte{1})))
+	if want := (&SVCB{Target: "svc.example."}); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("alias: got %+v, %v; want %+v", got, ok, want)
+	}
+
+	// The root target is returned as ".".
+	got, ok = newSVCB(svcbData(1, "."))
+	if want := (&SVCB{Priority: 1, Target: "."}); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("root: got %+v, %v; want %+v", got, ok, want)
+	}
+
+	// The target must not be compressed.
+	for _, b := range [][]byte{
+		nil,
+		{0, 1},
+		{0, 1, 0xC0, 12},
+		{0, 1, 3, 's', 'v'},
+	} {
+		if got, ok := newSVCB(b); ok {
+			t.Errorf("newSVCB(%v) = %+v; want it ignored", b, got)
+		}
+	}
+}
+
+func TestAnswerData(t *testing.T) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true})
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName("svc.example."), Type: dnsTypeHTTPS, Class: dnsmessage.ClassINET})
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := func(typ dnsmessage.Type, data string) []byte {
+		// The owner name is a pointer to the question.
+		b := []byte{0xC0, 12, byte(typ >> 8), byte(typ), 0, 1, 0, 0, 0, 60, 0, byte(len(data))}
+		return append(b, data...)
+	}
+	msg = append(msg, rr(dnsTypeHTTPS, "one")...)
+	msg = append(msg, rr(dnsmessage.TypeCNAME, "\x00")...)
+	msg = append(msg, rr(dnsTypeHTTPS, "two")...)
+	msg[7] = 3 // ANCOUNT
+
+	data, ok := answerData(msg, dnsTypeHTTPS)
+	if want := [][]byte{[]byte("one"), []byte("two")}; !ok || !reflect.DeepEqual(data, want) {
+		t.Errorf("answerData = %q, %v; want %q, true", data, ok, want)
+	}
+	for n := 0; n < len(msg); n++ {
+		if _, ok := answerData(msg[:n], dnsTypeHTTPS); ok {
+			t.Errorf("answerData of message truncated to %d bytes succeeded", n)
+		}
+	}
+}
@@ -38,6 +38,7 @@ var (
 	errServerMisbehaving         = errors.New("server misbehaving")
 	errInvalidDNSResponse        = errors.New("invalid DNS response")
 	errNoAnswerFromDNSServer     = errors.New("no answer from DNS server")
+	errNoDNSServers              = errors.New("no usable DNS servers")
 
 	// errServerTemporarilyMisbehaving is like errServerMisbehaving, except
 	// that when it gets translated to a DNSError, the IsTemporary field
@@ -45,6 +46,14 @@ var (
 	errServerTemporarilyMisbehaving = errors.New("server misbehaving")
 )
 
+// A dnsResponse is a DNS response being parsed. It keeps the message
+// next to the Parser so that records that package dnsmessage does not
+// know, such as SVCB, can be parsed from msg.
+type dnsResponse struct {
+	dnsmessage.Parser
+	msg []byte
+}
+
 func newRequest(q dnsmessage.Question) (id uint16, udpReq, tcpReq []byte, err error) {
 	id = uint16(randInt())
 	b := dnsmessage.NewBuilder(make([]byte, 2, 514), dnsmessage.Header{ID: id, RecursionDesired: true})
@@ -76,16 +85,16 @@ func checkResponse(reqID uint16, reqQues dnsmessage.Question, respHdr dnsmessage
 	return true
 }
 
-func dnsPacketRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte) (dnsmessage.Parser, dnsmessage.Header, error) {
+func dnsPacketRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte) (dnsResponse, dnsmessage.Header, error) {
 	if _, err := c.Write(b); err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, err
+		return dnsResponse{}, dnsmessage.Header{}, err
 	}
 
 	b = make([]byte, 512) // see RFC 1035
 	for {
 		n, err := c.Read(b)
 		if err != nil {
-			return dnsmessage.Parser{}, dnsmessage.Header{}, err
+			return dnsResponse{}, dnsmessage.Header{}, err
 		}
 		var p dnsmessage.Parser
 		// Ignore invalid responses as they may be malicious
@@ -99,18 +108,18 @@ func dnsPacketRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte)
 		if err != nil || !checkResponse(id, query, h, q) {
 			continue
 		}
-		return p, h, nil
+		return dnsResponse{p, b[:n]}, h, nil
 	}
 }
 
-func dnsStreamRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte) (dnsmessage.Parser, dnsmessage.Header, error) {
+func dnsStreamRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte) (dnsResponse, dnsmessage.Header, error) {
 	if _, err := c.Write(b); err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, err
+		return dnsResponse{}, dnsmessage.Header{}, err
 	}
 
 	b = make([]byte, 1280) // 1280 is a reasonable initial size for IP over Ethernet, see RFC 4035
 	if _, err := io.ReadFull(c, b[:2]); err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, err
+		return dnsResponse{}, dnsmessage.Header{}, err
 	}
 	l := int(b[0])<<8 | int(b[1])
 	if l > len(b) {
@@ -118,29 +127,29 @@ func dnsStreamRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte)
 	}
 	n, err := io.ReadFull(c, b[:l])
 	if err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, err
+		return dnsResponse{}, dnsmessage.Header{}, err
 	}
 	var p dnsmessage.Parser
 	h, err := p.Start(b[:n])
 	if err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, errCannotUnmarshalDNSMessage
+		return dnsResponse{}, dnsmessage.Header{}, errCannotUnmarshalDNSMessage
 	}
 	q, err := p.Question()
 	if err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, errCannotUnmarshalDNSMessage
+		return dnsResponse{}, dnsmessage.Header{}, errCannotUnmarshalDNSMessage
 	}
 	if !checkResponse(id, query, h, q) {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, errInvalidDNSResponse
+		return dnsResponse{}, dnsmessage.Header{}, errInvalidDNSResponse
 	}
-	return p, h, nil
+	return dnsResponse{p, b[:n]}, h, nil
 }
 
 // exchange sends a query on the connection and hopes for a response.
-func (r *Resolver) exchange(ctx context.Context, server string, q dnsmessage.Question, timeout time.Duration, useTCP bool) (dnsmessage.Parser, dnsmessage.Header, error) {
+func (r *Resolver) exchange(ctx context.Context, server string, q dnsmessage.Question, timeout time.Duration, useTCP bool) (dnsResponse, dnsmessage.Header, error) {
 	q.Class = dnsmessage.ClassINET
 	id, udpReq, tcpReq, err := newRequest(q)
 	if err != nil {
-		return dnsmessage.Parser{}, dnsmessage.Header{}, errCannotMarshalDNSMessage
+		return dnsResponse{}, dnsmessage.Header{}, errCannotMarshalDNSMessage
 	}
 	var networks []string
 	if useTCP {
@@ -154,12 +163,12 @@ func (r *Resolver) exchange(ctx context.Context, server string, q dnsmessage.Que
 
 		c, err := r.dial(ctx, network, server)
 		if err != nil {
-			return dnsmessage.Parser{}, dnsmessage.Header{}, err
+			return dnsResponse{}, dnsmessage.Header{}, err
 		}
 		if d, ok := ctx.Deadline(); ok && !d.IsZero() {
 			c.SetDeadline(d)
 		}
-		var p dnsmessage.Parser
+		var p dnsResponse
 		var h dnsmessage.Header
 		if _, ok := c.(PacketConn); ok {
 			p, h, err = dnsPacketRoundTrip(c, id, q, udpReq)
@@ -168,17 +177,17 @@ func (r *Resolver) exchange(ctx context.Context, server string, q dnsmessage.Que
 		}
 		c.Close()
 		if err != nil {
-			return dnsmessage.Parser{}, dnsmessage.Header{}, mapErr(err)
+			return dnsResponse{}, dnsmessage.Header{}, mapErr(err)
 		}
 		if err := p.SkipQuestion(); err != dnsmessage.ErrSectionDone {
-			return dnsmessage.Parser{}, dnsmessage.Header{}, errInvalidDNSResponse
+			return dnsResponse{}, dnsmessage.Header{}, errInvalidDNSResponse
 		}
 		if h.Truncated { // see RFC 5966
 			continue
 		}
 		return p, h, nil
 	}
-	return dnsmessage.Parser{}, dnsmessage.Header{}, errNoAnswerFromDNSServer
+	return dnsResponse{}, dnsmessage.Header{}, errNoAnswerFromDNSServer
 }
 
 // checkHeader performs basic sanity checks on the header.
@@ -233,14 +242,18 @@ func skipToAnswer(p *dnsmessage.Parser, qtype dnsmessage.Type) error {
 
 // Do a lookup for a single name, which must be rooted
 // (otherwise answer will not find the answers).
-func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string, qtype dnsmessage.Type) (dnsmessage.Parser, string, error) {
+func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string, qtype dnsmessage.Type) (dnsResponse, string, error) {
 	var lastErr error
 	serverOffset := cfg.serverOffset()
 	sLen := uint32(len(cfg.servers))
+	if sLen == 0 {
+		// Only possible with encrypted DNS servers given by the Resolver.
+		return dnsResponse{}, "", &DNSError{Err: errNoDNSServers.Error(), Name: name}
+	}
 
 	n, err := dnsmessage.NewName(name)
 	if err != nil {
-		return dnsmessage.Parser{}, "", errCannotMarshalDNSMessage
+		return dnsResponse{}, "", errCannotMarshalDNSMessage
 	}
 	q := dnsmessage.Question{
 		Name:  n,
@@ -252,7 +265,16 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 		for j := uint32(0); j < sLen; j++ {
 			server := cfg.servers[(serverOffset+j)%sLen]
 
-			p, h, err := r.exchange(ctx, server, q, cfg.timeout, cfg.useTCP)
+			var (
+				p   dnsResponse
+				h   dnsmessage.Header
+				err error
+			)
+			if cfg.encrypted != nil {
+				p, h, err = r.exchangeEncrypted(ctx, cfg.encrypted, server, q, cfg.timeout)
+			} else {
+				p, h, err = r.exchange(ctx, server, q, cfg.timeout, cfg.useTCP)
+			}
 			if err != nil {
 				dnsErr := &DNSError{
 					Err:    err.Error(),
@@ -271,7 +293,7 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 				continue
 			}
 
-			if err := checkHeader(&p, h); err != nil {
+			if err := checkHeader(&p.Parser, h); err != nil {
 				dnsErr := &DNSError{
 					Err:    err.Error(),
 					Name:   name,
@@ -291,7 +313,7 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 				continue
 			}
 
-			err = skipToAnswer(&p, qtype)
+			err = skipToAnswer(&p.Parser, qtype)
 			if err == nil {
 				return p, server, nil
 			}
@@ -309,7 +331,7 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 			}
 		}
 	}
-	return dnsmessage.Parser{}, "", lastErr
+	return dnsResponse{}, "", lastErr
 }
 
 // A resolverConfig represents a DNS stub resolver configuration.
@@ -387,21 +409,21 @@ func (conf *resolverConfig) releaseSema() {
 	<-conf.ch
 }
 
-func (r *Resolver) lookup(ctx context.Context, name string, qtype dnsmessage.Type) (dnsmessage.Parser, string, error) {
+func (r *Resolver) lookup(ctx context.Context, name string, qtype dnsmessage.Type) (dnsResponse, string, error) {
 	if !isDomainName(name) {
 		// We used to use "invalid domain name" as the error,
 		// but that is a detail of the specific lookup mechanism.
 		// Other lookups might allow broader name syntax
 		// (for example Multicast DNS allows UTF-8; see RFC 6762).
 		// For consistency with libc resolvers, report no such host.
-		return dnsmessage.Parser{}, "", &DNSError{Err: errNoSuchHost.Error(), Name: name, IsNotFound: true}
+		return dnsResponse{}, "", &DNSError{Err: errNoSuchHost.Error(), Name: name, IsNotFound: true}
 	}
 	resolvConf.tryUpdate("/etc/resolv.conf")
 	resolvConf.mu.RLock()
-	conf := resolvConf.dnsConfig
+	conf := r.withEncryptedDNS(resolvConf.dnsConfig)
 	resolvConf.mu.RUnlock()
 	var (
-		p      dnsmessage.Parser
+		p      dnsResponse
 		server string
 		err    error
 	)
@@ -425,7 +447,7 @@ func (r *Resolver) lookup(ctx context.Context, name string, qtype dnsmessage.Typ
 		// just one is misleading. See also golang.org/issue/6324.
 		err.Name = name
 	}
-	return dnsmessage.Parser{}, "", err
+	return dnsResponse{}, "", err
 }
 
 // avoidDNS reports whether this is a hostname for which we should not
@@ -575,10 +597,10 @@ func (r *Resolver) goLookupIPCNAMEOrder(ctx context.Context, name string, order
 	}
 	resolvConf.tryUpdate("/etc/resolv.conf")
 	resolvConf.mu.RLock()
-	conf := resolvConf.dnsConfig
+	conf := r.withEncryptedDNS(resolvConf.dnsConfig)
 	resolvConf.mu.RUnlock()
 	type result struct {
-		p      dnsmessage.Parser
+		p      dnsResponse
 		server string
 		error
 	}
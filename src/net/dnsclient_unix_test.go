@@ -1652,6 +1652,85 @@ func TestTXTRecordTwoStrings(t *testing.T) {
 	}
 }
 
+func TestLookupHTTPS(t *testing.T) {
+	// rr returns a resource record whose owner name
+	// is a pointer to the question.
+	rr := func(typ dnsmessage.Type, data []byte) []byte {
+		b := []byte{0xC0, 12, byte(typ >> 8), byte(typ), 0, 1, 0, 0, 0, 60, byte(len(data) >> 8), byte(len(data))}
+		return append(b, data...)
+	}
+	answers := map[dnsmessage.Type][][]byte{
+		dnsTypeHTTPS: {
+			rr(dnsTypeHTTPS, svcbData(2, "backup.example.", svcParam(svcParamPort, []byte{0x20, 0xfb}))),
+			rr(dnsTypeHTTPS, svcbData(1, ".")),
+			// Requires an unknown parameter, so must be ignored.
+			rr(dnsTypeHTTPS, svcbData(1, "ignored.example.",
+				svcParam(svcParamMandatory, []byte{0, 100}), svcParam(100, nil))),
+		},
+		dnsTypeSVCB: {
+			rr(dnsTypeSVCB, svcbData(0, "alias.example.")),
+		},
+	}
+	serve := func(c Conn) {
+		defer c.Close()
+		b := make([]byte, 514)
+		n, err := c.Read(b)
+		if err != nil || n < 2 {
+			t.Error("reading DNS query:", err)
+			return
+		}
+		var q dnsmessage.Message
+		if err := q.Unpack(b[2:n]); err != nil {
+			t.Error("invalid DNS query:", err)
+			return
+		}
+		// Package dnsmessage cannot build SVCB and HTTPS records,
+		// so they are appended to the packed message.
+		bld := dnsmessage.NewBuilder(make([]byte, 2, 514), dnsmessage.Header{ID: q.ID, Response: true, RecursionAvailable: true})
+		bld.StartQuestions()
+		bld.Question(q.Questions[0])
+		resp, err := bld.Finish()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		rrs := answers[q.Questions[0].Type]
+		for _, rr := range rrs {
+			resp = append(resp, rr...)
+		}
+		resp[2+7] = byte(len(rrs)) // ANCOUNT
+		l := len(resp) - 2
+		resp[0], resp[1] = byte(l>>8), byte(l)
+		c.Write(resp)
+	}
+	r := Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (Conn, error) {
+		c, s := Pipe()
+		go serve(s)
+		return c, nil
+	}}
+
+	got, err := r.LookupHTTPS(context.Background(), "www.example.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*SVCB{
+		{Priority: 1, Target: "."},
+		{Priority: 2, Target: "backup.example.", Port: 8443},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LookupHTTPS: got %+v, want %+v", got, want)
+	}
+
+	got, err = r.LookupSVCB(context.Background(), "_dns.example.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []*SVCB{{Priority: 0, Target: "alias.example."}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LookupSVCB: got %+v, want %+v", got, want)
+	}
+}
+
 // Issue 29644: support single-request resolv.conf option in pure Go resolver.
 // The A and AAAA queries will be sent sequentially, not in parallel.
 func TestSingleRequestLookup(t *testing.T) {
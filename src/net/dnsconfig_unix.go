@@ -34,6 +34,8 @@ type dnsConfig struct {
 	soffset       uint32        // used by serverOffset
 	singleRequest bool          // use sequential A and AAAA queries instead of parallel queries
 	useTCP        bool          // force usage of TCP for DNS resolutions
+	encrypted     *EncryptedDNS // encrypted transport to use, if any
+	encryptedOpt  *EncryptedDNS // encrypted DNS requested by options, used only if the Resolver can dial TLS
 }
 
 // See resolv.conf(5) on a Linux machine.
@@ -132,6 +134,16 @@ func dnsReadConfig(filename string) *dnsConfig {
 					// https://www.freebsd.org/cgi/man.cgi?query=resolv.conf&sektion=5&manpath=freebsd-release-ports
 					// https://man.openbsd.org/resolv.conf.5
 					conf.useTCP = true
+				case s == "dns-over-tls":
+					// Go-specific options for encrypted DNS.
+					// See the EncryptedDNS documentation.
+					conf.encryptedDNS().Protocol = "tls"
+				case s == "dns-over-https":
+					conf.encryptedDNS().Protocol = "https"
+				case hasPrefix(s, "tls-server-name:"):
+					conf.encryptedDNS().ServerName = s[len("tls-server-name:"):]
+				case hasPrefix(s, "doh-path:"):
+					conf.encryptedDNS().Path = s[len("doh-path:"):]
 				default:
 					conf.unknownOpt = true
 				}
@@ -153,9 +165,22 @@ func dnsReadConfig(filename string) *dnsConfig {
 	if len(conf.search) == 0 {
 		conf.search = dnsDefaultSearch()
 	}
+	if conf.encryptedOpt != nil && conf.encryptedOpt.Protocol == "" {
+		// Server name or path without a protocol.
+		conf.encryptedOpt = nil
+	}
 	return conf
 }
 
+// encryptedDNS returns the encrypted DNS options of c,
+// allocating them if needed.
+func (c *dnsConfig) encryptedDNS() *EncryptedDNS {
+	if c.encryptedOpt == nil {
+		c.encryptedOpt = new(EncryptedDNS)
+	}
+	return c.encryptedOpt
+}
+
 // serverOffset returns an offset that can be used to determine
 // indices of servers in c.servers when making queries.
 // When the rotate option is enabled, this offset increases.
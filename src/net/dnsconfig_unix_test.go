@@ -32,6 +32,21 @@ var dnsReadConfigTests = []struct {
 			unknownOpt: true, // the "options attempts 3" line
 		},
 	},
+	{
+		name: "testdata/encrypted-resolv.conf",
+		want: &dnsConfig{
+			servers:  []string{"8.8.8.8:53", "[2001:4860:4860::8888]:53"},
+			search:   []string{"domain.local."},
+			ndots:    1,
+			timeout:  5 * time.Second,
+			attempts: 2,
+			encryptedOpt: &EncryptedDNS{
+				Protocol:   "https",
+				ServerName: "dns.google",
+				Path:       "/resolve",
+			},
+		},
+	},
 	{
 		name: "testdata/domain-resolv.conf",
 		want: &dnsConfig{
@@ -0,0 +1,212 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// EncryptedDNS configures Go's built-in DNS resolver to send queries
+// over an encrypted transport instead of plain UDP and TCP: either
+// DNS over TLS (RFC 7858) or DNS over HTTPS (RFC 8484).
+//
+// Package net does not implement TLS itself. The Resolver's DialTLS
+// function is used to establish the TLS connections; if a Resolver has
+// an EncryptedDNS but no DialTLS, its lookups fail rather than fall back
+// to unencrypted DNS.
+//
+// Encrypted DNS can also be requested by options in /etc/resolv.conf,
+// which apply to its nameservers:
+//
+//	options dns-over-tls            # like Protocol "tls"
+//	options dns-over-https          # like Protocol "https"
+//	options tls-server-name:NAME    # like ServerName
+//	options doh-path:PATH           # like Path
+//
+// These options only take effect for Resolvers with a DialTLS function
+// and no EncryptedDNS of their own, such as the DefaultResolver once its
+// DialTLS is set. Other Resolvers ignore them and use plain DNS, or the
+// cgo-based resolver, as if the options were absent.
+//
+// Encrypted DNS is only supported by the Go resolver on Unix systems.
+// When it is in effect, the cgo-based resolver is not used. On other
+// systems, lookups by a Resolver with an EncryptedDNS return an error.
+type EncryptedDNS struct {
+	// Protocol is the transport to use:
+	// "tls" for DNS over TLS or "https" for DNS over HTTPS.
+	Protocol string
+
+	// Servers lists the addresses of the DNS servers to use, as
+	// literal IP addresses with an optional port, such as "192.0.2.1"
+	// or "[2001:db8::1]:8443". The default port is 853 for DNS over
+	// TLS and 443 for DNS over HTTPS.
+	// If Servers is empty, the nameservers listed in /etc/resolv.conf
+	// are used with the default port.
+	Servers []string
+
+	// ServerName is the host name passed to DialTLS to verify the
+	// servers' certificates; for DNS over HTTPS it is also sent as
+	// the Host header. If empty, the IP address of the server is used.
+	ServerName string
+
+	// Path is the path of the DNS over HTTPS endpoint on the servers.
+	// If empty, "/dns-query" is used.
+	Path string
+}
+
+var errEncryptedDNSUnsupported = errors.New("encrypted DNS not supported on this system")
+
+// checkEncryptedDNS returns an error for a lookup of name by r if r
+// asks for encrypted DNS and the resolver on this system can't use it.
+func (r *Resolver) checkEncryptedDNS(name string) error {
+	if encryptedDNSSupported || r == nil || r.EncryptedDNS == nil {
+		return nil
+	}
+	return &DNSError{Err: errEncryptedDNSUnsupported.Error(), Name: name}
+}
+
+// canDialTLS reports whether r can connect to encrypted DNS servers.
+func (r *Resolver) canDialTLS() bool {
+	if r == nil {
+		r = DefaultResolver
+	}
+	return r.DialTLS != nil
+}
+
+func (e *EncryptedDNS) defaultPort() string {
+	if e.Protocol == "https" {
+		return "443"
+	}
+	return "853"
+}
+
+func (e *EncryptedDNS) path() string {
+	if e.Path == "" {
+		return "/dns-query"
+	}
+	return e.Path
+}
+
+// serverName returns the TLS server name to use for server,
+// which is in host:port form.
+func (e *EncryptedDNS) serverName(server string) string {
+	if e.ServerName != "" {
+		return e.ServerName
+	}
+	host, _, _ := SplitHostPort(server)
+	return host
+}
+
+// servers returns the host:port addresses of the encrypted DNS servers
+// to use, given the nameservers of the system configuration.
+// Entries that are not IP addresses are dropped, since looking them up
+// would require DNS.
+func (e *EncryptedDNS) servers(nameservers []string) []string {
+	port := e.defaultPort()
+	var servers []string
+	if len(e.Servers) > 0 {
+		for _, s := range e.Servers {
+			host, p, err := SplitHostPort(s)
+			if err != nil {
+				host, p = s, port
+			}
+			if ip, _ := parseIPZone(host); ip != nil {
+				servers = append(servers, JoinHostPort(host, p))
+			}
+		}
+		return servers
+	}
+	for _, s := range nameservers {
+		if host, _, err := SplitHostPort(s); err == nil {
+			servers = append(servers, JoinHostPort(host, port))
+		}
+	}
+	return servers
+}
+
+// dnsIdleTimeout is how long an idle connection to an
+// encrypted DNS server is kept open for reuse.
+const dnsIdleTimeout = 10 * time.Second
+
+// maxIdleDNSConns is the maximum number of idle connections kept per
+// encrypted DNS server, enough for the parallel A and AAAA queries of
+// a host lookup.
+const maxIdleDNSConns = 2
+
+// A dnsConnCache holds idle connections to encrypted DNS servers,
+// which are reused as recommended by RFC 7858, section 3.4.
+type dnsConnCache struct {
+	mu   sync.Mutex
+	idle map[dnsConnKey][]*idleDNSConn
+}
+
+type dnsConnKey struct {
+	protocol, server, serverName string
+}
+
+type idleDNSConn struct {
+	c Conn
+	t *time.Timer // closes c when it has been idle too long
+}
+
+// get returns an idle connection for key, or nil if there is none.
+func (cc *dnsConnCache) get(key dnsConnKey) Conn {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	for {
+		conns := cc.idle[key]
+		if len(conns) == 0 {
+			return nil
+		}
+		// Use the most recently used connection.
+		ic := conns[len(conns)-1]
+		cc.remove(key, ic)
+		if ic.t.Stop() {
+			return ic.c
+		}
+		// The timer fired and is closing the connection.
+	}
+}
+
+// put adds c to the idle connections for key,
+// or closes it if there are enough of them.
+func (cc *dnsConnCache) put(key dnsConnKey, c Conn) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if len(cc.idle[key]) >= maxIdleDNSConns {
+		c.Close()
+		return
+	}
+	if cc.idle == nil {
+		cc.idle = make(map[dnsConnKey][]*idleDNSConn)
+	}
+	ic := &idleDNSConn{c: c}
+	ic.t = time.AfterFunc(dnsIdleTimeout, func() {
+		cc.mu.Lock()
+		cc.remove(key, ic)
+		cc.mu.Unlock()
+		c.Close()
+	})
+	cc.idle[key] = append(cc.idle[key], ic)
+}
+
+// remove removes ic from the idle connections for key.
+// cc.mu must be held.
+func (cc *dnsConnCache) remove(key dnsConnKey, ic *idleDNSConn) {
+	conns := cc.idle[key]
+	for i, c := range conns {
+		if c == ic {
+			conns = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(cc.idle, key)
+	} else {
+		cc.idle[key] = conns
+	}
+}
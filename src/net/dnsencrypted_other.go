@@ -0,0 +1,11 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build js,wasm plan9 windows
+
+package net
+
+// encryptedDNSSupported reports whether lookups can use EncryptedDNS.
+// These systems do not use the Go DNS resolver.
+const encryptedDNSSupported = false
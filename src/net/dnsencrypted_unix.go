@@ -0,0 +1,407 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+// Encrypted DNS transports: DNS over TLS (RFC 7858)
+// and DNS over HTTPS (RFC 8484).
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/bytealg"
+	"io"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	errNoDialTLS            = errors.New("encrypted DNS configured but Resolver.DialTLS is nil")
+	errInvalidDNSProtocol   = errors.New("unsupported encrypted DNS protocol")
+	errInvalidHTTPSResponse = errors.New("invalid DNS over HTTPS response")
+)
+
+// encryptedDNSSupported reports whether lookups can use EncryptedDNS.
+const encryptedDNSSupported = true
+
+// maxDNSMessage is the maximum size of a DNS message
+// sent over a stream, see RFC 1035, section 4.2.2.
+const maxDNSMessage = 65535
+
+// withEncryptedDNS returns the DNS configuration to use for lookups
+// by r. That is cfg with the Resolver's EncryptedDNS applied or, if it
+// has none, with the encrypted DNS options of cfg applied, provided r
+// can dial TLS. Otherwise it is cfg, and lookups use plain DNS.
+func (r *Resolver) withEncryptedDNS(cfg *dnsConfig) *dnsConfig {
+	var e *EncryptedDNS
+	switch {
+	case r != nil && r.EncryptedDNS != nil:
+		e = r.EncryptedDNS
+	case cfg.encryptedOpt != nil && r.canDialTLS():
+		e = cfg.encryptedOpt
+	default:
+		return cfg
+	}
+	return &dnsConfig{
+		servers:       e.servers(cfg.servers),
+		search:        cfg.search,
+		ndots:         cfg.ndots,
+		timeout:       cfg.timeout,
+		attempts:      cfg.attempts,
+		rotate:        cfg.rotate,
+		singleRequest: cfg.singleRequest,
+		encrypted:     e,
+	}
+}
+
+// exchangeEncrypted sends a query to an encrypted DNS server
+// and waits for a response.
+func (r *Resolver) exchangeEncrypted(ctx context.Context, e *EncryptedDNS, server string, q dnsmessage.Question, timeout time.Duration) (dnsResponse, dnsmessage.Header, error) {
+	if e.Protocol != "tls" && e.Protocol != "https" {
+		return dnsResponse{}, dnsmessage.Header{}, errInvalidDNSProtocol
+	}
+	if r == nil {
+		r = DefaultResolver
+	}
+	if r.DialTLS == nil {
+		return dnsResponse{}, dnsmessage.Header{}, errNoDialTLS
+	}
+	q.Class = dnsmessage.ClassINET
+	id, udpReq, tcpReq, err := newRequest(q)
+	if err != nil {
+		return dnsResponse{}, dnsmessage.Header{}, errCannotMarshalDNSMessage
+	}
+	if e.Protocol == "https" {
+		// DNS over HTTPS clients should use an ID of 0
+		// to make responses cacheable (RFC 8484, section 4.1).
+		id = 0
+		udpReq[0], udpReq[1] = 0, 0
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(timeout))
+	defer cancel()
+
+	key := dnsConnKey{protocol: e.Protocol, server: server, serverName: e.serverName(server)}
+	for {
+		// Prefer an idle connection. The server may have closed it
+		// in the meantime, so if the exchange fails, retry on a new
+		// connection.
+		c := r.dnsConns.get(key)
+		reused := c != nil
+		if !reused {
+			c, err = r.DialTLS(ctx, "tcp", server, key.serverName)
+			if err != nil {
+				return dnsResponse{}, dnsmessage.Header{}, mapErr(err)
+			}
+		}
+		if d, ok := ctx.Deadline(); ok && !d.IsZero() {
+			c.SetDeadline(d)
+		}
+		var (
+			p         dnsResponse
+			h         dnsmessage.Header
+			keepAlive = true
+		)
+		if e.Protocol == "tls" {
+			p, h, err = dnsStreamRoundTrip(c, id, q, tcpReq)
+		} else {
+			host := key.serverName
+			if bytealg.IndexByteString(host, ':') >= 0 {
+				host = "[" + host + "]" // IPv6 literal
+			}
+			p, h, keepAlive, err = dnsHTTPSRoundTrip(c, host, e.path(), id, q, udpReq)
+		}
+		if err != nil {
+			c.Close()
+			if reused && ctx.Err() == nil {
+				continue
+			}
+			return dnsResponse{}, dnsmessage.Header{}, mapErr(err)
+		}
+		if keepAlive {
+			c.SetDeadline(time.Time{})
+			r.dnsConns.put(key, c)
+		} else {
+			c.Close()
+		}
+		if err := p.SkipQuestion(); err != dnsmessage.ErrSectionDone {
+			return dnsResponse{}, dnsmessage.Header{}, errInvalidDNSResponse
+		}
+		return p, h, nil
+	}
+}
+
+// dnsHTTPSRoundTrip sends the DNS message msg to the server as an HTTP/1.1
+// POST request to path on c, as described in RFC 8484, and reads the response.
+// keepAlive reports whether c may be used for further requests.
+func dnsHTTPSRoundTrip(c Conn, host, path string, id uint16, query dnsmessage.Question, msg []byte) (_ dnsResponse, _ dnsmessage.Header, keepAlive bool, err error) {
+	req := make([]byte, 0, 256+len(msg))
+	req = append(req, "POST "+path+" HTTP/1.1\r\n"...)
+	req = append(req, "Host: "+host+"\r\n"...)
+	req = append(req, "Content-Type: application/dns-message\r\n"...)
+	req = append(req, "Accept: application/dns-message\r\n"...)
+	req = append(req, "Content-Length: "+itoa(len(msg))+"\r\n\r\n"...)
+	req = append(req, msg...)
+	if _, err := c.Write(req); err != nil {
+		return dnsResponse{}, dnsmessage.Header{}, false, err
+	}
+
+	b, keepAlive, err := readDoHResponse(c)
+	if err != nil {
+		return dnsResponse{}, dnsmessage.Header{}, false, err
+	}
+	var p dnsmessage.Parser
+	h, err := p.Start(b)
+	if err != nil {
+		return dnsResponse{}, dnsmessage.Header{}, false, errCannotUnmarshalDNSMessage
+	}
+	q, err := p.Question()
+	if err != nil {
+		return dnsResponse{}, dnsmessage.Header{}, false, errCannotUnmarshalDNSMessage
+	}
+	if !checkResponse(id, query, h, q) {
+		return dnsResponse{}, dnsmessage.Header{}, false, errInvalidDNSResponse
+	}
+	return dnsResponse{p, b}, h, keepAlive, nil
+}
+
+// maxDoHHeader is the maximum size of the status line and header
+// fields of a DNS over HTTPS response, and of a chunk size line or
+// the trailer of a chunked body.
+const maxDoHHeader = 8 << 10
+
+// readDoHResponse reads an HTTP/1.x response to a DNS over HTTPS query
+// from r and returns its body. It implements only what such a response
+// needs: interim 1xx responses are skipped, the final status must be
+// 200, the Content-Type must be application/dns-message, and the body,
+// delimited by a Content-Length, chunked transfer coding or the end of
+// the connection, must be at most maxDNSMessage bytes long.
+// keepAlive reports whether the connection may carry further requests.
+func readDoHResponse(r io.Reader) (body []byte, keepAlive bool, err error) {
+	d := &dohReader{r: r, buf: make([]byte, 0, 1024)}
+
+	// HTTP/1.1 200 OK
+	var status string
+	for {
+		line, err := d.line(maxDoHHeader)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(line) < 12 || string(line[:7]) != "HTTP/1." || (line[7] != '0' && line[7] != '1') || line[8] != ' ' || len(line) > 12 && line[12] != ' ' {
+			return nil, false, errInvalidHTTPSResponse
+		}
+		keepAlive = line[7] == '1'
+		status = string(line[9:12])
+		if status[0] != '1' || status == "101" {
+			break
+		}
+		// An interim response has a header but no body.
+		if _, err := d.header(); err != nil {
+			return nil, false, err
+		}
+	}
+	if status != "200" {
+		return nil, false, errors.New("DNS over HTTPS server returned HTTP status " + status)
+	}
+
+	header, err := d.header()
+	if err != nil {
+		return nil, false, err
+	}
+	var (
+		contentType string
+		length      = -1
+		chunked     bool
+	)
+	for _, line := range header {
+		i := bytealg.IndexByte(line, ':')
+		if i <= 0 {
+			return nil, false, errInvalidHTTPSResponse
+		}
+		lowerASCIIBytes(line)
+		name, value := string(line[:i]), string(trimSpace(line[i+1:]))
+		switch name {
+		case "content-type":
+			if i := bytealg.IndexByteString(value, ';'); i >= 0 {
+				value = string(trimSpace([]byte(value[:i])))
+			}
+			contentType = value
+		case "content-length":
+			n, i, ok := dtoi(value)
+			if !ok || i != len(value) || n > maxDNSMessage || length >= 0 && n != length {
+				return nil, false, errInvalidHTTPSResponse
+			}
+			length = n
+		case "transfer-encoding":
+			if value != "chunked" || chunked {
+				return nil, false, errInvalidHTTPSResponse
+			}
+			chunked = true
+		case "connection":
+			if value == "close" {
+				keepAlive = false
+			}
+		}
+	}
+	if contentType != "application/dns-message" || chunked && length >= 0 {
+		return nil, false, errInvalidHTTPSResponse
+	}
+
+	switch {
+	case chunked:
+		body, err = d.chunked()
+	case length >= 0:
+		body, err = d.next(length)
+	default:
+		// The body ends with the connection.
+		body, err = d.rest()
+		keepAlive = false
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(d.buf) > 0 {
+		// Unexpected data after the response.
+		keepAlive = false
+	}
+	return body, keepAlive, nil
+}
+
+// A dohReader reads the parts of an HTTP/1.x response from r.
+type dohReader struct {
+	r   io.Reader
+	buf []byte // read from r but not yet consumed
+}
+
+// fill reads more data from d.r into d.buf.
+func (d *dohReader) fill() error {
+	if len(d.buf) == cap(d.buf) {
+		nb := make([]byte, len(d.buf), 2*cap(d.buf)+512)
+		copy(nb, d.buf)
+		d.buf = nb
+	}
+	n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if n > 0 {
+		return nil
+	}
+	return err
+}
+
+// line returns the next line, without its CRLF.
+// The line may be at most max bytes long.
+func (d *dohReader) line(max int) ([]byte, error) {
+	from := 0
+	for {
+		for i := from; i+1 < len(d.buf); i++ {
+			if d.buf[i] == '\r' && d.buf[i+1] == '\n' {
+				if i > max {
+					return nil, errInvalidHTTPSResponse
+				}
+				line := d.buf[:i]
+				d.buf = d.buf[i+2:]
+				return line, nil
+			}
+		}
+		if len(d.buf) > max {
+			return nil, errInvalidHTTPSResponse
+		}
+		if from = len(d.buf) - 1; from < 0 {
+			from = 0
+		}
+		if err := d.fill(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+}
+
+// header returns the lines of a header block, or of the trailer of a
+// chunked body, up to the empty line that ends it. Together, the lines
+// may be at most maxDoHHeader bytes long.
+func (d *dohReader) header() ([][]byte, error) {
+	var lines [][]byte
+	for size := 0; ; {
+		line, err := d.line(maxDoHHeader - size)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			return lines, nil
+		}
+		lines = append(lines, line)
+		size += len(line) + 2
+	}
+}
+
+// next returns the next n bytes.
+func (d *dohReader) next(n int) ([]byte, error) {
+	for len(d.buf) < n {
+		if err := d.fill(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	b := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return b, nil
+}
+
+// chunked returns a body sent with the chunked transfer coding.
+func (d *dohReader) chunked() ([]byte, error) {
+	var body []byte
+	for {
+		line, err := d.line(maxDoHHeader)
+		if err != nil {
+			return nil, err
+		}
+		if i := bytealg.IndexByte(line, ';'); i >= 0 {
+			line = line[:i] // chunk extensions
+		}
+		size := string(trimSpace(line))
+		n, i, ok := xtoi(size)
+		if !ok || i != len(size) || len(body)+n > maxDNSMessage {
+			return nil, errInvalidHTTPSResponse
+		}
+		if n == 0 {
+			if _, err := d.header(); err != nil {
+				return nil, err
+			}
+			return body, nil
+		}
+		chunk, err := d.next(n + 2)
+		if err != nil {
+			return nil, err
+		}
+		if string(chunk[n:]) != "\r\n" {
+			return nil, errInvalidHTTPSResponse
+		}
+		body = append(body, chunk[:n]...)
+	}
+}
+
+// rest returns everything up to the end of the input.
+func (d *dohReader) rest() ([]byte, error) {
+	for len(d.buf) <= maxDNSMessage {
+		if err := d.fill(); err != nil {
+			if err == io.EOF {
+				b := d.buf
+				d.buf = nil
+				return b, nil
+			}
+			return nil, err
+		}
+	}
+	return nil, errInvalidHTTPSResponse
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF if err is io.EOF and err
+// otherwise.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
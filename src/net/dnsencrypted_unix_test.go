@@ -0,0 +1,503 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package net
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// An encryptedDNSServer is a stub DNS over TLS or DNS over HTTPS
+// server. It speaks the protocol without TLS; the tests' DialTLS
+// functions connect to it directly.
+type encryptedDNSServer struct {
+	t        *testing.T
+	ln       Listener
+	protocol string // "tls" or "https"
+	close    bool   // close HTTP connections after each response
+
+	conns   int32 // number of accepted connections
+	queries int32 // number of answered queries
+	wg      sync.WaitGroup
+}
+
+func newEncryptedDNSServer(t *testing.T, protocol string) *encryptedDNSServer {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &encryptedDNSServer{t: t, ln: ln, protocol: protocol}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&s.conns, 1)
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer c.Close()
+				s.serve(c)
+			}()
+		}
+	}()
+	return s
+}
+
+func (s *encryptedDNSServer) stop() {
+	s.ln.Close()
+}
+
+func (s *encryptedDNSServer) serve(c Conn) {
+	br := bufio.NewReader(c)
+	for {
+		var req []byte
+		if s.protocol == "tls" {
+			var l [2]byte
+			if _, err := io.ReadFull(br, l[:]); err != nil {
+				return
+			}
+			req = make([]byte, int(l[0])<<8|int(l[1]))
+			if _, err := io.ReadFull(br, req); err != nil {
+				return
+			}
+		} else {
+			var ok bool
+			if req, ok = s.readHTTPRequest(br); !ok {
+				return
+			}
+		}
+
+		resp := s.answer(req)
+		if resp == nil {
+			return
+		}
+		atomic.AddInt32(&s.queries, 1)
+
+		var out []byte
+		switch {
+		case s.protocol == "tls":
+			out = append([]byte{byte(len(resp) >> 8), byte(len(resp))}, resp...)
+		default:
+			out = []byte("HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\n")
+			if s.close {
+				out = append(out, "Connection: close\r\n"...)
+			}
+			out = append(out, "Content-Length: "+strconv.Itoa(len(resp))+"\r\n\r\n"...)
+			out = append(out, resp...)
+		}
+		if _, err := c.Write(out); err != nil {
+			return
+		}
+		if s.close {
+			return
+		}
+	}
+}
+
+// readHTTPRequest reads a DNS over HTTPS POST request and returns its body.
+func (s *encryptedDNSServer) readHTTPRequest(br *bufio.Reader) ([]byte, bool) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, false
+	}
+	if line != "POST /dns-query HTTP/1.1\r\n" {
+		s.t.Errorf("unexpected request line %q", line)
+		return nil, false
+	}
+	length := -1
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, false
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			s.t.Errorf("malformed header line %q", line)
+			return nil, false
+		}
+		name, value := strings.ToLower(line[:i]), strings.TrimSpace(line[i+1:])
+		switch name {
+		case "content-length":
+			length, _ = strconv.Atoi(value)
+		case "content-type", "accept":
+			if value != "application/dns-message" {
+				s.t.Errorf("%s = %q, want application/dns-message", name, value)
+			}
+		case "host":
+			if value != "dns.example" {
+				s.t.Errorf("Host = %q, want dns.example", value)
+			}
+		}
+	}
+	if length < 0 {
+		s.t.Error("missing Content-Length")
+		return nil, false
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(br, body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// answer returns the response to the DNS query req: TestAddr for
+// A queries and an empty answer for any other type.
+func (s *encryptedDNSServer) answer(req []byte) []byte {
+	var q dnsmessage.Message
+	if err := q.Unpack(req); err != nil || len(q.Questions) != 1 {
+		s.t.Errorf("invalid query: %v", err)
+		return nil
+	}
+	if s.protocol == "https" && q.Header.ID != 0 {
+		s.t.Errorf("DNS over HTTPS query has ID %d, want 0", q.Header.ID)
+	}
+	r := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 q.Header.ID,
+			Response:           true,
+			RecursionAvailable: true,
+		},
+		Questions: q.Questions,
+	}
+	if q.Questions[0].Type == dnsmessage.TypeA {
+		r.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{
+				Name:   q.Questions[0].Name,
+				Type:   dnsmessage.TypeA,
+				Class:  dnsmessage.ClassINET,
+				Length: 4,
+			},
+			Body: &dnsmessage.AResource{A: TestAddr},
+		}}
+	}
+	b, err := r.Pack()
+	if err != nil {
+		s.t.Error(err)
+		return nil
+	}
+	return b
+}
+
+func (s *encryptedDNSServer) resolver() *Resolver {
+	return &Resolver{
+		EncryptedDNS: &EncryptedDNS{
+			Protocol:   s.protocol,
+			Servers:    []string{s.ln.Addr().String()},
+			ServerName: "dns.example",
+		},
+		DialTLS: func(ctx context.Context, network, address, serverName string) (Conn, error) {
+			if address != s.ln.Addr().String() {
+				s.t.Errorf("DialTLS address = %q, want %q", address, s.ln.Addr())
+			}
+			if serverName != "dns.example" {
+				s.t.Errorf("DialTLS server name = %q, want dns.example", serverName)
+			}
+			var d Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+func TestEncryptedDNS(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		protocol string
+		close    bool
+		maxConns int32
+	}{
+		// Each lookup sends an A and an AAAA query, maybe in parallel.
+		// Connections are reused, unless the server closes them.
+		{name: "TLS", protocol: "tls", maxConns: 2},
+		{name: "HTTPS", protocol: "https", maxConns: 2},
+		{name: "HTTPSClose", protocol: "https", close: true, maxConns: 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newEncryptedDNSServer(t, tt.protocol)
+			s.close = tt.close
+			defer s.wg.Wait()
+			defer s.stop()
+
+			r := s.resolver()
+			defer r.dnsConns.closeIdle()
+			for i := 0; i < 3; i++ {
+				addrs, err := r.LookupIPAddr(context.Background(), "encrypted.test.")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(addrs) != 1 || !addrs[0].IP.Equal(IPv4(192, 0, 2, 1)) {
+					t.Fatalf("got %v, want [192.0.2.1]", addrs)
+				}
+			}
+			if q := atomic.LoadInt32(&s.queries); q != 6 {
+				t.Errorf("server answered %d queries, want 6", q)
+			}
+			if n := atomic.LoadInt32(&s.conns); n > tt.maxConns {
+				t.Errorf("server accepted %d connections, want at most %d", n, tt.maxConns)
+			}
+		})
+	}
+}
+
+func TestEncryptedDNSWithoutDialTLS(t *testing.T) {
+	s := newEncryptedDNSServer(t, "tls")
+	defer s.wg.Wait()
+	defer s.stop()
+
+	r := s.resolver()
+	r.DialTLS = nil
+	_, err := r.LookupIPAddr(context.Background(), "encrypted.test.")
+	if err == nil || !strings.Contains(err.Error(), errNoDialTLS.Error()) {
+		t.Errorf("got %v, want error containing %q", err, errNoDialTLS)
+	}
+	if n := atomic.LoadInt32(&s.conns); n != 0 {
+		t.Errorf("server accepted %d connections, want 0", n)
+	}
+}
+
+func TestEncryptedDNSResolvConfOptions(t *testing.T) {
+	cfg := &dnsConfig{
+		servers:      []string{"192.0.2.53:53"},
+		encryptedOpt: &EncryptedDNS{Protocol: "tls", ServerName: "dns.example"},
+	}
+	dialTLS := func(ctx context.Context, network, address, serverName string) (Conn, error) {
+		panic("unreachable")
+	}
+
+	// Without DialTLS, the options are ignored and plain DNS is used.
+	if got := (&Resolver{}).withEncryptedDNS(cfg); got != cfg || got.encrypted != nil {
+		t.Errorf("without DialTLS: got servers %v, encrypted %v; want plain DNS config", got.servers, got.encrypted)
+	}
+
+	// With DialTLS, the options apply to the nameservers.
+	got := (&Resolver{DialTLS: dialTLS}).withEncryptedDNS(cfg)
+	if got.encrypted != cfg.encryptedOpt || !reflect.DeepEqual(got.servers, []string{"192.0.2.53:853"}) {
+		t.Errorf("with DialTLS: got servers %v, encrypted %v; want [192.0.2.53:853], %v", got.servers, got.encrypted, cfg.encryptedOpt)
+	}
+
+	// The Resolver's EncryptedDNS overrides the options.
+	e := &EncryptedDNS{Protocol: "https", Servers: []string{"192.0.2.1"}}
+	got = (&Resolver{EncryptedDNS: e, DialTLS: dialTLS}).withEncryptedDNS(cfg)
+	if got.encrypted != e || !reflect.DeepEqual(got.servers, []string{"192.0.2.1:443"}) {
+		t.Errorf("with EncryptedDNS: got servers %v, encrypted %v; want [192.0.2.1:443], %v", got.servers, got.encrypted, e)
+	}
+}
+
+var readDoHResponseTests = []struct {
+	name      string
+	resp      string
+	body      string
+	keepAlive bool
+	err       bool
+}{
+	{
+		name:      "ok",
+		resp:      "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		body:      "abc",
+		keepAlive: true,
+	},
+	{
+		name: "parameters and case",
+		resp: "HTTP/1.1 200 OK\r\nCONTENT-TYPE: Application/DNS-Message; charset=x\r\ncontent-length:  3 \r\n\r\nabc",
+		body: "abc", keepAlive: true,
+	},
+	{
+		name: "HTTP/1.0",
+		resp: "HTTP/1.0 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		body: "abc",
+	},
+	{
+		name: "connection close",
+		resp: "HTTP/1.1 200 OK\r\nConnection: close\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		body: "abc",
+	},
+	{
+		name: "trailing data",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabcdef",
+		body: "abc",
+	},
+	{
+		name: "status",
+		resp: "HTTP/1.1 404 Not Found\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		err:  true,
+	},
+	{
+		name: "malformed status line",
+		resp: "HTTP/1.1 2000 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		err:  true,
+	},
+	{
+		name: "content type",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: 3\r\n\r\nabc",
+		err:  true,
+	},
+	{
+		name: "missing content type",
+		resp: "HTTP/1.1 200 OK\r\nContent-Length: 3\r\n\r\nabc",
+		err:  true,
+	},
+	{
+		name: "missing content length",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\n\r\nabc",
+		body: "abc",
+	},
+	{
+		name: "missing content length, empty body",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\n\r\n",
+		body: "",
+	},
+	{
+		name: "missing content length, body too large",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\n\r\n" + strings.Repeat("x", maxDNSMessage+1),
+		err:  true,
+	},
+	{
+		name: "conflicting content lengths",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\nContent-Length: 4\r\n\r\nabcd",
+		err:  true,
+	},
+	{
+		name: "content length too large",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 65536\r\n\r\n",
+		err:  true,
+	},
+	{
+		name:      "chunked",
+		resp:      "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+		body:      "abc",
+		keepAlive: true,
+	},
+	{
+		name:      "chunked, several chunks with extensions and trailer",
+		resp:      "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: Chunked\r\n\r\n2;x=y\r\nab\r\nA\r\ncdefghijkl\r\n0\r\nX-Trailer: z\r\n\r\n",
+		body:      "abcdefghijkl",
+		keepAlive: true,
+	},
+	{
+		name: "chunked, trailing data",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\nHTTP",
+		body: "abc",
+	},
+	{
+		name: "chunked, malformed size",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n3x\r\nabc\r\n0\r\n\r\n",
+		err:  true,
+	},
+	{
+		name: "chunked, missing CRLF after chunk",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabcd\r\n0\r\n\r\n",
+		err:  true,
+	},
+	{
+		name: "chunked, body too large",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n8000\r\n" + strings.Repeat("x", 0x8000) + "\r\n8000\r\n" + strings.Repeat("x", 0x8000) + "\r\n0\r\n\r\n",
+		err:  true,
+	},
+	{
+		name: "chunked, truncated",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n",
+		err:  true,
+	},
+	{
+		name: "chunked and content length",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\nContent-Length: 3\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+		err:  true,
+	},
+	{
+		name: "other transfer coding",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: gzip, chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+		err:  true,
+	},
+	{
+		name:      "interim responses",
+		resp:      "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 103 Early Hints\r\nLink: </x>\r\n\r\nHTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		body:      "abc",
+		keepAlive: true,
+	},
+	{
+		name: "switching protocols",
+		resp: "HTTP/1.1 101 Switching Protocols\r\nUpgrade: x\r\n\r\n",
+		err:  true,
+	},
+	{
+		name: "truncated interim response",
+		resp: "HTTP/1.1 100 Continue\r\n",
+		err:  true,
+	},
+	{
+		name: "malformed header field",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		err:  true,
+	},
+	{
+		name: "header too large",
+		resp: "HTTP/1.1 200 OK\r\nX: " + strings.Repeat("x", maxDoHHeader) + "\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nabc",
+		err:  true,
+	},
+	{
+		name: "truncated header",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\n",
+		err:  true,
+	},
+	{
+		name: "truncated body",
+		resp: "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nContent-Length: 3\r\n\r\nab",
+		err:  true,
+	},
+}
+
+func TestReadDoHResponse(t *testing.T) {
+	for _, tt := range readDoHResponseTests {
+		body, keepAlive, err := readDoHResponse(strings.NewReader(tt.resp))
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: readDoHResponse succeeded, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil || string(body) != tt.body || keepAlive != tt.keepAlive {
+			t.Errorf("%s: readDoHResponse = %q, %v, %v; want %q, %v, nil", tt.name, body, keepAlive, err, tt.body, tt.keepAlive)
+		}
+
+		// The response may arrive in pieces.
+		body, _, err = readDoHResponse(iotest.OneByteReader(strings.NewReader(tt.resp)))
+		if err != nil || string(body) != tt.body {
+			t.Errorf("%s: readDoHResponse one byte at a time = %q, %v; want %q, nil", tt.name, body, err, tt.body)
+		}
+	}
+}
+
+// closeIdle closes all idle connections.
+func (cc *dnsConnCache) closeIdle() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	for key, conns := range cc.idle {
+		for _, ic := range conns {
+			if ic.t.Stop() {
+				ic.c.Close()
+			}
+		}
+		delete(cc.idle, key)
+	}
+}
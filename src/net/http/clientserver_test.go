@@ -439,6 +439,19 @@ func TestH12_AutoGzip(t *testing.T) {
 	}.run(t)
 }
 
+// The HTTP/2 transport does not support zstd yet: it only asks for
+// gzip, even with EnableZstd set.
+func TestTransportZstdHTTP2(t *testing.T) {
+	defer afterTest(t)
+	cst := newClientServerTest(t, h2Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Header.Get("Accept-Encoding"))
+	}), func(tr *Transport) { tr.EnableZstd = true })
+	defer cst.close()
+	if got := cst.getURL(cst.ts.URL); got != "gzip" {
+		t.Errorf("Accept-Encoding over HTTP/2 = %q; want gzip", got)
+	}
+}
+
 func TestH12_AutoGzip_Disabled(t *testing.T) {
 	h12Compare{
 		Opts: []interface{}{
@@ -81,6 +81,10 @@ var writeSetCookiesTests = []struct {
 		&Cookie{Name: "cookie-15", Value: "samesite-none", SameSite: SameSiteNoneMode},
 		"cookie-15=samesite-none; SameSite=None",
 	},
+	{
+		&Cookie{Name: "cookie-16", Value: "partitioned", Secure: true, Partitioned: true},
+		"cookie-16=partitioned; Secure; Partitioned",
+	},
 	// The "special" cookies have values containing commas or spaces which
 	// are disallowed by RFC 6265 but are common in the wild.
 	{
@@ -318,6 +322,16 @@ var readSetCookiesTests = []struct {
 			Raw:      "samesitenone=foo; SameSite=None",
 		}},
 	},
+	{
+		Header{"Set-Cookie": {"partitioned=foo; Secure; Partitioned"}},
+		[]*Cookie{{
+			Name:        "partitioned",
+			Value:       "foo",
+			Secure:      true,
+			Partitioned: true,
+			Raw:         "partitioned=foo; Secure; Partitioned",
+		}},
+	},
 	// Make sure we can properly read back the Set-Cookie headers we create
 	// for values containing spaces or commas:
 	{
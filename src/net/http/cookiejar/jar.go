@@ -54,11 +54,17 @@ type Options struct {
 	// secure: it means that the HTTP server for foo.co.uk can set a cookie
 	// for bar.co.uk.
 	PublicSuffixList PublicSuffixList
+
+	// Storage, if non-nil, holds the cookies of the Jar between runs
+	// of a program. New loads the Jar's initial cookies from Storage,
+	// and Jar.Save writes the Jar's cookies back to it.
+	Storage Storage
 }
 
 // Jar implements the http.CookieJar interface from the net/http package.
 type Jar struct {
-	psList PublicSuffixList
+	psList  PublicSuffixList
+	storage Storage
 
 	// mu locks the remaining fields.
 	mu sync.Mutex
@@ -74,12 +80,26 @@ type Jar struct {
 
 // New returns a new cookie jar. A nil *Options is equivalent to a zero
 // Options.
+//
+// If o.Storage is non-nil, the jar is populated with the entries it
+// returns from Load; an error from Load or from adding the entries to
+// the jar is returned by New.
 func New(o *Options) (*Jar, error) {
 	jar := &Jar{
 		entries: make(map[string]map[string]entry),
 	}
 	if o != nil {
 		jar.psList = o.PublicSuffixList
+		jar.storage = o.Storage
+	}
+	if jar.storage != nil {
+		entries, err := jar.storage.Load()
+		if err != nil {
+			return nil, err
+		}
+		if err := jar.SetEntries(entries); err != nil {
+			return nil, err
+		}
 	}
 	return jar, nil
 }
@@ -89,18 +109,19 @@ func New(o *Options) (*Jar, error) {
 // This struct type is not used outside of this package per se, but the exported
 // fields are those of RFC 6265.
 type entry struct {
-	Name       string
-	Value      string
-	Domain     string
-	Path       string
-	SameSite   string
-	Secure     bool
-	HttpOnly   bool
-	Persistent bool
-	HostOnly   bool
-	Expires    time.Time
-	Creation   time.Time
-	LastAccess time.Time
+	Name        string
+	Value       string
+	Domain      string
+	Path        string
+	SameSite    string
+	Secure      bool
+	HttpOnly    bool
+	Partitioned bool
+	Persistent  bool
+	HostOnly    bool
+	Expires     time.Time
+	Creation    time.Time
+	LastAccess  time.Time
 
 	// seqNum is a sequence number so that Cookies returns cookies in a
 	// deterministic order, even for cookies that have equal Path length and
@@ -418,23 +439,48 @@ func (j *Jar) newEntry(c *http.Cookie, now time.Time, defPath, host string) (e e
 	e.Value = c.Value
 	e.Secure = c.Secure
 	e.HttpOnly = c.HttpOnly
+	e.Partitioned = c.Partitioned
+	e.SameSite = sameSiteAttr(c.SameSite)
 
-	switch c.SameSite {
+	return e, false, nil
+}
+
+// sameSiteAttr returns the SameSite attribute of an entry for mode.
+func sameSiteAttr(mode http.SameSite) string {
+	switch mode {
 	case http.SameSiteDefaultMode:
-		e.SameSite = "SameSite"
+		return "SameSite"
 	case http.SameSiteStrictMode:
-		e.SameSite = "SameSite=Strict"
+		return "SameSite=Strict"
 	case http.SameSiteLaxMode:
-		e.SameSite = "SameSite=Lax"
+		return "SameSite=Lax"
+	case http.SameSiteNoneMode:
+		return "SameSite=None"
 	}
+	return ""
+}
 
-	return e, false, nil
+// sameSiteMode is the inverse of sameSiteAttr.
+func sameSiteMode(attr string) http.SameSite {
+	switch attr {
+	case "SameSite":
+		return http.SameSiteDefaultMode
+	case "SameSite=Strict":
+		return http.SameSiteStrictMode
+	case "SameSite=Lax":
+		return http.SameSiteLaxMode
+	case "SameSite=None":
+		return http.SameSiteNoneMode
+	}
+	return 0
 }
 
 var (
 	errIllegalDomain   = errors.New("cookiejar: illegal cookie domain attribute")
 	errMalformedDomain = errors.New("cookiejar: malformed cookie domain attribute")
 	errNoHostname      = errors.New("cookiejar: no host name available (IP only)")
+	errMalformedPath   = errors.New("cookiejar: malformed cookie path")
+	errNoStorage       = errors.New("cookiejar: no Storage configured")
 )
 
 // endOfTime is the time when session (non-persistent) cookies expire.
@@ -0,0 +1,39 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package cookiejar
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile places an advisory lock on f, waiting until it is available.
+// The lock is exclusive if exclusive is true and shared otherwise.
+func lockFile(f *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	return flock(f, how, "flock")
+}
+
+// unlockFile releases the lock placed on f by lockFile.
+func unlockFile(f *os.File) error {
+	return flock(f, syscall.LOCK_UN, "funlock")
+}
+
+func flock(f *os.File, how int, op string) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			if err != nil {
+				return &os.PathError{Op: op, Path: f.Name(), Err: err}
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cookiejar
+
+import (
+	"internal/syscall/windows"
+	"os"
+	"syscall"
+)
+
+// allBytes locks the whole file, however long it grows.
+const allBytes = ^uint32(0)
+
+// lockFile places a lock on f, waiting until it is available.
+// The lock is exclusive if exclusive is true and shared otherwise.
+func lockFile(f *os.File, exclusive bool) error {
+	var flags uint32
+	if exclusive {
+		flags = windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	ol := new(syscall.Overlapped)
+	if err := windows.LockFileEx(syscall.Handle(f.Fd()), flags, 0, allBytes, allBytes, ol); err != nil {
+		return &os.PathError{Op: "LockFileEx", Path: f.Name(), Err: err}
+	}
+	return nil
+}
+
+// unlockFile releases the lock placed on f by lockFile.
+func unlockFile(f *os.File) error {
+	ol := new(syscall.Overlapped)
+	if err := windows.UnlockFileEx(syscall.Handle(f.Fd()), 0, allBytes, allBytes, ol); err != nil {
+		return &os.PathError{Op: "UnlockFileEx", Path: f.Name(), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,431 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cookiejar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// An Entry is a cookie held by a Jar, together with the attributes the
+// Jar records for it. Entries are returned by Jar.Entries and accepted by
+// Jar.SetEntries, and are suitable for encoding with encoding/json.
+type Entry struct {
+	Name  string
+	Value string
+
+	// Domain is the canonical host or domain the cookie belongs to,
+	// without a leading dot. If HostOnly is true, the cookie is sent
+	// to Domain only; otherwise it is also sent to its subdomains.
+	Domain   string
+	HostOnly bool
+
+	Path        string
+	SameSite    http.SameSite
+	Secure      bool
+	HttpOnly    bool
+	Partitioned bool
+
+	// Persistent reports whether the cookie expires at Expires.
+	// A non-persistent (session) cookie has a zero Expires.
+	Persistent bool
+	Expires    time.Time
+
+	Creation   time.Time
+	LastAccess time.Time
+}
+
+// Entries returns the unexpired cookies held by j, including session
+// cookies, in the order in which they were created.
+func (j *Jar) Entries() []Entry {
+	return j.allEntries(time.Now())
+}
+
+// allEntries is like Entries but takes the current time as a parameter.
+func (j *Jar) allEntries(now time.Time) []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var selected []entry
+	for _, submap := range j.entries {
+		for _, e := range submap {
+			if e.Persistent && !e.Expires.After(now) {
+				continue
+			}
+			selected = append(selected, e)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		s := selected
+		if !s[i].Creation.Equal(s[j].Creation) {
+			return s[i].Creation.Before(s[j].Creation)
+		}
+		return s[i].seqNum < s[j].seqNum
+	})
+
+	entries := make([]Entry, 0, len(selected))
+	for _, e := range selected {
+		x := Entry{
+			Name:        e.Name,
+			Value:       e.Value,
+			Domain:      e.Domain,
+			HostOnly:    e.HostOnly,
+			Path:        e.Path,
+			SameSite:    sameSiteMode(e.SameSite),
+			Secure:      e.Secure,
+			HttpOnly:    e.HttpOnly,
+			Partitioned: e.Partitioned,
+			Persistent:  e.Persistent,
+			Creation:    e.Creation,
+			LastAccess:  e.LastAccess,
+		}
+		if e.Persistent {
+			x.Expires = e.Expires
+		}
+		entries = append(entries, x)
+	}
+	return entries
+}
+
+// SetEntries adds entries, typically obtained from an earlier call to
+// Entries, to j. An entry replaces any cookie in j with the same name,
+// domain and path. Expired entries are ignored, and a zero Creation or
+// LastAccess time is replaced by the current time.
+//
+// If any entry is malformed, or has a Domain that a server could not
+// have set a cookie for, SetEntries returns an error and leaves j
+// unchanged.
+func (j *Jar) SetEntries(entries []Entry) error {
+	return j.setEntries(entries, time.Now())
+}
+
+// setEntries is like SetEntries but takes the current time as a parameter.
+func (j *Jar) setEntries(entries []Entry, now time.Time) error {
+	for i := range entries {
+		if err := j.checkEntry(&entries[i]); err != nil {
+			x := &entries[i]
+			return fmt.Errorf("%w: entry %s;%s;%s", err, x.Domain, x.Path, x.Name)
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, x := range entries {
+		if x.Persistent && !x.Expires.After(now) {
+			continue
+		}
+		e := entry{
+			Name:        x.Name,
+			Value:       x.Value,
+			Domain:      x.Domain,
+			Path:        x.Path,
+			SameSite:    sameSiteAttr(x.SameSite),
+			Secure:      x.Secure,
+			HttpOnly:    x.HttpOnly,
+			Partitioned: x.Partitioned,
+			Persistent:  x.Persistent,
+			HostOnly:    x.HostOnly,
+			Expires:     x.Expires,
+			Creation:    x.Creation,
+			LastAccess:  x.LastAccess,
+		}
+		if !e.Persistent {
+			e.Expires = endOfTime
+		}
+		if e.Creation.IsZero() {
+			e.Creation = now
+		}
+		if e.LastAccess.IsZero() {
+			e.LastAccess = now
+		}
+
+		key := jarKey(e.Domain, j.psList)
+		submap := j.entries[key]
+		if submap == nil {
+			submap = make(map[string]entry)
+			j.entries[key] = submap
+		}
+		id := e.id()
+		if old, ok := submap[id]; ok {
+			e.seqNum = old.seqNum
+		} else {
+			e.seqNum = j.nextSeqNum
+			j.nextSeqNum++
+		}
+		submap[id] = e
+	}
+	return nil
+}
+
+// checkEntry reports whether x could have been created by SetCookies.
+func (j *Jar) checkEntry(x *Entry) error {
+	if x.Path == "" || x.Path[0] != '/' {
+		return errMalformedPath
+	}
+	if host, err := canonicalHost(x.Domain); err != nil || host == "" || host[0] == '.' || host != x.Domain {
+		return errMalformedDomain
+	}
+	if x.HostOnly {
+		return nil
+	}
+	if isIP(x.Domain) {
+		return errNoHostname
+	}
+	if j.psList != nil {
+		if ps := j.psList.PublicSuffix(x.Domain); ps != "" && !hasDotSuffix(x.Domain, ps) {
+			return errIllegalDomain
+		}
+	}
+	return nil
+}
+
+// Save writes the persistent cookies held by j, as returned by Entries,
+// to the Storage given in the Options passed to New. Session cookies
+// are not saved.
+func (j *Jar) Save() error {
+	if j.storage == nil {
+		return errNoStorage
+	}
+	entries := j.Entries()
+	persistent := entries[:0]
+	for _, x := range entries {
+		if x.Persistent {
+			persistent = append(persistent, x)
+		}
+	}
+	return j.storage.Save(persistent)
+}
+
+// Storage is the interface implemented by persistent storage for the
+// cookies of a Jar.
+//
+// Implementations of Storage must be safe for concurrent use by
+// multiple goroutines.
+type Storage interface {
+	// Load returns the stored entries.
+	// If Save has never been called, Load returns no entries
+	// and a nil error.
+	Load() ([]Entry, error)
+
+	// Save stores entries, replacing the entries previously
+	// loaded or saved through the Storage.
+	Save(entries []Entry) error
+}
+
+// A FileStorage is a Storage that keeps entries in a file, encoded as
+// JSON.
+//
+// Several processes may share the file. Load and Save hold an advisory
+// lock on a second file, named by adding ".lock" to the file's name,
+// while they read or write. Save merges the entries with those saved
+// by other processes: of two entries with the same domain, path and
+// name, the one accessed most recently is kept, and entries removed
+// from the Jar are removed from the file. Expired and session entries
+// are dropped.
+//
+// Save writes the entries to a temporary file in the same directory
+// and renames it over the file, so that a crash never leaves a
+// partially written file behind. On systems without file locking,
+// only concurrent use within a single process is safe. On Windows,
+// Save fails if a program that does not use FileStorage has the file
+// open.
+type FileStorage struct {
+	name string
+
+	// mu serializes Load and Save, and locks the remaining field.
+	mu sync.Mutex
+
+	// known holds the Creation time of each entry last loaded or
+	// saved through s, keyed by its domain, path and name. Save uses
+	// it to tell entries removed from the Jar from entries added by
+	// other processes.
+	known map[string]time.Time
+}
+
+// NewFileStorage returns a FileStorage that keeps entries in the named
+// file. The file is created by the first call to Save.
+func NewFileStorage(name string) *FileStorage {
+	return &FileStorage{name: name}
+}
+
+// lock opens the lock file of s and locks it, creating it if necessary.
+// The lock is exclusive if exclusive is true and shared otherwise.
+// The caller must call unlock when done.
+func (s *FileStorage) lock(exclusive bool) (*os.File, error) {
+	f, err := os.OpenFile(s.name+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// unlock releases the lock returned by lock.
+func unlock(f *os.File) {
+	unlockFile(f)
+	f.Close()
+}
+
+// Load implements the Load method of the Storage interface.
+// It returns no entries if the file does not exist.
+func (s *FileStorage) Load() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.name); errors.Is(err, os.ErrNotExist) {
+		s.known = nil
+		return nil, nil
+	}
+	lf, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock(lf)
+
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	s.known = make(map[string]time.Time, len(entries))
+	for _, x := range entries {
+		s.known[entryID(&x)] = x.Creation
+	}
+	return entries, nil
+}
+
+// read returns the entries in the file, or none if it does not exist.
+// The caller must hold the lock.
+func (s *FileStorage) read() ([]Entry, error) {
+	data, err := os.ReadFile(s.name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("cookiejar: reading %s: %w", s.name, err)
+	}
+	return entries, nil
+}
+
+// Save implements the Save method of the Storage interface.
+// The file is created with mode 0600, replacing any existing file.
+func (s *FileStorage) Save(entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lf, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock(lf)
+
+	stored, err := s.read()
+	if err != nil {
+		return err
+	}
+	merged := mergeEntries(entries, stored, s.known, time.Now())
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	dir, base := filepath.Split(s.name)
+	if dir == "" {
+		// Not os.TempDir, which may be on another file system.
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, base+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		// Make sure the data is on disk before the rename is.
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	s.known = make(map[string]time.Time, len(entries))
+	for _, x := range entries {
+		s.known[entryID(&x)] = x.Creation
+	}
+	return nil
+}
+
+// mergeEntries merges the entries being saved with those stored in the
+// file. known is as described in FileStorage. A stored entry missing
+// from entries is kept unless known records it with the same Creation
+// time, in which case it was removed from the Jar. If both hold an
+// entry, the one with the later LastAccess time is kept. Expired and
+// session entries are dropped. The result is in the order of Creation.
+func mergeEntries(entries, stored []Entry, known map[string]time.Time, now time.Time) []Entry {
+	keep := func(x *Entry) bool {
+		return x.Persistent && x.Expires.After(now)
+	}
+
+	merged := make([]Entry, 0, len(entries)+len(stored))
+	index := make(map[string]int, len(entries))
+	for _, x := range entries {
+		if !keep(&x) {
+			continue
+		}
+		index[entryID(&x)] = len(merged)
+		merged = append(merged, x)
+	}
+	for _, x := range stored {
+		if !keep(&x) {
+			continue
+		}
+		id := entryID(&x)
+		if i, ok := index[id]; ok {
+			if x.LastAccess.After(merged[i].LastAccess) {
+				merged[i] = x
+			}
+			continue
+		}
+		if c, ok := known[id]; ok && c.Equal(x.Creation) {
+			continue
+		}
+		index[id] = len(merged)
+		merged = append(merged, x)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Creation.Before(merged[j].Creation)
+	})
+	return merged
+}
+
+// entryID returns the domain, path and name of x, which identify it
+// as in entry.id.
+func entryID(x *Entry) string {
+	return fmt.Sprintf("%s;%s;%s", x.Domain, x.Path, x.Name)
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cookiejar
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntries(t *testing.T) {
+	jar := newTestJar()
+	jar.setCookies(mustParseURL("https://www.host.test/some/path"), []*http.Cookie{
+		{Name: "a", Value: "1"},
+		{Name: "b", Value: "2", Domain: "host.test", Path: "/", MaxAge: 3600, Secure: true},
+		{Name: "c", Value: "3", HttpOnly: true, SameSite: http.SameSiteStrictMode, Partitioned: true},
+		{Name: "d", Value: "4", Expires: tNow.Add(-time.Second)},
+	}, tNow)
+	jar.setCookies(mustParseURL("http://www.host.test/"), []*http.Cookie{
+		{Name: "a", Value: "5", SameSite: http.SameSiteLaxMode},
+		{Name: "e", Value: "6", MaxAge: 1},
+	}, tNow.Add(time.Second))
+
+	want := []Entry{
+		{Name: "a", Value: "1", Domain: "www.host.test", HostOnly: true, Path: "/some", Creation: tNow, LastAccess: tNow},
+		{Name: "b", Value: "2", Domain: "host.test", Path: "/", Secure: true, Persistent: true, Expires: tNow.Add(time.Hour), Creation: tNow, LastAccess: tNow},
+		{Name: "c", Value: "3", Domain: "www.host.test", HostOnly: true, Path: "/some", SameSite: http.SameSiteStrictMode, HttpOnly: true, Partitioned: true, Creation: tNow, LastAccess: tNow},
+		{Name: "a", Value: "5", Domain: "www.host.test", HostOnly: true, Path: "/", SameSite: http.SameSiteLaxMode, Creation: tNow.Add(time.Second), LastAccess: tNow.Add(time.Second)},
+	}
+	if got := jar.allEntries(tNow.Add(2 * time.Second)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("allEntries:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// The entries can be loaded into another jar, which then sends
+	// the same cookies as the original one.
+	jar2 := newTestJar()
+	if err := jar2.setEntries(want, tNow.Add(2*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := jar2.allEntries(tNow.Add(2 * time.Second)); !reflect.DeepEqual(got, want) {
+		t.Errorf("allEntries after setEntries:\ngot  %+v\nwant %+v", got, want)
+	}
+	for _, u := range []string{
+		"https://www.host.test/some/path",
+		"http://www.host.test/some",
+		"https://sub.host.test/",
+	} {
+		now := tNow.Add(3 * time.Second)
+		got := jar2.cookies(mustParseURL(u), now)
+		want := jar.cookies(mustParseURL(u), now)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("cookies(%q) = %v; want %v", u, got, want)
+		}
+	}
+}
+
+func TestSetEntries(t *testing.T) {
+	jar := newTestJar()
+	err := jar.setEntries([]Entry{
+		{Name: "fresh", Value: "1", Domain: "www.host.test", Path: "/", Persistent: true, Expires: tNow.Add(time.Hour)},
+		{Name: "expired", Value: "2", Domain: "www.host.test", Path: "/", Persistent: true, Expires: tNow},
+	}, tNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Name: "fresh", Value: "1", Domain: "www.host.test", Path: "/", Persistent: true, Expires: tNow.Add(time.Hour), Creation: tNow, LastAccess: tNow},
+	}
+	if got := jar.allEntries(tNow); !reflect.DeepEqual(got, want) {
+		t.Errorf("allEntries:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	for _, test := range []struct {
+		entry Entry
+		err   error
+	}{
+		{Entry{Name: "a", Domain: "www.host.test", Path: ""}, errMalformedPath},
+		{Entry{Name: "a", Domain: "www.host.test", Path: "rel"}, errMalformedPath},
+		{Entry{Name: "a", Domain: "", Path: "/", HostOnly: true}, errMalformedDomain},
+		{Entry{Name: "a", Domain: ".host.test", Path: "/"}, errMalformedDomain},
+		{Entry{Name: "a", Domain: "WWW.host.test", Path: "/"}, errMalformedDomain},
+		{Entry{Name: "a", Domain: "www.host.test:80", Path: "/"}, errMalformedDomain},
+		{Entry{Name: "a", Domain: "127.0.0.1", Path: "/"}, errNoHostname},
+		{Entry{Name: "a", Domain: "co.uk", Path: "/"}, errIllegalDomain},
+	} {
+		entries := []Entry{
+			{Name: "ok", Domain: "www.host.test", Path: "/"},
+			test.entry,
+		}
+		if err := jar.setEntries(entries, tNow); !errors.Is(err, test.err) {
+			t.Errorf("setEntries(%+v) = %v; want %v", test.entry, err, test.err)
+		}
+		if got := jar.allEntries(tNow); !reflect.DeepEqual(got, want) {
+			t.Errorf("setEntries(%+v) modified the jar:\ngot  %+v\nwant %+v", test.entry, got, want)
+		}
+	}
+
+	// Host-only entries are valid for IP addresses and public suffixes.
+	if err := jar.setEntries([]Entry{
+		{Name: "a", Domain: "127.0.0.1", Path: "/", HostOnly: true},
+		{Name: "a", Domain: "co.uk", Path: "/", HostOnly: true},
+	}, tNow); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "cookies")
+	storage := NewFileStorage(name)
+	if entries, err := storage.Load(); entries != nil || err != nil {
+		t.Fatalf("Load of missing file = %v, %v; want nil, nil", entries, err)
+	}
+
+	jar, err := New(&Options{PublicSuffixList: testPSL{}, Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := mustParseURL("https://www.host.test/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "1"},
+		{Name: "login", Value: "2", MaxAge: 3600, Secure: true, Partitioned: true},
+	})
+	if err := jar.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(name); err != nil {
+		t.Fatal(err)
+	} else if perm := fi.Mode().Perm(); runtime.GOOS != "windows" && perm&0077 != 0 {
+		t.Errorf("file mode = %v; want no group or other access", perm)
+	}
+
+	jar2, err := New(&Options{PublicSuffixList: testPSL{}, Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Session cookies are not saved.
+	got, want := jar2.Entries(), jar.Entries()
+	if len(want) != 2 || want[0].Name != "session" {
+		t.Fatalf("jar holds %+v; want a session and a login cookie", want)
+	}
+	want = want[1:]
+	if len(got) != len(want) {
+		t.Fatalf("loaded %d entries; want %d", len(got), len(want))
+	}
+	for i := range got {
+		if !got[i].Expires.Equal(want[i].Expires) || !got[i].Creation.Equal(want[i].Creation) || !got[i].LastAccess.Equal(want[i].LastAccess) {
+			t.Errorf("entry %d times = %v, %v, %v; want %v, %v, %v", i,
+				got[i].Expires, got[i].Creation, got[i].LastAccess,
+				want[i].Expires, want[i].Creation, want[i].LastAccess)
+		}
+		got[i].Expires, got[i].Creation, got[i].LastAccess = time.Time{}, time.Time{}, time.Time{}
+		want[i].Expires, want[i].Creation, want[i].LastAccess = time.Time{}, time.Time{}, time.Time{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded entries:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// Saving replaces the previously stored entries.
+	if err := storage.Save(nil); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := storage.Load(); len(entries) != 0 || err != nil {
+		t.Errorf("Load after Save(nil) = %v, %v; want no entries", entries, err)
+	}
+
+	// Saving replaces the file rather than writing to it,
+	// and leaves no temporary file behind.
+	storage = NewFileStorage(name)
+	if err := os.Chmod(name, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Save(nil); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(name); err != nil {
+		t.Fatal(err)
+	} else if perm := fi.Mode().Perm(); runtime.GOOS != "windows" && perm&0077 != 0 {
+		t.Errorf("file mode after replacing a 0644 file = %v; want no group or other access", perm)
+	}
+	if files, err := os.ReadDir(dir); err != nil {
+		t.Fatal(err)
+	} else {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		if want := []string{"cookies", "cookies.lock"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("directory holds %q after Save; want %q", names, want)
+		}
+	}
+
+	if err := os.WriteFile(name, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(&Options{Storage: storage}); err == nil {
+		t.Error("New with malformed storage file succeeded")
+	}
+}
+
+func TestFileStorageMerge(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cookies")
+	newJar := func() *Jar {
+		jar, err := New(&Options{PublicSuffixList: testPSL{}, Storage: NewFileStorage(name)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jar
+	}
+	names := func(jar *Jar) string {
+		var s []string
+		for _, x := range jar.Entries() {
+			s = append(s, x.Name+"="+x.Value)
+		}
+		return strings.Join(s, " ")
+	}
+	save := func(jar *Jar) {
+		t.Helper()
+		if err := jar.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u := mustParseURL("https://www.host.test/")
+
+	jar1 := newJar()
+	jar1.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1", MaxAge: 3600}, {Name: "b", Value: "1", MaxAge: 3600}})
+	save(jar1)
+
+	// Two jars sharing the file keep each other's cookies,
+	// and the most recently set value wins.
+	jar2 := newJar()
+	jar1.SetCookies(u, []*http.Cookie{{Name: "c", Value: "1", MaxAge: 3600}})
+	time.Sleep(10 * time.Millisecond)
+	jar2.SetCookies(u, []*http.Cookie{{Name: "a", Value: "2", MaxAge: 3600}, {Name: "d", Value: "2", MaxAge: 3600}})
+	save(jar2)
+	save(jar1)
+	if got, want := names(newJar()), "a=2 b=1 c=1 d=2"; got != want {
+		t.Errorf("after saving both jars, loaded %q; want %q", got, want)
+	}
+
+	// A cookie removed from a jar is removed from the file,
+	// but only if the jar had seen it.
+	jar1.SetCookies(u, []*http.Cookie{{Name: "b", MaxAge: -1}, {Name: "d", MaxAge: -1}})
+	save(jar1)
+	if got, want := names(newJar()), "a=2 c=1 d=2"; got != want {
+		t.Errorf("after removing cookies, loaded %q; want %q", got, want)
+	}
+}
+
+func TestFileStorageLock(t *testing.T) {
+	switch runtime.GOOS {
+	case "darwin", "dragonfly", "freebsd", "linux", "netbsd", "openbsd", "windows":
+	default:
+		t.Skipf("file locking not supported on %s", runtime.GOOS)
+	}
+	name := filepath.Join(t.TempDir(), "cookies")
+	s1, s2 := NewFileStorage(name), NewFileStorage(name)
+	lf, err := s1.lock(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s2.Save(nil)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Save returned %v while the file was locked", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock(lf)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveWithoutStorage(t *testing.T) {
+	if err := newTestJar().Save(); err != errNoStorage {
+		t.Errorf("Save = %v; want %v", err, errNoStorage)
+	}
+}
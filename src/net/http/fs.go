@@ -659,11 +659,79 @@ func serveFile(w ResponseWriter, r *Request, fs FileSystem, name string, redirec
 		return
 	}
 
+	if zfs, ok := fs.(zstdVariantsFS); ok && servePrecompressed(w, r, zfs.FileSystem, name, d, f) {
+		return
+	}
+
 	// serveContent will check modification time
 	sizeFunc := func() (int64, error) { return d.Size(), nil }
 	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, f)
 }
 
+// servePrecompressed serves the zstd-compressed variant of the file f
+// with the given name and info, stored in fsys with a ".zst" suffix,
+// if there is one and the client accepts it. It reports whether it
+// served the request.
+func servePrecompressed(w ResponseWriter, r *Request, fsys FileSystem, name string, d fs.FileInfo, f File) bool {
+	zf, err := fsys.Open(name + ".zst")
+	if err != nil {
+		return false
+	}
+	defer zf.Close()
+	zd, err := zf.Stat()
+	if err != nil || zd.IsDir() {
+		return false
+	}
+
+	// The response depends on Accept-Encoding whichever variant is served.
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	// Range requests get the uncompressed file: a client that asks
+	// for a range is unlikely to be able to decode part of a
+	// compressed file.
+	if !acceptsEncoding(r, "zstd") || r.Header.Get("Range") != "" {
+		return false
+	}
+
+	// The Content-Type is that of the uncompressed file.
+	if _, haveType := w.Header()["Content-Type"]; !haveType {
+		ctype := mime.TypeByExtension(filepath.Ext(d.Name()))
+		if ctype == "" {
+			var buf [sniffLen]byte
+			n, _ := io.ReadFull(f, buf[:])
+			ctype = DetectContentType(buf[:n])
+		}
+		w.Header().Set("Content-Type", ctype)
+	}
+	w.Header().Set("Content-Encoding", "zstd")
+	sizeFunc := func() (int64, error) { return zd.Size(), nil }
+	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, zf)
+	return true
+}
+
+// acceptsEncoding reports whether the request's Accept-Encoding header
+// lists the content coding with a nonzero quality value.
+func acceptsEncoding(r *Request, coding string) bool {
+	for _, v := range r.Header["Accept-Encoding"] {
+		for _, part := range strings.Split(v, ",") {
+			name, params := part, ""
+			if i := strings.IndexByte(part, ';'); i >= 0 {
+				name, params = part[:i], part[i+1:]
+			}
+			if !strings.EqualFold(textproto.TrimString(name), coding) {
+				continue
+			}
+			params = textproto.TrimString(params)
+			if len(params) < 2 || !strings.EqualFold(params[:2], "q=") {
+				return true
+			}
+			q, err := strconv.ParseFloat(params[2:], 64)
+			return err == nil && q > 0
+		}
+	}
+	return false
+}
+
 // toHTTPError returns a non-specific HTTP error message and status code
 // for a given non-nil error value. It's important that toHTTPError does not
 // actually return err.Error(), since msg and httpStatus are returned to users,
@@ -819,6 +887,25 @@ func FS(fsys fs.FS) FileSystem {
 	return ioFS{fsys}
 }
 
+// ZstdVariants returns a FileSystem with the contents of fsys that makes
+// FileServer serve precompressed files. If a file has a zstd-compressed
+// variant alongside it, with the same name plus a ".zst" suffix, the file
+// server serves the variant with "Content-Encoding: zstd" to clients
+// whose Accept-Encoding header lists zstd, and adds
+// "Vary: Accept-Encoding" to its responses for the file. Range requests
+// are always served from the uncompressed file.
+//
+//	http.Handle("/", http.FileServer(http.ZstdVariants(http.Dir("/var/www"))))
+//
+func ZstdVariants(fsys FileSystem) FileSystem {
+	return zstdVariantsFS{fsys}
+}
+
+// zstdVariantsFS is the FileSystem returned by ZstdVariants.
+type zstdVariantsFS struct {
+	FileSystem
+}
+
 // FileServer returns a handler that serves HTTP requests
 // with the contents of the file system rooted at root.
 //
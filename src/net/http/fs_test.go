@@ -7,6 +7,7 @@ package http_test
 import (
 	"bufio"
 	"bytes"
+	"compress/zstd"
 	"errors"
 	"fmt"
 	"io"
@@ -27,6 +28,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -628,6 +630,98 @@ func TestServeIndexHtmlFS(t *testing.T) {
 	}
 }
 
+func TestFileServerZstd(t *testing.T) {
+	defer afterTest(t)
+	const content = "some text that is served compressed if the client accepts it\n"
+	var zbuf bytes.Buffer
+	zw := zstd.NewWriter(&zbuf)
+	io.WriteString(zw, content)
+	zw.Close()
+	fsys := fstest.MapFS{
+		"page.txt":     {Data: []byte(content)},
+		"page.txt.zst": {Data: zbuf.Bytes()},
+		"plain.txt":    {Data: []byte(content)},
+	}
+	ts := httptest.NewServer(FileServer(ZstdVariants(FS(fsys))))
+	defer ts.Close()
+	plain := httptest.NewServer(FileServer(FS(fsys)))
+	defer plain.Close()
+	c := &Client{Transport: &Transport{DisableCompression: true}}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		url            string
+		acceptEncoding string
+		rangeHeader    string
+		wantEncoding   string
+		wantVary       string
+	}{
+		{ts.URL + "/page.txt", "gzip, zstd", "", "zstd", "Accept-Encoding"},
+		{ts.URL + "/page.txt", "ZSTD;q=0.5", "", "zstd", "Accept-Encoding"},
+		{ts.URL + "/page.txt", "gzip", "", "", "Accept-Encoding"},
+		{ts.URL + "/page.txt", "zstd;q=0", "", "", "Accept-Encoding"},
+		{ts.URL + "/page.txt", "", "", "", "Accept-Encoding"},
+		{ts.URL + "/page.txt", "zstd", "bytes=0-3", "", "Accept-Encoding"},
+		{ts.URL + "/plain.txt", "zstd", "", "", ""},
+		// Without ZstdVariants, the variant is not used.
+		{plain.URL + "/page.txt", "zstd", "", "", ""},
+	}
+	for _, tt := range tests {
+		req, _ := NewRequest("GET", tt.url, nil)
+		if tt.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		if tt.rangeHeader != "" {
+			req.Header.Set("Range", tt.rangeHeader)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := fmt.Sprintf("GET %s with Accept-Encoding %q, Range %q", tt.url, tt.acceptEncoding, tt.rangeHeader)
+		if g := res.Header.Get("Content-Encoding"); g != tt.wantEncoding {
+			t.Errorf("%s: Content-Encoding = %q; want %q", name, g, tt.wantEncoding)
+		}
+		if g := res.Header.Get("Vary"); g != tt.wantVary {
+			t.Errorf("%s: Vary = %q; want %q", name, g, tt.wantVary)
+		}
+		if g, e := res.Header.Get("Content-Type"), "text/plain; charset=utf-8"; g != e {
+			t.Errorf("%s: Content-Type = %q; want %q", name, g, e)
+		}
+		want := content
+		switch {
+		case tt.wantEncoding == "zstd":
+			want = zbuf.String()
+		case tt.rangeHeader != "":
+			want = content[:4]
+		}
+		if string(body) != want {
+			t.Errorf("%s: got body %q; want %q", name, body, want)
+		}
+	}
+
+	// A Transport with EnableZstd asks for and decodes the compressed file.
+	zc := &Client{Transport: &Transport{EnableZstd: true}}
+	defer zc.CloseIdleConnections()
+	res, err := zc.Get(ts.URL + "/page.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != content || !res.Uncompressed {
+		t.Errorf("zstd client: got body %q, Uncompressed %v; want %q, true", body, res.Uncompressed, content)
+	}
+}
+
 func TestFileServerZeroByte(t *testing.T) {
 	defer afterTest(t)
 	ts := httptest.NewServer(FileServer(Dir(".")))
@@ -166,3 +166,60 @@ type Pusher interface {
 	// is not supported on the underlying connection.
 	Push(target string, opts *PushOptions) error
 }
+
+// HTTP2Config defines HTTP/2 configuration parameters common to
+// both Transport and Server.
+//
+// Some parameters are only supported by one side for now, as noted
+// below; the other side ignores them.
+//
+// The maximum size of the header list that a peer may send is set by
+// Server.MaxHeaderBytes and Transport.MaxResponseHeaderBytes.
+type HTTP2Config struct {
+	// MaxConcurrentStreams optionally specifies the number of
+	// concurrent streams that each client may have open at a time.
+	// If zero, it defaults to at least 100.
+	// It is used only by Server.
+	MaxConcurrentStreams int
+
+	// MaxReadFrameSize optionally specifies the largest frame
+	// this endpoint is willing to read. A valid value is between
+	// 16KiB and 16MiB, inclusive. If zero or otherwise invalid, a
+	// default value is used.
+	// It is used only by Server.
+	MaxReadFrameSize int
+
+	// MaxReceiveBufferPerConnection is the size of the flow control
+	// window for data received on a connection. A valid value is
+	// at least 64KiB and less than 2GiB. If zero or otherwise
+	// invalid, a default value is used.
+	// It is used only by Server.
+	MaxReceiveBufferPerConnection int
+
+	// MaxReceiveBufferPerStream is the size of the flow control
+	// window for data received on a stream (a request). A valid
+	// value is less than 2GiB. If zero or otherwise invalid, a
+	// default value is used.
+	// It is used only by Server.
+	MaxReceiveBufferPerStream int
+
+	// ReadIdleTimeout is the timeout after which a health check
+	// using a ping frame will be carried out if no frame is received
+	// on a connection. A ping response is considered a received
+	// frame, so if there is no other traffic on the connection, the
+	// health check will be performed every ReadIdleTimeout interval.
+	// If zero, no health check is performed.
+	// It is used only by Transport.
+	ReadIdleTimeout time.Duration
+
+	// PingTimeout is the timeout after which a connection will be
+	// closed if a response to a health check ping is not received.
+	// If zero, a default of 15 seconds is used.
+	// It is used only by Transport.
+	PingTimeout time.Duration
+
+	// PermitProhibitedCipherSuites, if true, permits the use of
+	// cipher suites prohibited by the HTTP/2 spec. It is used only
+	// by Server.
+	PermitProhibitedCipherSuites bool
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !nethttpomithttp2
+
+package http
+
+// configureServer applies c to the configuration of the bundled
+// HTTP/2 server. Parameters that the bundled server does not support
+// are ignored; see HTTP2Config.
+func (c *HTTP2Config) configureServer(conf *http2Server) {
+	conf.MaxConcurrentStreams = h2Uint32(c.MaxConcurrentStreams)
+	conf.MaxReadFrameSize = h2Uint32(c.MaxReadFrameSize)
+	conf.MaxUploadBufferPerConnection = h2Int32(c.MaxReceiveBufferPerConnection)
+	conf.MaxUploadBufferPerStream = h2Int32(c.MaxReceiveBufferPerStream)
+	conf.PermitProhibitedCipherSuites = c.PermitProhibitedCipherSuites
+}
+
+// configureTransport is like configureServer, but for the bundled
+// HTTP/2 transport.
+func (c *HTTP2Config) configureTransport(t2 *http2Transport) {
+	t2.ReadIdleTimeout = c.ReadIdleTimeout
+	t2.PingTimeout = c.PingTimeout
+}
+
+// h2Uint32 converts the HTTP2Config value v to the HTTP/2 package's
+// type, mapping values that are out of range to zero, which selects
+// the default. It has the "h2" prefix to stay out of the "http2"
+// prefix namespace used by x/tools/cmd/bundle for h2_bundle.go.
+func h2Uint32(v int) uint32 {
+	if v <= 0 || int64(v) > 1<<32-1 {
+		return 0
+	}
+	return uint32(v)
+}
+
+// h2Int32 is like h2Uint32, but for int32 values.
+func h2Int32(v int) int32 {
+	if v <= 0 || int64(v) > 1<<31-1 {
+		return 0
+	}
+	return int32(v)
+}
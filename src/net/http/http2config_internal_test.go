@@ -0,0 +1,146 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !nethttpomithttp2
+
+// White-box tests for HTTP2Config (in package http instead of http_test).
+
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http/internal"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestServerHTTP2Config(t *testing.T) {
+	cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := newLocalListener(t)
+	srv := &Server{
+		Handler:   HandlerFunc(func(w ResponseWriter, r *Request) {}),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		HTTP2: &HTTP2Config{
+			MaxConcurrentStreams:          7,
+			MaxReadFrameSize:              1 << 20,
+			MaxReceiveBufferPerConnection: 1 << 21,
+			MaxReceiveBufferPerStream:     1 << 18,
+		},
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	c, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"h2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := io.WriteString(c, http2ClientPreface); err != nil {
+		t.Fatal(err)
+	}
+	fr := http2NewFramer(c, c)
+	if err := fr.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, ok := f.(*http2SettingsFrame)
+	if !ok {
+		t.Fatalf("got %v frame, want SETTINGS", f.Header().Type)
+	}
+	for id, want := range map[http2SettingID]uint32{
+		http2SettingMaxConcurrentStreams: 7,
+		http2SettingMaxFrameSize:         1 << 20,
+		http2SettingInitialWindowSize:    1 << 18,
+	} {
+		if got, ok := sf.Value(id); !ok || got != want {
+			t.Errorf("setting %v = %v, %v; want %v", id, got, ok, want)
+		}
+	}
+
+	// The server raises the connection's flow control window
+	// once the client's SETTINGS have been acknowledged.
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wf, ok := f.(*http2WindowUpdateFrame); ok && wf.StreamID == 0 {
+			if want := uint32(1<<21 - http2initialWindowSize); wf.Increment != want {
+				t.Errorf("connection WINDOW_UPDATE of %v; want %v", wf.Increment, want)
+			}
+			break
+		}
+	}
+}
+
+func TestTransportHTTP2Config(t *testing.T) {
+	tr := &Transport{
+		HTTP2: &HTTP2Config{
+			ReadIdleTimeout: 50 * time.Millisecond,
+			PingTimeout:     50 * time.Millisecond,
+		},
+	}
+	tr.nextProtoOnce.Do(tr.onceSetNextProtoDefaults)
+	t2, ok := tr.h2transport.(*http2Transport)
+	if !ok {
+		t.Fatalf("h2transport = %T; want *http2Transport", tr.h2transport)
+	}
+
+	c, sc := net.Pipe()
+	defer sc.Close()
+	sc.SetDeadline(time.Now().Add(10 * time.Second))
+	ccc := make(chan *http2ClientConn, 1)
+	go func() {
+		cc, err := t2.NewClientConn(c)
+		if err != nil {
+			t.Error(err)
+		}
+		ccc <- cc
+	}()
+
+	preface := make([]byte, len(http2ClientPreface))
+	if _, err := io.ReadFull(sc, preface); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if cc := <-ccc; cc != nil {
+			cc.Close()
+		}
+	}()
+
+	// Without a response to its health check ping, the client
+	// closes the connection.
+	fr := http2NewFramer(sc, sc)
+	sawPing := false
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			if !sawPing {
+				t.Fatalf("connection closed without PING: %v", err)
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatal("connection not closed after unanswered PING")
+			}
+			break
+		}
+		if pf, ok := f.(*http2PingFrame); ok && !pf.IsAck() {
+			sawPing = true
+		}
+	}
+}
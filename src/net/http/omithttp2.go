@@ -58,6 +58,9 @@ type http2Server struct {
 
 type http2WriteScheduler interface{}
 
+func (*HTTP2Config) configureServer(*http2Server)       {}
+func (*HTTP2Config) configureTransport(*http2Transport) {}
+
 func http2NewPriorityWriteScheduler(interface{}) http2WriteScheduler { panic(noHTTP2) }
 
 func http2ConfigureServer(s *Server, conf *http2Server) error { panic(noHTTP2) }
@@ -288,6 +288,11 @@ type conn struct {
 	// on this connection, if any.
 	lastMethod string
 
+	// handlerWriteDeadline is whether a Handler set the write
+	// deadline of the connection with SetWriteDeadline, which
+	// must then be reset before the next request.
+	handlerWriteDeadline bool
+
 	curReq atomic.Value // of *response (which has a Request in it)
 
 	curState struct{ atomic uint64 } // packed (unixtime<<8|uint8(ConnState))
@@ -390,11 +395,11 @@ func (cw *chunkWriter) Write(p []byte) (n int, err error) {
 	return
 }
 
-func (cw *chunkWriter) flush() {
+func (cw *chunkWriter) flush() error {
 	if !cw.wroteHeader {
 		cw.writeHeader(nil)
 	}
-	cw.res.conn.bufw.Flush()
+	return cw.res.conn.bufw.Flush()
 }
 
 func (cw *chunkWriter) close() {
@@ -424,6 +429,7 @@ type response struct {
 	wroteContinue    bool               // 100 Continue response was written
 	wants10KeepAlive bool               // HTTP/1.0 w/ Connection "keep-alive"
 	wantsClose       bool               // HTTP request has Connection "close"
+	fullDuplex       bool               // handler interleaves reads of the request body and writes of the response
 
 	// canWriteContinue is a boolean value accessed as an atomic int32
 	// that says whether or not a 100 Continue header can be written
@@ -975,7 +981,10 @@ func (c *conn) readRequest(ctx context.Context) (w *response, err error) {
 		defer func() {
 			c.rwc.SetWriteDeadline(time.Now().Add(d))
 		}()
+	} else if c.handlerWriteDeadline {
+		c.rwc.SetWriteDeadline(time.Time{})
 	}
+	c.handlerWriteDeadline = false
 
 	c.r.setReadLimit(c.server.initialReadLimitSize())
 	if c.lastMethod == "POST" {
@@ -1341,7 +1350,7 @@ func (cw *chunkWriter) writeHeader(p []byte) {
 	// TODO(bradfitz): where does RFC 2616 say that? See Issue 15527
 	// about HTTP/1.x Handlers concurrently reading and writing, like
 	// HTTP/2 handlers can do. Maybe this code should be relaxed?
-	if w.req.ContentLength != 0 && !w.closeAfterReply {
+	if w.req.ContentLength != 0 && !w.closeAfterReply && !w.fullDuplex {
 		var discard, tooBig bool
 
 		switch bdy := w.req.Body.(type) {
@@ -1675,11 +1684,39 @@ func (w *response) closedRequestBodyEarly() bool {
 }
 
 func (w *response) Flush() {
+	w.FlushError()
+}
+
+// FlushError is like Flush, but returns the error, if any,
+// encountered while writing to the connection.
+func (w *response) FlushError() error {
 	if !w.wroteHeader {
 		w.WriteHeader(StatusOK)
 	}
-	w.w.Flush()
-	w.cw.flush()
+	err := w.w.Flush()
+	if err2 := w.cw.flush(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// SetReadDeadline sets the read deadline of the connection.
+// It implements ResponseController.SetReadDeadline.
+func (w *response) SetReadDeadline(deadline time.Time) error {
+	return w.conn.rwc.SetReadDeadline(deadline)
+}
+
+// SetWriteDeadline sets the write deadline of the connection.
+// It implements ResponseController.SetWriteDeadline.
+func (w *response) SetWriteDeadline(deadline time.Time) error {
+	w.conn.handlerWriteDeadline = true
+	return w.conn.rwc.SetWriteDeadline(deadline)
+}
+
+// EnableFullDuplex implements ResponseController.EnableFullDuplex.
+func (w *response) EnableFullDuplex() error {
+	w.fullDuplex = true
+	return nil
 }
 
 func (c *conn) finalFlush() {
@@ -2638,6 +2675,11 @@ type Server struct {
 	// value.
 	ConnContext func(ctx context.Context, c net.Conn) context.Context
 
+	// HTTP2 configures HTTP/2 connections.
+	// It has no effect if HTTP/2 is not enabled automatically,
+	// for instance because TLSNextProto is non-nil.
+	HTTP2 *HTTP2Config
+
 	inShutdown atomicBool // true when when server is in shutdown
 
 	disableKeepAlives int32     // accessed atomically.
@@ -3248,6 +3290,9 @@ func (srv *Server) onceSetNextProtoDefaults() {
 		conf := &http2Server{
 			NewWriteScheduler: func() http2WriteScheduler { return http2NewPriorityWriteScheduler(nil) },
 		}
+		if c := srv.HTTP2; c != nil {
+			c.configureServer(conf)
+		}
 		srv.nextProtoErr = http2ConfigureServer(srv, conf)
 	}
 }
@@ -12,6 +12,7 @@ package http
 import (
 	"bufio"
 	"compress/gzip"
+	"compress/zstd"
 	"container/list"
 	"context"
 	"crypto/tls"
@@ -25,6 +26,7 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -188,6 +190,17 @@ type Transport struct {
 	// uncompressed.
 	DisableCompression bool
 
+	// EnableZstd, if true, makes the Transport request zstd as well
+	// as gzip compression, with an "Accept-Encoding: gzip, zstd"
+	// request header, and transparently decode zstd encoded
+	// responses in the same cases as gzipped ones. It has no effect
+	// if DisableCompression is true. As RFC 9659 requires, zstd
+	// responses that need a window larger than 8 MB fail to decode.
+	//
+	// EnableZstd currently applies to HTTP/1 connections only;
+	// over HTTP/2, the Transport requests gzip only.
+	EnableZstd bool
+
 	// MaxIdleConns controls the maximum number of idle (keep-alive)
 	// connections across all hosts. Zero means no limit.
 	MaxIdleConns int
@@ -280,6 +293,34 @@ type Transport struct {
 	// To use a custom dialer or TLS config and still attempt HTTP/2
 	// upgrades, set this to true.
 	ForceAttemptHTTP2 bool
+
+	// UseHTTPSRecords, if true, makes the Transport look up the DNS
+	// HTTPS records (RFC 9460) of the server of an "https" request
+	// that does not use a proxy before connecting to it. The endpoints
+	// the records advertise are tried in order of priority: the
+	// Transport connects to the record's target and port, falling
+	// back to its IP hints, and only offers the ALPN protocols that
+	// the record supports. Each attempt is limited to a few seconds.
+	// The records are looked up while the Transport connects to the
+	// server as usual; that connection is used if there are no usable
+	// records, if none of their endpoints can be reached, or if the
+	// lookup takes noticeably longer than the connection.
+	//
+	// The ECH configurations of the records are ignored.
+	// UseHTTPSRecords has no effect if DialTLSContext or DialTLS is set,
+	// or on systems where net.Resolver.LookupHTTPS is not supported.
+	UseHTTPSRecords bool
+
+	// HTTPSResolver specifies the resolver used to look up DNS HTTPS
+	// records when UseHTTPSRecords is set. If nil, net.DefaultResolver
+	// is used. The lookup does not go through DialContext or Dial; to
+	// send it over a custom connection, set the Dial field of the
+	// Resolver.
+	HTTPSResolver *net.Resolver
+
+	// HTTP2 configures HTTP/2 connections.
+	// It has no effect if HTTP/2 is not enabled; see ForceAttemptHTTP2.
+	HTTP2 *HTTP2Config
 }
 
 // A cancelKey is the key of the reqCanceler map.
@@ -315,6 +356,7 @@ func (t *Transport) Clone() *Transport {
 		TLSHandshakeTimeout:    t.TLSHandshakeTimeout,
 		DisableKeepAlives:      t.DisableKeepAlives,
 		DisableCompression:     t.DisableCompression,
+		EnableZstd:             t.EnableZstd,
 		MaxIdleConns:           t.MaxIdleConns,
 		MaxIdleConnsPerHost:    t.MaxIdleConnsPerHost,
 		MaxConnsPerHost:        t.MaxConnsPerHost,
@@ -325,12 +367,18 @@ func (t *Transport) Clone() *Transport {
 		GetProxyConnectHeader:  t.GetProxyConnectHeader,
 		MaxResponseHeaderBytes: t.MaxResponseHeaderBytes,
 		ForceAttemptHTTP2:      t.ForceAttemptHTTP2,
+		UseHTTPSRecords:        t.UseHTTPSRecords,
+		HTTPSResolver:          t.HTTPSResolver,
 		WriteBufferSize:        t.WriteBufferSize,
 		ReadBufferSize:         t.ReadBufferSize,
 	}
 	if t.TLSClientConfig != nil {
 		t2.TLSClientConfig = t.TLSClientConfig.Clone()
 	}
+	if t.HTTP2 != nil {
+		c := *t.HTTP2
+		t2.HTTP2 = &c
+	}
 	if !t.tlsNextProtoWasNil {
 		npm := map[string]func(authority string, c *tls.Conn) RoundTripper{}
 		for k, v := range t.TLSNextProto {
@@ -416,6 +464,10 @@ func (t *Transport) onceSetNextProtoDefaults() {
 			t2.MaxHeaderListSize = uint32(limit1)
 		}
 	}
+
+	if c := t.HTTP2; c != nil {
+		c.configureTransport(t2)
+	}
 }
 
 // ProxyFromEnvironment returns the URL of the proxy to use for a
@@ -1505,12 +1557,17 @@ func (t *Transport) decConnsPerHost(key connectMethodKey) {
 // Add TLS to a persistent connection, i.e. negotiate a TLS session. If pconn is already a TLS
 // tunnel, this function establishes a nested TLS session inside the encrypted channel.
 // The remote endpoint's name may be overridden by TLSClientConfig.ServerName.
-func (pconn *persistConn) addTLS(name string, trace *httptrace.ClientTrace) error {
+// If nextProtos is non-nil, it replaces the ALPN protocols of the
+// Transport's TLS configuration.
+func (pconn *persistConn) addTLS(name string, trace *httptrace.ClientTrace, nextProtos []string) error {
 	// Initiate TLS and check remote host name against certificate.
 	cfg := cloneTLSConfig(pconn.t.TLSClientConfig)
 	if cfg.ServerName == "" {
 		cfg.ServerName = name
 	}
+	if nextProtos != nil {
+		cfg.NextProtos = nextProtos
+	}
 	if pconn.cacheKey.onlyH1 {
 		cfg.NextProtos = nil
 	}
@@ -1549,6 +1606,210 @@ func (pconn *persistConn) addTLS(name string, trace *httptrace.ClientTrace) erro
 	return nil
 }
 
+// lookupHTTPS looks up DNS HTTPS records using r. It is a variable for testing.
+var lookupHTTPS = (*net.Resolver).LookupHTTPS
+
+// maxHTTPSAliases is the maximum number of AliasMode HTTPS records
+// followed when looking up the endpoints of a server.
+const maxHTTPSAliases = 8
+
+// httpsRecordsWait is how long dialHTTPSRecords waits for the DNS HTTPS
+// records of a server once the usual connection to it has been made.
+// httpsAttemptTimeout limits each attempt to connect to an endpoint
+// that the records advertise. They are variables for testing.
+var (
+	httpsRecordsWait    = 50 * time.Millisecond
+	httpsAttemptTimeout = 5 * time.Second
+)
+
+// An httpsEndpoint is an endpoint of an HTTPS server
+// advertised by its DNS HTTPS records.
+type httpsEndpoint struct {
+	addrs      []string // addresses to try, in order
+	nextProtos []string // ALPN protocols to offer
+}
+
+// dialHTTPSRecords connects to cm's target, preferring the endpoints
+// advertised by its DNS HTTPS records. The records are looked up while
+// the usual connection to cm.addr() is made, which is used if there
+// are no usable records, if none of their endpoints can be reached,
+// or if the lookup takes more than httpsRecordsWait longer than the
+// connection. It returns the connection and the ALPN protocols to
+// offer on it, or nil to offer the usual ones.
+func (t *Transport) dialHTTPSRecords(ctx context.Context, cm connectMethod) (net.Conn, []string, error) {
+	var nextProtos []string
+	if t.TLSClientConfig != nil && !cm.onlyH1 {
+		nextProtos = t.TLSClientConfig.NextProtos
+	}
+	r := t.HTTPSResolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	dialc := make(chan dialResult, 1)
+	go func() {
+		c, err := t.dial(ctx, "tcp", cm.addr())
+		dialc <- dialResult{c, err}
+	}()
+	epc := make(chan []httpsEndpoint, 1)
+	go func() {
+		epc <- httpsEndpoints(ctx, r, cm.targetAddr, nextProtos)
+	}()
+
+	var (
+		direct *dialResult // result of the usual dial, once known
+		eps    []httpsEndpoint
+		wait   <-chan time.Time
+	)
+lookup:
+	for {
+		select {
+		case res := <-dialc:
+			direct = &res
+			if res.err == nil {
+				timer := time.NewTimer(httpsRecordsWait)
+				defer timer.Stop()
+				wait = timer.C
+			}
+		case eps = <-epc:
+			break lookup
+		case <-wait:
+			return direct.conn, nil, nil
+		}
+	}
+	usual := func() dialResult {
+		if direct == nil {
+			res := <-dialc
+			direct = &res
+		}
+		return *direct
+	}
+
+dial:
+	for _, ep := range eps {
+		for _, addr := range ep.addrs {
+			if addr == cm.addr() {
+				// The usual connection reaches this endpoint.
+				if res := usual(); res.err == nil {
+					return res.conn, ep.nextProtos, nil
+				}
+				continue
+			}
+			actx, acancel := context.WithTimeout(ctx, httpsAttemptTimeout)
+			c, err := t.dial(actx, "tcp", addr)
+			acancel()
+			if err == nil {
+				if direct != nil {
+					if direct.conn != nil {
+						direct.conn.Close()
+					}
+				} else {
+					go func() {
+						if res := <-dialc; res.conn != nil {
+							res.conn.Close()
+						}
+					}()
+				}
+				return c, ep.nextProtos, nil
+			}
+			if ctx.Err() != nil {
+				break dial
+			}
+		}
+	}
+	res := usual()
+	return res.conn, nil, res.err
+}
+
+// httpsEndpoints returns the endpoints of the HTTPS server at addr
+// advertised by its DNS HTTPS records, which it looks up using r,
+// in order of preference.
+// Endpoints that support none of the ALPN protocols in nextProtos
+// are skipped. An empty nextProtos means HTTP/1.1 without ALPN.
+func httpsEndpoints(ctx context.Context, r *net.Resolver, addr string, nextProtos []string) []httpsEndpoint {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return nil
+	}
+	// Origins on other ports than 443 use a prefixed name
+	// (RFC 9460, section 9.1).
+	name, target := host, host
+	if port != "443" {
+		name = "_" + port + "._https." + host
+	}
+	var records []*net.SVCB
+	for i := 0; ; i++ {
+		if records, err = lookupHTTPS(r, ctx, name); err != nil || len(records) == 0 {
+			return nil
+		}
+		if records[0].Priority != 0 {
+			break
+		}
+		// AliasMode: the records of another name apply.
+		if i == maxHTTPSAliases || records[0].Target == "." {
+			return nil
+		}
+		name, target = records[0].Target, strings.TrimSuffix(records[0].Target, ".")
+	}
+
+	var eps []httpsEndpoint
+	for _, r := range records {
+		if r.Priority == 0 {
+			// AliasMode records mixed with ServiceMode ones are ignored.
+			continue
+		}
+		protos, ok := httpsNextProtos(r, nextProtos)
+		if !ok {
+			continue
+		}
+		h, p := strings.TrimSuffix(r.Target, "."), port
+		if r.Target == "." {
+			h = target
+		}
+		if r.Port != 0 {
+			p = strconv.Itoa(int(r.Port))
+		}
+		ep := httpsEndpoint{addrs: []string{net.JoinHostPort(h, p)}, nextProtos: protos}
+		for _, ip := range r.IPHint {
+			ep.addrs = append(ep.addrs, net.JoinHostPort(ip.String(), p))
+		}
+		eps = append(eps, ep)
+	}
+	return eps
+}
+
+// httpsNextProtos returns the protocols of nextProtos that the endpoint
+// of the HTTPS record r supports. It reports false if there are none.
+func httpsNextProtos(r *net.SVCB, nextProtos []string) ([]string, bool) {
+	supports := func(proto string) bool {
+		if proto == "http/1.1" && !r.NoDefaultALPN {
+			return true
+		}
+		for _, p := range r.ALPN {
+			if p == proto {
+				return true
+			}
+		}
+		return false
+	}
+	if len(nextProtos) == 0 {
+		return nil, supports("http/1.1")
+	}
+	var protos []string
+	for _, p := range nextProtos {
+		if supports(p) {
+			protos = append(protos, p)
+		}
+	}
+	return protos, len(protos) > 0
+}
+
 type erringRoundTripper interface {
 	RoundTripErr() error
 }
@@ -1597,7 +1858,15 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 			pconn.tlsState = &cs
 		}
 	} else {
-		conn, err := t.dial(ctx, "tcp", cm.addr())
+		var (
+			conn       net.Conn
+			nextProtos []string
+		)
+		if cm.scheme() == "https" && cm.proxyURL == nil && t.UseHTTPSRecords {
+			conn, nextProtos, err = t.dialHTTPSRecords(ctx, cm)
+		} else {
+			conn, err = t.dial(ctx, "tcp", cm.addr())
+		}
 		if err != nil {
 			return nil, wrapErr(err)
 		}
@@ -1607,7 +1876,7 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 			if firstTLSHost, _, err = net.SplitHostPort(cm.addr()); err != nil {
 				return nil, wrapErr(err)
 			}
-			if err = pconn.addTLS(firstTLSHost, trace); err != nil {
+			if err = pconn.addTLS(firstTLSHost, trace, nextProtos); err != nil {
 				return nil, wrapErr(err)
 			}
 		}
@@ -1721,7 +1990,7 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 	}
 
 	if cm.proxyURL != nil && cm.targetScheme == "https" {
-		if err := pconn.addTLS(cm.tlsHost(), trace); err != nil {
+		if err := pconn.addTLS(cm.tlsHost(), trace, nil); err != nil {
 			return nil, err
 		}
 	}
@@ -2183,8 +2452,12 @@ func (pc *persistConn) readLoop() {
 		}
 
 		resp.Body = body
-		if rc.addedGzip && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if ce := resp.Header.Get("Content-Encoding"); rc.addedGzip && strings.EqualFold(ce, "gzip") {
 			resp.Body = &gzipReader{body: body}
+		} else if rc.addedZstd && strings.EqualFold(ce, "zstd") {
+			resp.Body = &zstdReader{body: body}
+		}
+		if resp.Body != body {
 			resp.Header.Del("Content-Encoding")
 			resp.Header.Del("Content-Length")
 			resp.ContentLength = -1
@@ -2468,6 +2741,10 @@ type requestAndChan struct {
 	// set it, only then do we transparently decode the gzip.
 	addedGzip bool
 
+	// addedZstd is like addedGzip, for zstd, which the Transport
+	// also requests if EnableZstd is set.
+	addedZstd bool
+
 	// Optional blocking chan for Expect: 100-continue (for send).
 	// If the request has an "Expect: 100-continue" header and
 	// the server responds 100 Continue, readLoop send a value
@@ -2541,6 +2818,7 @@ func (pc *persistConn) roundTrip(req *transportRequest) (resp *Response, err err
 	// uncompress the gzip stream if we were the layer that
 	// requested it.
 	requestedGzip := false
+	requestedZstd := false
 	if !pc.t.DisableCompression &&
 		req.Header.Get("Accept-Encoding") == "" &&
 		req.Header.Get("Range") == "" &&
@@ -2558,7 +2836,8 @@ func (pc *persistConn) roundTrip(req *transportRequest) (resp *Response, err err
 		// auto-decoding a portion of a gzipped document will just fail
 		// anyway. See https://golang.org/issue/8923
 		requestedGzip = true
-		req.extraHeaders().Set("Accept-Encoding", "gzip")
+		requestedZstd = pc.t.EnableZstd
+		req.extraHeaders().Set("Accept-Encoding", pc.t.acceptEncoding())
 	}
 
 	var continueCh chan struct{}
@@ -2596,6 +2875,7 @@ func (pc *persistConn) roundTrip(req *transportRequest) (resp *Response, err err
 		cancelKey:  req.cancelKey,
 		ch:         resc,
 		addedGzip:  requestedGzip,
+		addedZstd:  requestedZstd,
 		continueCh: continueCh,
 		callerGone: gone,
 	}
@@ -2832,6 +3112,56 @@ func (gz *gzipReader) Close() error {
 	return gz.body.Close()
 }
 
+// acceptEncoding returns the Accept-Encoding header value the Transport
+// sends when it requests compression on its own.
+func (t *Transport) acceptEncoding() string {
+	if t.EnableZstd {
+		return "gzip, zstd"
+	}
+	return "gzip"
+}
+
+// maxZstdWindowSize is the largest zstd window the Transport decodes,
+// the limit RFC 9659 sets for the zstd content coding.
+const maxZstdWindowSize = 8 << 20
+
+// newZstdReader returns a zstd.Reader decoding the zstd encoded
+// response body r.
+func newZstdReader(r io.Reader) *zstd.Reader {
+	zr := zstd.NewReader(r)
+	zr.SetMaxWindowSize(maxZstdWindowSize)
+	return zr
+}
+
+// zstdReader wraps a response body so it can lazily
+// call newZstdReader on the first call to Read
+type zstdReader struct {
+	_    incomparable
+	body *bodyEOFSignal // underlying HTTP/1 response body framing
+	zr   *zstd.Reader   // lazily-initialized zstd reader
+}
+
+func (zs *zstdReader) Read(p []byte) (n int, err error) {
+	if zs.zr == nil {
+		zs.zr = newZstdReader(zs.body)
+	}
+
+	zs.body.mu.Lock()
+	if zs.body.closed {
+		err = errReadOnClosedResBody
+	}
+	zs.body.mu.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+	return zs.zr.Read(p)
+}
+
+func (zs *zstdReader) Close() error {
+	return zs.body.Close()
+}
+
 type tlsHandshakeTimeoutError struct{}
 
 func (tlsHandshakeTimeoutError) Timeout() bool   { return true }
@@ -8,13 +8,18 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"io"
 	"net"
 	"net/http/internal"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Issue 15446: incorrect wrapping of errors when server closes an idle connection.
@@ -260,3 +265,217 @@ func TestTransportBodyAltRewind(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// setLookupHTTPS makes the Transport use the HTTPS records
+// in records, keyed by name, until the test ends. It returns
+// the resolvers passed to the lookups.
+func setLookupHTTPS(t *testing.T, records map[string][]*net.SVCB) *[]*net.Resolver {
+	var resolvers []*net.Resolver
+	old := lookupHTTPS
+	t.Cleanup(func() { lookupHTTPS = old })
+	lookupHTTPS = func(r *net.Resolver, ctx context.Context, name string) ([]*net.SVCB, error) {
+		resolvers = append(resolvers, r)
+		if rs, ok := records[name]; ok {
+			return rs, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return &resolvers
+}
+
+func TestHTTPSEndpoints(t *testing.T) {
+	setLookupHTTPS(t, map[string][]*net.SVCB{
+		"example.com": {
+			{Priority: 1, Target: ".", ALPN: []string{"h2"}},
+			{Priority: 2, Target: "alt.example.net.", Port: 8443, IPHint: []net.IP{net.IPv4(192, 0, 2, 1)}},
+			{Priority: 3, Target: "h3.example.net.", ALPN: []string{"h3"}, NoDefaultALPN: true},
+		},
+		"_8080._https.example.com": {{Priority: 0, Target: "svc.example.org."}},
+		"svc.example.org.":         {{Priority: 1, Target: "."}},
+		"loop.example.com":         {{Priority: 0, Target: "loop.example.com."}},
+		"loop.example.com.":        {{Priority: 0, Target: "loop.example.com."}},
+	})
+	for _, tt := range []struct {
+		addr       string
+		nextProtos []string
+		want       []httpsEndpoint
+	}{
+		{
+			addr:       "example.com:443",
+			nextProtos: []string{"h2", "http/1.1"},
+			want: []httpsEndpoint{
+				{addrs: []string{"example.com:443"}, nextProtos: []string{"h2", "http/1.1"}},
+				{addrs: []string{"alt.example.net:8443", "192.0.2.1:8443"}, nextProtos: []string{"http/1.1"}},
+			},
+		},
+		{
+			addr: "example.com:443",
+			want: []httpsEndpoint{
+				{addrs: []string{"example.com:443"}},
+				{addrs: []string{"alt.example.net:8443", "192.0.2.1:8443"}},
+			},
+		},
+		{
+			addr: "example.com:8080",
+			want: []httpsEndpoint{{addrs: []string{"svc.example.org:8080"}}},
+		},
+		{addr: "loop.example.com:443"},
+		{addr: "unknown.example.com:443"},
+		{addr: "192.0.2.1:443"},
+	} {
+		got := httpsEndpoints(context.Background(), net.DefaultResolver, tt.addr, tt.nextProtos)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("httpsEndpoints(%q, %q) = %+v; want %+v", tt.addr, tt.nextProtos, got, tt.want)
+		}
+	}
+}
+
+func TestTransportUseHTTPSRecords(t *testing.T) {
+	cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := newLocalListener(t)
+	defer ln.Close()
+	srv := &Server{Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Host)
+	})}
+	go srv.Serve(tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}))
+	defer srv.Close()
+
+	resolvers := setLookupHTTPS(t, map[string][]*net.SVCB{
+		"example.com": {
+			// HTTP/2 is not enabled, so this one must be skipped.
+			{Priority: 1, Target: "h2.example.net.", ALPN: []string{"h2"}, NoDefaultALPN: true},
+			{Priority: 2, Target: "down.example.net."},
+			{Priority: 3, Target: "up.example.net.", Port: 8443},
+		},
+	})
+	var (
+		mu     sync.Mutex
+		dialed []string
+	)
+	resolver := &net.Resolver{PreferGo: true}
+	tr := &Transport{
+		UseHTTPSRecords: true,
+		HTTPSResolver:   resolver,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dialed = append(dialed, addr)
+			mu.Unlock()
+			if addr != "up.example.net:8443" {
+				return nil, errors.New("unreachable")
+			}
+			var d net.Dialer
+			return d.DialContext(ctx, network, ln.Addr().String())
+		},
+	}
+	defer tr.CloseIdleConnections()
+	res, err := (&Client{Transport: tr}).Get("https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "example.com" {
+		t.Errorf("server got Host %q; want example.com", body)
+	}
+	// The usual connection is attempted while the records are looked up.
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(dialed)
+	if want := []string{"down.example.net:443", "example.com:443", "up.example.net:8443"}; !reflect.DeepEqual(dialed, want) {
+		t.Errorf("dialed %q; want %q", dialed, want)
+	}
+	if want := []*net.Resolver{resolver}; !reflect.DeepEqual(*resolvers, want) {
+		t.Errorf("records looked up with resolvers %p; want %p", *resolvers, want)
+	}
+}
+
+// newHTTPSRecordsTransport returns a Transport that uses HTTPS records
+// and dials the TLS server at srvAddr for the addresses in up; dials to
+// the addresses in hang block until they are canceled, and dials to
+// any other address fail.
+func newHTTPSRecordsTransport(srvAddr string, up, hang []string) *Transport {
+	return &Transport{
+		UseHTTPSRecords: true,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			for _, a := range hang {
+				if addr == a {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+			}
+			for _, a := range up {
+				if addr == a {
+					var d net.Dialer
+					return d.DialContext(ctx, network, srvAddr)
+				}
+			}
+			return nil, errors.New("unreachable")
+		},
+	}
+}
+
+func TestTransportHTTPSRecordsSlowLookup(t *testing.T) {
+	cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := newLocalListener(t)
+	defer ln.Close()
+	srv := &Server{Handler: HandlerFunc(func(w ResponseWriter, r *Request) {})}
+	go srv.Serve(tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}))
+	defer srv.Close()
+
+	// A lookup that never completes must not hold up the request
+	// once the usual connection has been made.
+	old := lookupHTTPS
+	defer func() { lookupHTTPS = old }()
+	lookupHTTPS = func(r *net.Resolver, ctx context.Context, name string) ([]*net.SVCB, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	tr := newHTTPSRecordsTransport(ln.Addr().String(), []string{"example.com:443"}, nil)
+	defer tr.CloseIdleConnections()
+	res, err := (&Client{Transport: tr}).Get("https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func TestTransportHTTPSRecordsAttemptTimeout(t *testing.T) {
+	cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := newLocalListener(t)
+	defer ln.Close()
+	srv := &Server{Handler: HandlerFunc(func(w ResponseWriter, r *Request) {})}
+	go srv.Serve(tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}))
+	defer srv.Close()
+
+	defer func(d time.Duration) { httpsAttemptTimeout = d }(httpsAttemptTimeout)
+	httpsAttemptTimeout = 10 * time.Millisecond
+	setLookupHTTPS(t, map[string][]*net.SVCB{
+		"example.com": {
+			{Priority: 1, Target: "blackhole.example.net."},
+			{Priority: 2, Target: "up.example.net."},
+		},
+	})
+	// An endpoint that does not answer must not keep the
+	// Transport from trying the next one.
+	tr := newHTTPSRecordsTransport(ln.Addr().String(), []string{"up.example.net:443"}, []string{"blackhole.example.net:443", "example.com:443"})
+	defer tr.CloseIdleConnections()
+	res, err := (&Client{Transport: tr}).Get("https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
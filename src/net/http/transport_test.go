@@ -13,6 +13,7 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"compress/zstd"
 	"context"
 	"crypto/rand"
 	"crypto/tls"
@@ -1122,6 +1123,71 @@ func TestTransportGzip(t *testing.T) {
 	}
 }
 
+func TestTransportZstd(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	const testString = "The test string zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz"
+	// An empty zstd frame declaring a 16 MB window, more than RFC 9659
+	// permits for the zstd content coding.
+	bigWindow := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 14 << 3, 0x01, 0x00, 0x00}
+	ts := httptest.NewServer(HandlerFunc(func(rw ResponseWriter, req *Request) {
+		rw.Header().Set("Content-Encoding", "zstd")
+		if req.FormValue("bigwindow") != "" {
+			rw.Write(bigWindow)
+			return
+		}
+		zw := zstd.NewWriter(rw)
+		zw.Write([]byte(testString))
+		zw.Close()
+	}))
+	defer ts.Close()
+
+	get := func(tr *Transport, url string) (*Response, []byte, error) {
+		t.Helper()
+		res, err := (&Client{Transport: tr}).Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		return res, body, err
+	}
+
+	// Without EnableZstd, the Transport neither asks for nor decodes zstd.
+	tr := &Transport{}
+	defer tr.CloseIdleConnections()
+	res, body, err := get(tr, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := res.Header.Get("Content-Encoding"); g != "zstd" || res.Uncompressed {
+		t.Errorf("default Transport: Content-Encoding = %q, Uncompressed = %v; want zstd, false", g, res.Uncompressed)
+	}
+
+	tr = &Transport{EnableZstd: true}
+	defer tr.CloseIdleConnections()
+	res, body, err = get(tr, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != testString {
+		t.Errorf("body = %q; want %q", body, testString)
+	}
+	if g := res.Header.Get("Content-Encoding"); g != "" {
+		t.Errorf("Content-Encoding = %q; want none", g)
+	}
+	if !res.Uncompressed {
+		t.Errorf("Uncompressed = false; want true")
+	}
+	if n, err := res.Body.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Errorf("expected Read error after Close; got %d, %v", n, err)
+	}
+
+	if _, _, err := get(tr, ts.URL+"/?bigwindow=1"); err == nil {
+		t.Errorf("reading response with a 16 MB zstd window succeeded; want error")
+	}
+}
+
 // If a request has Expect:100-continue header, the request blocks sending body until the first response.
 // Premature consumption of the request body should not be occurred.
 func TestTransportExpect100Continue(t *testing.T) {
@@ -5883,6 +5949,7 @@ func TestTransportClone(t *testing.T) {
 		TLSHandshakeTimeout:    time.Second,
 		DisableKeepAlives:      true,
 		DisableCompression:     true,
+		EnableZstd:             true,
 		MaxIdleConns:           1,
 		MaxIdleConnsPerHost:    1,
 		MaxConnsPerHost:        1,
@@ -5893,6 +5960,9 @@ func TestTransportClone(t *testing.T) {
 		GetProxyConnectHeader:  func(context.Context, *url.URL, string) (Header, error) { return nil, nil },
 		MaxResponseHeaderBytes: 1,
 		ForceAttemptHTTP2:      true,
+		UseHTTPSRecords:        true,
+		HTTPSResolver:          &net.Resolver{},
+		HTTP2:                  &HTTP2Config{MaxConcurrentStreams: 1},
 		TLSNextProto: map[string]func(authority string, c *tls.Conn) RoundTripper{
 			"foo": func(authority string, c *tls.Conn) RoundTripper { panic("") },
 		},
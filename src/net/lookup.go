@@ -145,16 +145,43 @@ type Resolver struct {
 	// If nil, the default dialer is used.
 	Dial func(ctx context.Context, network, address string) (Conn, error)
 
+	// EncryptedDNS, if non-nil, makes Go's built-in DNS resolver send
+	// queries over DNS over TLS or DNS over HTTPS, overriding any
+	// encrypted DNS options in /etc/resolv.conf.
+	// Setting it implies PreferGo. On systems where the Go resolver
+	// is not available, lookups by a Resolver with an EncryptedDNS
+	// fail rather than use unencrypted DNS.
+	EncryptedDNS *EncryptedDNS
+
+	// DialTLS specifies the function used by Go's built-in DNS
+	// resolver to connect to encrypted DNS servers. It must return
+	// a connection to address on the named network ("tcp") that
+	// has completed a TLS handshake verifying serverName, typically
+	// using crypto/tls:
+	//
+	//	d := &tls.Dialer{Config: &tls.Config{ServerName: serverName}}
+	//	return d.DialContext(ctx, network, address)
+	//
+	// As with Dial, the host in address is always a literal IP address.
+	// DNS over HTTPS is spoken as HTTP/1.1 on the connection, so the
+	// TLS configuration must not require another application protocol.
+	// If DialTLS is nil, lookups using EncryptedDNS fail, and the
+	// encrypted DNS options in /etc/resolv.conf are ignored.
+	DialTLS func(ctx context.Context, network, address, serverName string) (Conn, error)
+
 	// lookupGroup merges LookupIPAddr calls together for lookups for the same
 	// host. The lookupGroup key is the LookupIPAddr.host argument.
 	// The return values are ([]IPAddr, error).
 	lookupGroup singleflight.Group
 
+	// dnsConns holds idle connections to encrypted DNS servers.
+	dnsConns dnsConnCache
+
 	// TODO(bradfitz): optional interface impl override hook
 	// TODO(bradfitz): Timeout time.Duration?
 }
 
-func (r *Resolver) preferGo() bool     { return r != nil && r.PreferGo }
+func (r *Resolver) preferGo() bool     { return r != nil && (r.PreferGo || r.EncryptedDNS != nil) }
 func (r *Resolver) strictErrors() bool { return r != nil && r.StrictErrors }
 
 func (r *Resolver) getLookupGroup() *singleflight.Group {
@@ -181,6 +208,9 @@ func (r *Resolver) LookupHost(ctx context.Context, host string) (addrs []string,
 	if ip, _ := parseIPZone(host); ip != nil {
 		return []string{host}, nil
 	}
+	if err := r.checkEncryptedDNS(host); err != nil {
+		return nil, err
+	}
 	return r.lookupHost(ctx, host)
 }
 
@@ -267,6 +297,9 @@ func (r *Resolver) lookupIPAddr(ctx context.Context, network, host string) ([]IP
 	if ip, zone := parseIPZone(host); ip != nil {
 		return []IPAddr{{IP: ip, Zone: zone}}, nil
 	}
+	if err := r.checkEncryptedDNS(host); err != nil {
+		return nil, err
+	}
 	trace, _ := ctx.Value(nettrace.TraceKey{}).(*nettrace.Trace)
 	if trace != nil && trace.DNSStart != nil {
 		trace.DNSStart(host)
@@ -390,7 +423,7 @@ func (r *Resolver) LookupPort(ctx context.Context, network, service string) (por
 // contain DNS "CNAME" records, as long as host resolves to
 // address records.
 func LookupCNAME(host string) (cname string, err error) {
-	return DefaultResolver.lookupCNAME(context.Background(), host)
+	return DefaultResolver.LookupCNAME(context.Background(), host)
 }
 
 // LookupCNAME returns the canonical name for the given host.
@@ -404,6 +437,9 @@ func LookupCNAME(host string) (cname string, err error) {
 // contain DNS "CNAME" records, as long as host resolves to
 // address records.
 func (r *Resolver) LookupCNAME(ctx context.Context, host string) (cname string, err error) {
+	if err := r.checkEncryptedDNS(host); err != nil {
+		return "", err
+	}
 	return r.lookupCNAME(ctx, host)
 }
 
@@ -417,7 +453,7 @@ func (r *Resolver) LookupCNAME(ctx context.Context, host string) (cname string,
 // publishing SRV records under non-standard names, if both service
 // and proto are empty strings, LookupSRV looks up name directly.
 func LookupSRV(service, proto, name string) (cname string, addrs []*SRV, err error) {
-	return DefaultResolver.lookupSRV(context.Background(), service, proto, name)
+	return DefaultResolver.LookupSRV(context.Background(), service, proto, name)
 }
 
 // LookupSRV tries to resolve an SRV query of the given service,
@@ -430,50 +466,117 @@ func LookupSRV(service, proto, name string) (cname string, addrs []*SRV, err err
 // publishing SRV records under non-standard names, if both service
 // and proto are empty strings, LookupSRV looks up name directly.
 func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*SRV, err error) {
+	if err := r.checkEncryptedDNS(name); err != nil {
+		return "", nil, err
+	}
 	return r.lookupSRV(ctx, service, proto, name)
 }
 
 // LookupMX returns the DNS MX records for the given domain name sorted by preference.
 func LookupMX(name string) ([]*MX, error) {
-	return DefaultResolver.lookupMX(context.Background(), name)
+	return DefaultResolver.LookupMX(context.Background(), name)
 }
 
 // LookupMX returns the DNS MX records for the given domain name sorted by preference.
 func (r *Resolver) LookupMX(ctx context.Context, name string) ([]*MX, error) {
+	if err := r.checkEncryptedDNS(name); err != nil {
+		return nil, err
+	}
 	return r.lookupMX(ctx, name)
 }
 
 // LookupNS returns the DNS NS records for the given domain name.
 func LookupNS(name string) ([]*NS, error) {
-	return DefaultResolver.lookupNS(context.Background(), name)
+	return DefaultResolver.LookupNS(context.Background(), name)
 }
 
 // LookupNS returns the DNS NS records for the given domain name.
 func (r *Resolver) LookupNS(ctx context.Context, name string) ([]*NS, error) {
+	if err := r.checkEncryptedDNS(name); err != nil {
+		return nil, err
+	}
 	return r.lookupNS(ctx, name)
 }
 
 // LookupTXT returns the DNS TXT records for the given domain name.
 func LookupTXT(name string) ([]string, error) {
-	return DefaultResolver.lookupTXT(context.Background(), name)
+	return DefaultResolver.LookupTXT(context.Background(), name)
 }
 
 // LookupTXT returns the DNS TXT records for the given domain name.
 func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if err := r.checkEncryptedDNS(name); err != nil {
+		return nil, err
+	}
 	return r.lookupTXT(ctx, name)
 }
 
+// LookupHTTPS returns the DNS HTTPS records for the given domain name,
+// sorted by priority. See SVCB for how to interpret them.
+//
+// To look up the records for an origin with a port other than 443,
+// look up "_port._https." followed by the domain name, as described
+// in RFC 9460, section 9.1.
+//
+// LookupHTTPS is only supported by the Go resolver on Unix systems;
+// on Windows, Plan 9 and js/wasm it always returns an error.
+func LookupHTTPS(name string) ([]*SVCB, error) {
+	return DefaultResolver.LookupHTTPS(context.Background(), name)
+}
+
+// LookupHTTPS returns the DNS HTTPS records for the given domain name,
+// sorted by priority. See SVCB for how to interpret them.
+//
+// To look up the records for an origin with a port other than 443,
+// look up "_port._https." followed by the domain name, as described
+// in RFC 9460, section 9.1.
+//
+// LookupHTTPS is only supported by the Go resolver on Unix systems;
+// on Windows, Plan 9 and js/wasm it always returns an error.
+func (r *Resolver) LookupHTTPS(ctx context.Context, name string) ([]*SVCB, error) {
+	if err := r.checkEncryptedDNS(name); err != nil {
+		return nil, err
+	}
+	return r.lookupSVCB(ctx, name, true)
+}
+
+// LookupSVCB returns the DNS SVCB records for the given domain name,
+// sorted by priority. The name usually has a prefix that selects the
+// service, such as "_dns." or "_8443._foo.".
+//
+// LookupSVCB is only supported by the Go resolver on Unix systems;
+// on Windows, Plan 9 and js/wasm it always returns an error.
+func LookupSVCB(name string) ([]*SVCB, error) {
+	return DefaultResolver.LookupSVCB(context.Background(), name)
+}
+
+// LookupSVCB returns the DNS SVCB records for the given domain name,
+// sorted by priority. The name usually has a prefix that selects the
+// service, such as "_dns." or "_8443._foo.".
+//
+// LookupSVCB is only supported by the Go resolver on Unix systems;
+// on Windows, Plan 9 and js/wasm it always returns an error.
+func (r *Resolver) LookupSVCB(ctx context.Context, name string) ([]*SVCB, error) {
+	if err := r.checkEncryptedDNS(name); err != nil {
+		return nil, err
+	}
+	return r.lookupSVCB(ctx, name, false)
+}
+
 // LookupAddr performs a reverse lookup for the given address, returning a list
 // of names mapping to that address.
 //
 // When using the host C library resolver, at most one result will be
 // returned. To bypass the host resolver, use a custom Resolver.
 func LookupAddr(addr string) (names []string, err error) {
-	return DefaultResolver.lookupAddr(context.Background(), addr)
+	return DefaultResolver.LookupAddr(context.Background(), addr)
 }
 
 // LookupAddr performs a reverse lookup for the given address, returning a list
 // of names mapping to that address.
 func (r *Resolver) LookupAddr(ctx context.Context, addr string) (names []string, err error) {
+	if err := r.checkEncryptedDNS(addr); err != nil {
+		return nil, err
+	}
 	return r.lookupAddr(ctx, addr)
 }
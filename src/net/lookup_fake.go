@@ -47,6 +47,10 @@ func (*Resolver) lookupTXT(ctx context.Context, name string) (txts []string, err
 	return nil, syscall.ENOPROTOOPT
 }
 
+func (*Resolver) lookupSVCB(ctx context.Context, name string, https bool) ([]*SVCB, error) {
+	return nil, syscall.ENOPROTOOPT
+}
+
 func (*Resolver) lookupAddr(ctx context.Context, addr string) (ptrs []string, err error) {
 	return nil, syscall.ENOPROTOOPT
 }
@@ -10,6 +10,7 @@ import (
 	"internal/bytealg"
 	"io"
 	"os"
+	"syscall"
 )
 
 func query(ctx context.Context, filename, query string, bufSize int) (addrs []string, err error) {
@@ -314,6 +315,11 @@ func (*Resolver) lookupTXT(ctx context.Context, name string) (txt []string, err
 	return
 }
 
+func (*Resolver) lookupSVCB(ctx context.Context, name string, https bool) ([]*SVCB, error) {
+	// TODO: query SVCB and HTTPS records with the system resolver.
+	return nil, &DNSError{Err: syscall.EPLAN9.Error(), Name: name}
+}
+
 func (*Resolver) lookupAddr(ctx context.Context, addr string) (name []string, err error) {
 	arpa, err := reverseaddr(addr)
 	if err != nil {
@@ -9,6 +9,7 @@ package net
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"internal/testenv"
 	"reflect"
@@ -1266,3 +1267,43 @@ func TestResolverLookupIP(t *testing.T) {
 		})
 	}
 }
+
+func TestEncryptedDNSUnsupported(t *testing.T) {
+	if encryptedDNSSupported {
+		t.Skip("encrypted DNS is supported on " + runtime.GOOS)
+	}
+	// A Resolver that asks for encrypted DNS must not fall back to
+	// the system resolver, which sends queries in the clear.
+	r := &Resolver{EncryptedDNS: &EncryptedDNS{Protocol: "tls"}}
+	ctx := context.Background()
+	check := func(name string, err error) {
+		t.Helper()
+		var dnsErr *DNSError
+		if !errors.As(err, &dnsErr) || dnsErr.Err != errEncryptedDNSUnsupported.Error() {
+			t.Errorf("%s: got error %v, want %v", name, err, errEncryptedDNSUnsupported)
+		}
+	}
+	_, err := r.LookupHost(ctx, "golang.org")
+	check("LookupHost", err)
+	_, err = r.LookupIPAddr(ctx, "golang.org")
+	check("LookupIPAddr", err)
+	_, err = r.LookupCNAME(ctx, "golang.org")
+	check("LookupCNAME", err)
+	_, _, err = r.LookupSRV(ctx, "xmpp-server", "tcp", "golang.org")
+	check("LookupSRV", err)
+	_, err = r.LookupMX(ctx, "golang.org")
+	check("LookupMX", err)
+	_, err = r.LookupNS(ctx, "golang.org")
+	check("LookupNS", err)
+	_, err = r.LookupTXT(ctx, "golang.org")
+	check("LookupTXT", err)
+	_, err = r.LookupHTTPS(ctx, "golang.org")
+	check("LookupHTTPS", err)
+	_, err = r.LookupAddr(ctx, "192.0.2.1")
+	check("LookupAddr", err)
+
+	// Literal addresses need no lookup.
+	if addrs, err := r.LookupHost(ctx, "192.0.2.1"); err != nil || len(addrs) != 1 {
+		t.Errorf("LookupHost(192.0.2.1) = %v, %v; want [192.0.2.1], nil", addrs, err)
+	}
+}
@@ -319,6 +319,35 @@ func (r *Resolver) lookupTXT(ctx context.Context, name string) ([]string, error)
 	return txts, nil
 }
 
+// lookupSVCB looks up the SVCB records for name,
+// or its HTTPS records if https is set.
+func (r *Resolver) lookupSVCB(ctx context.Context, name string, https bool) ([]*SVCB, error) {
+	qtype := dnsTypeSVCB
+	if https {
+		qtype = dnsTypeHTTPS
+	}
+	p, server, err := r.lookup(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	records, ok := answerData(p.msg, qtype)
+	if !ok {
+		return nil, &DNSError{
+			Err:    "cannot unmarshal DNS message",
+			Name:   name,
+			Server: server,
+		}
+	}
+	var svcbs []*SVCB
+	for _, b := range records {
+		if s, ok := newSVCB(b); ok {
+			svcbs = append(svcbs, s)
+		}
+	}
+	bySVCBPriority(svcbs).sort()
+	return svcbs, nil
+}
+
 func (r *Resolver) lookupAddr(ctx context.Context, addr string) ([]string, error) {
 	if !r.preferGo() && systemConf().canUseCgo() {
 		if ptrs, err, ok := cgoLookupPTR(ctx, addr); ok {
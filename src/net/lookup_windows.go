@@ -326,6 +326,11 @@ func (*Resolver) lookupTXT(ctx context.Context, name string) ([]string, error) {
 	return txts, nil
 }
 
+func (*Resolver) lookupSVCB(ctx context.Context, name string, https bool) ([]*SVCB, error) {
+	// TODO: query SVCB and HTTPS records with the system resolver.
+	return nil, &DNSError{Err: syscall.EWINDOWS.Error(), Name: name}
+}
+
 func (*Resolver) lookupAddr(ctx context.Context, addr string) ([]string, error) {
 	// TODO(bradfitz): finish ctx plumbing. Nothing currently depends on this.
 	acquireThread()
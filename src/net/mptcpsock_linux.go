@@ -0,0 +1,136 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/poll"
+	"sync"
+	"syscall"
+)
+
+var (
+	mptcpOnce      sync.Once
+	mptcpAvailable bool
+	hasSOLMPTCP    bool
+)
+
+// These constants aren't in the syscall package, which is frozen.
+const (
+	_IPPROTO_MPTCP = 0x106
+	_SOL_MPTCP     = 0x11c
+	_MPTCP_INFO    = 0x1
+)
+
+func supportsMultipathTCP() bool {
+	mptcpOnce.Do(initMPTCPavailable)
+	return mptcpAvailable
+}
+
+// initMPTCPavailable checks that MPTCP is supported by attempting to
+// create an MPTCP socket and by looking at the returned error if any.
+func initMPTCPavailable() {
+	s, err := sysSocket(syscall.AF_INET, syscall.SOCK_STREAM, _IPPROTO_MPTCP)
+	switch {
+	case errors.Is(err, syscall.EPROTONOSUPPORT): // Not supported: >= v5.6
+	case errors.Is(err, syscall.EINVAL): // Not supported: < v5.6
+	case err == nil: // Supported and no error
+		poll.CloseFunc(s)
+		fallthrough
+	default:
+		// Another error: MPTCP was not available but it might be later.
+		mptcpAvailable = true
+	}
+
+	major, minor := kernelVersion()
+	// SOL_MPTCP is only supported from Linux 5.16.
+	hasSOLMPTCP = major > 5 || (major == 5 && minor >= 16)
+}
+
+func (sd *sysDialer) dialMPTCP(ctx context.Context, laddr, raddr *TCPAddr) (*TCPConn, error) {
+	if supportsMultipathTCP() {
+		if conn, err := sd.doDialTCPProto(ctx, laddr, raddr, _IPPROTO_MPTCP); err == nil {
+			return conn, nil
+		}
+	}
+
+	// Fall back to dialTCP if Multipath TCP isn't supported on this
+	// operating system, but also in case of any error with MPTCP.
+	//
+	// A possible MPTCP specific error is ENOPROTOOPT, when the
+	// net.mptcp.enabled sysctl is 0. But in case MPTCP is blocked in
+	// another way (SELinux, seccomp, etc.), retry with plain TCP.
+	return sd.dialTCP(ctx, laddr, raddr)
+}
+
+func (sl *sysListener) listenMPTCP(ctx context.Context, laddr *TCPAddr) (*TCPListener, error) {
+	if supportsMultipathTCP() {
+		if ln, err := sl.listenTCPProto(ctx, laddr, _IPPROTO_MPTCP); err == nil {
+			return ln, nil
+		}
+	}
+
+	// Fall back to listenTCP if Multipath TCP isn't supported on this
+	// operating system, but also in case of any error with MPTCP, as
+	// in dialMPTCP.
+	return sl.listenTCP(ctx, laddr)
+}
+
+// getsockoptInt returns the value of the integer socket option
+// level/opt of fd.
+func getsockoptInt(fd *netFD, level, opt int) (v int, err error) {
+	cerr := fd.pfd.RawControl(func(s uintptr) {
+		v, err = syscall.GetsockoptInt(int(s), level, opt)
+	})
+	if cerr != nil {
+		return 0, cerr
+	}
+	return v, err
+}
+
+// hasFallenBack reports whether the MPTCP connection has fallen back
+// to plain TCP.
+//
+// A connection can fall back to TCP for different reasons, e.g. the
+// peer doesn't support MPTCP or a middlebox drops the MPTCP options.
+// If MPTCP was not requested when creating the socket, hasFallenBack
+// returns true: MPTCP is not being used.
+//
+// Linux 5.16 and later return EOPNOTSUPP or ENOPROTOOPT in case of
+// fallback. Older kernels always return them, even if MPTCP is used,
+// so hasFallenBack can't be used on them.
+func hasFallenBack(fd *netFD) bool {
+	_, err := getsockoptInt(fd, _SOL_MPTCP, _MPTCP_INFO)
+
+	// The expected error in case of fallback depends on the
+	// address family: EOPNOTSUPP for AF_INET and ENOPROTOOPT
+	// for AF_INET6.
+	return err == syscall.EOPNOTSUPP || err == syscall.ENOPROTOOPT
+}
+
+// isUsingMPTCPProto reports whether the socket protocol is MPTCP.
+//
+// Unlike hasFallenBack, it only checks the protocol of the socket:
+// an MPTCP socket may still have fallen back to TCP on the wire.
+func isUsingMPTCPProto(fd *netFD) bool {
+	proto, _ := getsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_PROTOCOL)
+	return proto == _IPPROTO_MPTCP
+}
+
+// isUsingMultipathTCP reports whether MPTCP is still being used.
+//
+// See hasFallenBack and isUsingMPTCPProto for what is checked
+// depending on the kernel version.
+func isUsingMultipathTCP(fd *netFD) bool {
+	if !supportsMultipathTCP() {
+		return false
+	}
+
+	if hasSOLMPTCP {
+		return !hasFallenBack(fd)
+	}
+	return isUsingMPTCPProto(fd)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"testing"
+)
+
+func newLocalListenerMPTCP(t *testing.T, network, address string, mptcp bool) Listener {
+	lc := &ListenConfig{}
+	if mptcp {
+		if lc.MultipathTCP() {
+			t.Error("MultipathTCP should be off by default")
+		}
+		lc.SetMultipathTCP(true)
+		if !lc.MultipathTCP() {
+			t.Fatal("SetMultipathTCP(true) didn't enable MultipathTCP")
+		}
+	}
+	ln, err := lc.Listen(context.Background(), network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+func checkMultipathTCP(t *testing.T, side string, c Conn, want bool) {
+	tcp, ok := c.(*TCPConn)
+	if !ok {
+		t.Fatalf("%s: got %T, want *TCPConn", side, c)
+	}
+	got, err := tcp.MultipathTCP()
+	if err != nil {
+		t.Fatalf("%s: MultipathTCP: %v", side, err)
+	}
+	if got != want {
+		t.Errorf("%s: MultipathTCP = %v, want %v", side, got, want)
+	}
+}
+
+func dialerMPTCP(t *testing.T, ln Listener, clientMPTCP, serverMPTCP bool) {
+	done := make(chan Conn, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+		}
+		done <- c
+	}()
+
+	d := &Dialer{}
+	if clientMPTCP {
+		d.SetMultipathTCP(true)
+	}
+	c, err := d.Dial(ln.Addr().Network(), ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	sc := <-done
+	if sc == nil {
+		return
+	}
+	defer sc.Close()
+
+	// MPTCP is only used if both sides asked for it. On kernels
+	// before 5.16 only the socket protocol can be checked.
+	checkMultipathTCP(t, "client", c, clientMPTCP && (serverMPTCP || !hasSOLMPTCP))
+	checkMultipathTCP(t, "server", sc, serverMPTCP && (clientMPTCP || !hasSOLMPTCP))
+}
+
+func TestMultipathTCP(t *testing.T) {
+	if !supportsMultipathTCP() {
+		t.Skip("MPTCP is not supported on this kernel")
+	}
+
+	for _, network := range []string{"tcp4", "tcp6"} {
+		if network == "tcp6" && !supportsIPv6() {
+			continue
+		}
+		address := "127.0.0.1:0"
+		if network == "tcp6" {
+			address = "[::1]:0"
+		}
+		for _, tt := range []struct{ client, server bool }{
+			{true, true},
+			{true, false},
+			{false, true},
+		} {
+			ln := newLocalListenerMPTCP(t, network, address, tt.server)
+			dialerMPTCP(t, ln, tt.client, tt.server)
+			ln.Close()
+		}
+	}
+}
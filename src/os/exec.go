@@ -125,6 +125,9 @@ func (p *Process) Kill() error {
 // Wait releases any resources associated with the Process.
 // On most operating systems, the Process must be a child
 // of the current process or an error will be returned.
+// On Linux, if the Process was returned by FindProcess for a
+// process that is not a child, Wait waits for it to exit and then
+// returns an error wrapping syscall.ECHILD.
 func (p *Process) Wait() (*ProcessState, error) {
 	return p.wait()
 }
@@ -11,6 +11,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"internal/poll"
 	"internal/testenv"
@@ -915,6 +916,16 @@ func TestHelperProcess(*testing.T) {
 	case "sleep":
 		time.Sleep(3 * time.Second)
 		os.Exit(0)
+	case "leakstdout":
+		// Start a subprocess that keeps our standard output open
+		// after we exit.
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", "sleep")
+		cmd.Stdout = os.Stdout
+		if err := cmd.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Start: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command %q\n", cmd)
 		os.Exit(2)
@@ -1107,6 +1118,103 @@ func TestContextCancel(t *testing.T) {
 	}
 }
 
+func TestContextCancelFunc(t *testing.T) {
+	errCancel := errors.New("cancel failed")
+	tests := []struct {
+		name   string
+		cancel func(stdin io.Closer) error
+		check  func(err error) bool
+	}{
+		{
+			name:   "success",
+			cancel: func(stdin io.Closer) error { return stdin.Close() },
+			check:  func(err error) bool { return errors.Is(err, context.Canceled) },
+		},
+		{
+			name: "error",
+			cancel: func(stdin io.Closer) error {
+				stdin.Close()
+				return errCancel
+			},
+			check: func(err error) bool { return errors.Is(err, errCancel) },
+		},
+		{
+			name: "processdone",
+			cancel: func(stdin io.Closer) error {
+				stdin.Close()
+				return os.ErrProcessDone
+			},
+			check: func(err error) bool { return err == nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c := helperCommandContext(t, ctx, "cat")
+			stdin, err := c.StdinPipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Cancel = func() error { return tt.cancel(stdin) }
+			if err := c.Start(); err != nil {
+				t.Fatal(err)
+			}
+			cancel()
+			if err := c.Wait(); !tt.check(err) {
+				t.Errorf("Wait: unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestCancelWithoutContext(t *testing.T) {
+	c := helperCommand(t, "echo")
+	c.Cancel = func() error { return nil }
+	if err := c.Start(); err == nil {
+		c.Wait()
+		t.Fatal("Start succeeded for command with Cancel but no Context")
+	}
+}
+
+func TestWaitDelayKill(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := helperCommandContext(t, ctx, "cat")
+	// Leave stdin open and do nothing on cancellation,
+	// so that only WaitDelay stops the command.
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	c.Cancel = nil
+	c.WaitDelay = 100 * time.Millisecond
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	err = c.Wait()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Errorf("Wait = %v; want *exec.ExitError from killed process", err)
+	}
+}
+
+func TestWaitDelayPipes(t *testing.T) {
+	c := helperCommand(t, "leakstdout")
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.WaitDelay = 100 * time.Millisecond
+	start := time.Now()
+	if err := c.Run(); err != exec.ErrWaitDelay {
+		t.Errorf("Run = %v; want %v", err, exec.ErrWaitDelay)
+	}
+	// The subprocess holding the pipe sleeps for 3 seconds.
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Run took %v; WaitDelay did not stop waiting for stdout", d)
+	}
+}
+
 // test that environment variables are de-duped.
 func TestDedupEnvEcho(t *testing.T) {
 	testenv.MustHaveExec(t)
@@ -5,6 +5,7 @@
 package os
 
 import (
+	"errors"
 	"runtime"
 	"syscall"
 	"time"
@@ -95,6 +96,12 @@ func findProcess(pid int) (p *Process, err error) {
 	return newProcess(pid, 0), nil
 }
 
+// PidFD returns a new file descriptor referring to the process,
+// a Linux pidfd. It is not supported on Plan 9.
+func (p *Process) PidFD() (int, error) {
+	return -1, errors.New("os: pidfd not supported on this system")
+}
+
 // ProcessState stores information about a process, as reported by Wait.
 type ProcessState struct {
 	pid    int              // The process's id.
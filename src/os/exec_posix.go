@@ -45,6 +45,8 @@ func startProcess(name string, argv []string, attr *ProcAttr) (p *Process, err e
 			return nil, err
 		}
 	}
+	var needDup bool
+	sysattr.Sys, needDup = ensurePidfd(sysattr.Sys)
 	sysattr.Files = make([]uintptr, 0, len(attr.Files))
 	for _, f := range attr.Files {
 		sysattr.Files = append(sysattr.Files, f.Fd())
@@ -59,6 +61,11 @@ func startProcess(name string, argv []string, attr *ProcAttr) (p *Process, err e
 		return nil, &PathError{Op: "fork/exec", Path: name, Err: e}
 	}
 
+	// For Windows, syscall.StartProcess above already returned a process handle.
+	if runtime.GOOS != "windows" {
+		h = getPidfd(sysattr.Sys, needDup)
+	}
+
 	return newProcess(pid, h), nil
 }
 
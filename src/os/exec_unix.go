@@ -18,6 +18,11 @@ func (p *Process) wait() (ps *ProcessState, err error) {
 		return nil, syscall.EINVAL
 	}
 
+	// Use the pidfd, if we have one, to wait without tying up a thread.
+	if handle, ok := p.pidfd(); ok {
+		return p.pidfdWait(handle)
+	}
+
 	// If we can block until Wait4 will succeed immediately, do so.
 	ready, err := p.blockUntilWaitable()
 	if err != nil {
@@ -75,6 +80,9 @@ func (p *Process) signal(sig Signal) error {
 	if !ok {
 		return errors.New("os: unsupported signal type")
 	}
+	if handle, ok := p.pidfd(); ok {
+		return p.pidfdSendSignal(handle, s)
+	}
 	if e := syscall.Kill(p.Pid, s); e != nil {
 		if e == syscall.ESRCH {
 			return ErrProcessDone
@@ -85,7 +93,7 @@ func (p *Process) signal(sig Signal) error {
 }
 
 func (p *Process) release() error {
-	// NOOP for unix.
+	p.pidfdRelease()
 	p.Pid = -1
 	// no need for a finalizer anymore
 	runtime.SetFinalizer(p, nil)
@@ -93,8 +101,9 @@ func (p *Process) release() error {
 }
 
 func findProcess(pid int) (p *Process, err error) {
-	// NOOP for unix.
-	return newProcess(pid, 0), nil
+	// On Linux, open a pidfd for the process if we can;
+	// on other Unix systems, this is a NOOP.
+	return newProcess(pid, pidfdFind(pid)), nil
 }
 
 func (p *ProcessState) userTime() time.Duration {
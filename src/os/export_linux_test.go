@@ -4,4 +4,25 @@
 
 package os
 
+import "sync/atomic"
+
 var PollCopyFileRangeP = &pollCopyFileRange
+
+// DisableOpenat2 makes Root methods resolve paths without openat2,
+// and returns a function that restores the previous behavior.
+func DisableOpenat2() (restore func()) {
+	old := atomic.LoadInt32(&openat2Unsupported)
+	atomic.StoreInt32(&openat2Unsupported, 1)
+	return func() { atomic.StoreInt32(&openat2Unsupported, old) }
+}
+
+var PidfdWorks = pidfdWorks
+
+// DisablePidfd makes the os package behave as if pidfds were not
+// supported, and returns a function that restores the previous behavior.
+func DisablePidfd() (restore func()) {
+	pidfdOnce.Do(func() { pidfdOK = checkPidfd() == nil })
+	old := pidfdOK
+	pidfdOK = false
+	return func() { pidfdOK = old }
+}
@@ -46,6 +46,7 @@ import (
 	"internal/testlog"
 	"io"
 	"io/fs"
+	"path"
 	"runtime"
 	"syscall"
 	"time"
@@ -616,6 +617,9 @@ func isWindowsNulName(name string) bool {
 // the /prefix tree, then using DirFS does not stop the access any more than using
 // os.Open does. DirFS is therefore not a general substitute for a chroot-style security
 // mechanism when the directory tree contains arbitrary content.
+//
+// The result implements fs.CreateFS, fs.WriteFileFS, fs.MkdirFS, fs.RemoveFS,
+// fs.RenameFS, fs.ChmodFS and fs.SymlinkFS.
 func DirFS(dir string) fs.FS {
 	return dirFS(dir)
 }
@@ -634,16 +638,100 @@ func containsAny(s, chars string) bool {
 type dirFS string
 
 func (dir dirFS) Open(name string) (fs.File, error) {
-	if !fs.ValidPath(name) || runtime.GOOS == "windows" && containsAny(name, `\:`) {
-		return nil, &PathError{Op: "open", Path: name, Err: ErrInvalid}
+	fullname, err := dir.join("open", name)
+	if err != nil {
+		return nil, err
 	}
-	f, err := Open(string(dir) + "/" + name)
+	f, err := Open(fullname)
 	if err != nil {
 		return nil, err // nil fs.File
 	}
 	return f, nil
 }
 
+// join returns the path for name in dir,
+// or an error reporting op if name is not a valid path.
+func (dir dirFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) || runtime.GOOS == "windows" && containsAny(name, `\:`) {
+		return "", &PathError{Op: op, Path: name, Err: ErrInvalid}
+	}
+	return string(dir) + "/" + name, nil
+}
+
+func (dir dirFS) Create(name string, perm FileMode) (fs.WriterFile, error) {
+	fullname, err := dir.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := OpenFile(fullname, O_RDWR|O_CREATE|O_TRUNC, perm)
+	if err != nil {
+		return nil, err // nil fs.WriterFile
+	}
+	return f, nil
+}
+
+func (dir dirFS) WriteFile(name string, data []byte, perm FileMode) error {
+	fullname, err := dir.join("open", name)
+	if err != nil {
+		return err
+	}
+	return WriteFile(fullname, data, perm)
+}
+
+func (dir dirFS) Mkdir(name string, perm FileMode) error {
+	fullname, err := dir.join("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return Mkdir(fullname, perm)
+}
+
+func (dir dirFS) Remove(name string) error {
+	fullname, err := dir.join("remove", name)
+	if err != nil {
+		return err
+	}
+	return Remove(fullname)
+}
+
+func (dir dirFS) Rename(oldname, newname string) error {
+	oldfull, err := dir.join("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newfull, err := dir.join("rename", newname)
+	if err != nil {
+		return err
+	}
+	return Rename(oldfull, newfull)
+}
+
+func (dir dirFS) Chmod(name string, mode FileMode) error {
+	fullname, err := dir.join("chmod", name)
+	if err != nil {
+		return err
+	}
+	return Chmod(fullname, mode)
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+// The target oldname must be a relative, slash-separated path that
+// refers to a name within the tree rooted at dir, taken relative to
+// the directory containing newname. Symlink does not follow existing
+// links in the tree, so a target that passes through one of them can
+// still point outside it.
+func (dir dirFS) Symlink(oldname, newname string) error {
+	fullname, err := dir.join("symlink", newname)
+	if err != nil {
+		return err
+	}
+	if oldname == "" || oldname[0] == '/' || runtime.GOOS == "windows" && containsAny(oldname, `\:`) ||
+		!fs.ValidPath(path.Join(path.Dir(newname), oldname)) {
+		return &LinkError{Op: "symlink", Old: oldname, New: newname, Err: ErrInvalid}
+	}
+	return Symlink(oldname, fullname)
+}
+
 // ReadFile reads the named file and returns the contents.
 // A successful call returns err == nil, not err == EOF.
 // Because ReadFile reads the whole file, it does not treat an EOF from Read
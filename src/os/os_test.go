@@ -2728,6 +2728,27 @@ func TestDirFS(t *testing.T) {
 	}
 }
 
+func TestDirFSWrite(t *testing.T) {
+	if err := fstest.TestWriteFS(DirFS(t.TempDir())); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirFSSymlinkOutside(t *testing.T) {
+	testenv.MustHaveSymlink(t)
+	fsys := DirFS(t.TempDir()).(interface {
+		Symlink(oldname, newname string) error
+	})
+	for _, target := range []string{"", "/etc/passwd", "..", "../x", "a/../../x"} {
+		if err := fsys.Symlink(target, "link"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Symlink(%q, \"link\") = %v, want ErrInvalid", target, err)
+		}
+	}
+	if err := fsys.Symlink("../x", "sub/link"); errors.Is(err, ErrInvalid) {
+		t.Errorf("Symlink(\"../x\", \"sub/link\") = %v, want target within tree accepted", err)
+	}
+}
+
 func TestDirFSPathsValid(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skipf("skipping on Windows")
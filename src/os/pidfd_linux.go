@@ -0,0 +1,252 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Support for pidfd was added during the course of a few Linux releases:
+//  v5.1: pidfd_send_signal syscall;
+//  v5.2: CLONE_PIDFD flag for clone syscall;
+//  v5.3: pidfd_open syscall;
+//  v5.4: P_PIDFD idtype support for waitid syscall.
+//
+// On Linux a Process stores its pidfd, if it has one, in its handle field.
+
+package os
+
+import (
+	"errors"
+	"internal/poll"
+	"internal/syscall/unix"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// unsetHandle is the handle of a Process that has no pidfd.
+// It is zero so that a Process that was not created by
+// StartProcess or FindProcess is treated as having no pidfd;
+// pidfdHandle makes sure that no pidfd uses descriptor 0.
+const unsetHandle = 0
+
+// errNoPidfd is returned by Process.PidFD for a process without a pidfd.
+var errNoPidfd = errors.New("os: process has no pidfd")
+
+// PidFD returns a new file descriptor referring to the process,
+// a Linux pidfd, which the caller is responsible for closing.
+// The pidfd becomes readable when the process exits, may be passed
+// to the pidfd_send_signal and waitid system calls, and keeps
+// referring to the same process even if its PID is reused.
+//
+// A Process has a pidfd if it was started by StartProcess or found
+// by FindProcess on Linux 5.4 or later and has not yet been waited
+// for or released.
+func (p *Process) PidFD() (int, error) {
+	p.sigMu.RLock()
+	defer p.sigMu.RUnlock()
+	if p.Pid == -1 {
+		return -1, errors.New("os: process already released")
+	}
+	if p.done() {
+		return -1, ErrProcessDone
+	}
+	handle, ok := p.pidfd()
+	if !ok {
+		return -1, errNoPidfd
+	}
+	fd, _, err := poll.DupCloseOnExec(int(handle))
+	if err != nil {
+		return -1, NewSyscallError("fcntl", err)
+	}
+	return fd, nil
+}
+
+// ensurePidfd returns process attributes that ask syscall.StartProcess
+// for a pidfd, if pidfds are supported. needDup reports whether sysAttr
+// itself asked for a pidfd, which then belongs to the caller, so that
+// the Process needs its own duplicate.
+func ensurePidfd(sysAttr *syscall.SysProcAttr) (_ *syscall.SysProcAttr, needDup bool) {
+	if !pidfdWorks() {
+		return sysAttr, false
+	}
+	pidfd := -1
+	if sysAttr == nil {
+		return &syscall.SysProcAttr{PidFD: &pidfd}, false
+	}
+	if sysAttr.PidFD == nil {
+		newSys := *sysAttr // copy
+		newSys.PidFD = &pidfd
+		return &newSys, false
+	}
+	return sysAttr, true
+}
+
+// getPidfd returns the handle for a process started with the attributes
+// returned by ensurePidfd.
+func getPidfd(sysAttr *syscall.SysProcAttr, needDup bool) uintptr {
+	if !pidfdWorks() || sysAttr == nil || sysAttr.PidFD == nil || *sysAttr.PidFD < 0 {
+		return unsetHandle
+	}
+	h := *sysAttr.PidFD
+	if needDup {
+		var err error
+		if h, _, err = poll.DupCloseOnExec(h); err != nil {
+			return unsetHandle
+		}
+	}
+	return pidfdHandle(h)
+}
+
+// pidfdFind returns the handle for the process with the given PID,
+// or unsetHandle if a pidfd can not be opened for it.
+func pidfdFind(pid int) uintptr {
+	if !pidfdWorks() {
+		return unsetHandle
+	}
+	h, err := unix.PidFDOpen(pid, 0)
+	if err != nil {
+		return unsetHandle
+	}
+	return pidfdHandle(int(h))
+}
+
+// pidfdHandle returns the handle for the pidfd fd, which it takes
+// ownership of. If fd is 0, which happens when standard input is
+// closed, the pidfd is moved to another descriptor first.
+func pidfdHandle(fd int) uintptr {
+	if fd != 0 {
+		return uintptr(fd)
+	}
+	h, _, err := poll.DupCloseOnExec(fd)
+	syscall.Close(fd)
+	if err != nil {
+		return unsetHandle
+	}
+	return uintptr(h)
+}
+
+// pidfd returns p's pidfd and reports whether it has one.
+func (p *Process) pidfd() (uintptr, bool) {
+	h := atomic.LoadUintptr(&p.handle)
+	return h, h != unsetHandle
+}
+
+// pidfdWait waits for the process to exit using its pidfd.
+//
+// The pidfd is registered with the runtime network poller, which
+// reports it as readable once the process exits, so no thread is
+// blocked while waiting. As with wait4, waiting for a process that
+// is not our child fails immediately with syscall.ECHILD; the process
+// keeps its pidfd in that case, so that it can still be signaled.
+func (p *Process) pidfdWait(handle uintptr) (*ProcessState, error) {
+	var (
+		info   unix.SiginfoChild
+		rusage syscall.Rusage
+	)
+	werr := ignoringEINTR(func() error {
+		return unix.Waitid(unix.P_PIDFD, int(handle), &info, syscall.WEXITED|syscall.WNOHANG, &rusage)
+	})
+	if werr == syscall.ECHILD {
+		return nil, NewSyscallError("waitid", werr)
+	}
+	pfd := &poll.FD{Sysfd: int(handle)}
+	if werr == nil && info.Pid == 0 {
+		// Still running.
+		if pfd.Init("pidfd", true) == nil {
+			err := pfd.RawRead(func(fd uintptr) bool {
+				werr = ignoringEINTR(func() error {
+					return unix.Waitid(unix.P_PIDFD, int(fd), &info, syscall.WEXITED|syscall.WNOHANG, &rusage)
+				})
+				return werr != nil || info.Pid != 0
+			})
+			if werr == nil {
+				werr = err
+			}
+		} else {
+			// The poller can't wait for this pidfd; block the thread.
+			werr = ignoringEINTR(func() error {
+				return unix.Waitid(unix.P_PIDFD, int(handle), &info, syscall.WEXITED, &rusage)
+			})
+		}
+	}
+
+	// Whatever the outcome, stop using the pidfd.
+	// Acquire a write lock on sigMu to wait for any
+	// active call to the signal method to complete.
+	if werr == nil {
+		p.setDone()
+	}
+	p.sigMu.Lock()
+	atomic.StoreUintptr(&p.handle, unsetHandle)
+	pfd.Close()
+	p.sigMu.Unlock()
+	runtime.KeepAlive(p)
+
+	if werr != nil {
+		return nil, NewSyscallError("waitid", werr)
+	}
+	return &ProcessState{
+		pid:    int(info.Pid),
+		status: info.WaitStatus(),
+		rusage: &rusage,
+	}, nil
+}
+
+// pidfdSendSignal sends the signal s to the process using its pidfd.
+func (p *Process) pidfdSendSignal(handle uintptr, s syscall.Signal) error {
+	if err := unix.PidFDSendSignal(handle, s); err != nil {
+		if err == syscall.ESRCH {
+			return ErrProcessDone
+		}
+		return NewSyscallError("pidfd_send_signal", err)
+	}
+	return nil
+}
+
+// pidfdRelease closes p's pidfd, if it has one.
+func (p *Process) pidfdRelease() {
+	p.sigMu.Lock()
+	defer p.sigMu.Unlock()
+	if h := atomic.SwapUintptr(&p.handle, unsetHandle); h != unsetHandle {
+		syscall.Close(int(h))
+	}
+}
+
+var (
+	pidfdOnce sync.Once
+	pidfdOK   bool
+)
+
+// pidfdWorks reports whether all the pidfd system calls used by
+// package os are available.
+func pidfdWorks() bool {
+	pidfdOnce.Do(func() {
+		pidfdOK = checkPidfd() == nil
+	})
+	return pidfdOK
+}
+
+// checkPidfd checks whether the system calls used by pidfdWait,
+// pidfdSendSignal and ensurePidfd work, using a pidfd for the current
+// process. They may be missing on older kernels or blocked by seccomp.
+func checkPidfd() error {
+	fd, err := unix.PidFDOpen(syscall.Getpid(), 0)
+	if err != nil {
+		return NewSyscallError("pidfd_open", err)
+	}
+	defer syscall.Close(int(fd))
+
+	// We are not our own child, so this fails with ECHILD if
+	// P_PIDFD is supported and EINVAL if it is not.
+	var info unix.SiginfoChild
+	err = ignoringEINTR(func() error {
+		return unix.Waitid(unix.P_PIDFD, int(fd), &info, syscall.WEXITED|syscall.WNOHANG, nil)
+	})
+	if err != syscall.ECHILD {
+		return NewSyscallError("waitid", err)
+	}
+
+	if err := unix.PidFDSendSignal(fd, 0); err != nil {
+		return NewSyscallError("pidfd_send_signal", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"errors"
+	"internal/syscall/unix"
+	"os"
+	osexec "os/exec"
+	"syscall"
+	"testing"
+)
+
+func startSleep(t *testing.T) *os.Process {
+	t.Helper()
+	if !os.PidfdWorks() {
+		t.Skip("pidfd not supported")
+	}
+	path, err := osexec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+	p, err := os.StartProcess(path, []string{"sleep", "100"}, &os.ProcAttr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPidFD(t *testing.T) {
+	p := startSleep(t)
+	fd, err := p.PidFD()
+	if err != nil {
+		p.Kill()
+		p.Wait()
+		t.Fatalf("PidFD: %v", err)
+	}
+	defer syscall.Close(fd)
+
+	// The returned pidfd can be used independently of p.
+	if err := unix.PidFDSendSignal(uintptr(fd), syscall.SIGKILL); err != nil {
+		t.Errorf("pidfd_send_signal: %v", err)
+		p.Kill()
+	}
+	ps, err := p.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if status := ps.Sys().(syscall.WaitStatus); !status.Signaled() || status.Signal() != syscall.SIGKILL {
+		t.Errorf("Wait status = %v, want killed", ps)
+	}
+	if ps.Pid() != p.Pid {
+		t.Errorf("ProcessState.Pid = %d, want %d", ps.Pid(), p.Pid)
+	}
+	if _, err := p.PidFD(); err != os.ErrProcessDone {
+		t.Errorf("PidFD after Wait = %v, want %v", err, os.ErrProcessDone)
+	}
+	if err := p.Signal(os.Kill); err != os.ErrProcessDone {
+		t.Errorf("Signal after Wait = %v, want %v", err, os.ErrProcessDone)
+	}
+}
+
+func TestPidFDSysProcAttr(t *testing.T) {
+	if !os.PidfdWorks() {
+		t.Skip("pidfd not supported")
+	}
+	path, err := osexec.LookPath("true")
+	if err != nil {
+		t.Skip("true not found")
+	}
+	fd := -1
+	p, err := os.StartProcess(path, []string{"true"}, &os.ProcAttr{
+		Sys: &syscall.SysProcAttr{PidFD: &fd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fd < 0 {
+		t.Fatalf("SysProcAttr.PidFD not set")
+	}
+	// The pidfd belongs to the caller: waiting must not close it.
+	if _, err := p.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if err := unix.PidFDSendSignal(uintptr(fd), 0); err != syscall.ESRCH {
+		t.Errorf("pidfd_send_signal after Wait = %v, want ESRCH", err)
+	}
+	if err := syscall.Close(fd); err != nil {
+		t.Errorf("Close(pidfd): %v", err)
+	}
+}
+
+func TestFindProcessPidFD(t *testing.T) {
+	p := startSleep(t)
+	defer p.Wait()
+	defer p.Kill()
+
+	found, err := os.FindProcess(p.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer found.Release()
+	fd, err := found.PidFD()
+	if err != nil {
+		t.Fatalf("PidFD: %v", err)
+	}
+	syscall.Close(fd)
+	if err := found.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("Signal(0): %v", err)
+	}
+}
+
+func TestFindProcessWaitNotChild(t *testing.T) {
+	if !os.PidfdWorks() {
+		t.Skip("pidfd not supported")
+	}
+	// Our parent is not our child: Wait must fail immediately
+	// rather than block until it exits.
+	p, err := os.FindProcess(os.Getppid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Release()
+	if _, err := p.Wait(); !errors.Is(err, syscall.ECHILD) {
+		t.Fatalf("Wait = %v, want ECHILD", err)
+	}
+	// The process can still be signaled.
+	if err := p.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("Signal(0) after Wait: %v", err)
+	}
+}
+
+func TestStartProcessWithoutPidfd(t *testing.T) {
+	// Simulate a kernel without pidfd support.
+	defer os.DisablePidfd()()
+	path, err := osexec.LookPath("true")
+	if err != nil {
+		t.Skip("true not found")
+	}
+	fd := -1
+	p, err := os.StartProcess(path, []string{"true"}, &os.ProcAttr{
+		Sys: &syscall.SysProcAttr{PidFD: &fd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.PidFD(); err == nil {
+		t.Errorf("PidFD succeeded without pidfd support")
+	}
+	ps, err := p.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if !ps.Success() {
+		t.Errorf("Wait status = %v, want success", ps)
+	}
+	// Whatever the kernel did, the pidfd belongs to the caller.
+	if fd != -1 {
+		if err := syscall.Close(fd); err != nil {
+			t.Errorf("Close(pidfd): %v", err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux,!plan9
+
+package os
+
+import (
+	"errors"
+	"syscall"
+)
+
+// PidFD returns a new file descriptor referring to the process,
+// a Linux pidfd, which the caller is responsible for closing.
+// It is only supported on Linux; on other systems it returns an error.
+func (p *Process) PidFD() (int, error) {
+	return -1, errors.New("os: pidfd not supported on this system")
+}
+
+func ensurePidfd(sysAttr *syscall.SysProcAttr) (*syscall.SysProcAttr, bool) {
+	return sysAttr, false
+}
+
+func getPidfd(_ *syscall.SysProcAttr, _ bool) uintptr {
+	return 0
+}
+
+func pidfdFind(_ int) uintptr {
+	return 0
+}
+
+func (p *Process) pidfd() (uintptr, bool) {
+	return 0, false
+}
+
+func (p *Process) pidfdWait(_ uintptr) (*ProcessState, error) {
+	panic("unreachable")
+}
+
+func (p *Process) pidfdSendSignal(_ uintptr, _ syscall.Signal) error {
+	panic("unreachable")
+}
+
+func (p *Process) pidfdRelease() {}
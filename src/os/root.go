@@ -0,0 +1,204 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"errors"
+	"internal/testlog"
+	"io/fs"
+	"runtime"
+	"syscall"
+)
+
+// errPathEscapes is returned when a path given to a Root method would
+// refer to a file outside the root directory.
+var errPathEscapes = errors.New("path escapes from parent")
+
+// maxRootSymlinks is the maximum number of symbolic links followed
+// while resolving a single path within a Root.
+const maxRootSymlinks = 40
+
+// Root may be used to only access files within a single directory tree.
+//
+// Methods on Root can only access files and directories beneath a root
+// directory. If any component of a file name passed to a method of Root
+// references a location outside the root, the method returns an error.
+// File names may reference the directory itself (.).
+//
+// Methods on Root follow symbolic links, but symbolic links may not
+// reference a location outside the root. Symbolic links must not be
+// absolute.
+//
+// Methods on Root do not prohibit traversal of filesystem boundaries,
+// Linux bind mounts, /proc special files, or access to Unix device files.
+//
+// On Linux, Root uses the openat2 system call with RESOLVE_BENEATH when
+// the kernel supports it. On Linux and on Darwin and the BSDs, Root
+// otherwise resolves each path one component at a time with openat, so
+// it guarantees that its methods cannot escape the root even if the
+// directory tree is modified concurrently. On other platforms, Root
+// checks each path before accessing it and does not protect against a
+// concurrent rename or replacement of a directory with a symbolic link.
+//
+// Methods on Root are safe to be used from multiple goroutines
+// simultaneously.
+type Root struct {
+	root *root
+}
+
+// OpenRoot opens the named directory for use as a Root.
+// If there is an error, it will be of type *PathError.
+func OpenRoot(name string) (*Root, error) {
+	testlog.Open(name)
+	return openRootNolog(name)
+}
+
+// Name returns the name of the directory presented to OpenRoot.
+//
+// It is safe to call Name after Close.
+func (r *Root) Name() string {
+	return r.root.name
+}
+
+// Close closes the Root.
+// After Close is called, methods on Root return errors.
+func (r *Root) Close() error {
+	return r.root.Close()
+}
+
+// Open opens the named file in the root for reading.
+// See Open for more details.
+func (r *Root) Open(name string) (*File, error) {
+	return r.OpenFile(name, O_RDONLY, 0)
+}
+
+// Create creates or truncates the named file in the root.
+// See Create for more details.
+func (r *Root) Create(name string) (*File, error) {
+	return r.OpenFile(name, O_RDWR|O_CREATE|O_TRUNC, 0666)
+}
+
+// OpenFile opens the named file in the root.
+// See OpenFile for more details.
+//
+// If perm contains bits other than the nine least-significant bits
+// (0o777), OpenFile returns an error.
+func (r *Root) OpenFile(name string, flag int, perm FileMode) (*File, error) {
+	if perm&0o777 != perm {
+		return nil, &PathError{Op: "openat", Path: name, Err: errors.New("unsupported file mode")}
+	}
+	r.logOpen(name)
+	return rootOpenFileNolog(r, name, flag, perm)
+}
+
+// Mkdir creates a new directory in the root
+// with the specified name and permission bits (before umask).
+// See Mkdir for more details.
+//
+// If perm contains bits other than the nine least-significant bits
+// (0o777), Mkdir returns an error.
+func (r *Root) Mkdir(name string, perm FileMode) error {
+	if perm&0o777 != perm {
+		return &PathError{Op: "mkdirat", Path: name, Err: errors.New("unsupported file mode")}
+	}
+	return rootMkdir(r, name, perm)
+}
+
+// Remove removes the named file or (empty) directory in the root.
+// See Remove for more details.
+func (r *Root) Remove(name string) error {
+	return rootRemove(r, name)
+}
+
+// Stat returns a FileInfo describing the named file in the root.
+// See Stat for more details.
+func (r *Root) Stat(name string) (FileInfo, error) {
+	r.logStat(name)
+	return rootStat(r, name, false)
+}
+
+// Lstat returns a FileInfo describing the named file in the root.
+// If the file is a symbolic link, the returned FileInfo
+// describes the symbolic link.
+// See Lstat for more details.
+func (r *Root) Lstat(name string) (FileInfo, error) {
+	r.logStat(name)
+	return rootStat(r, name, true)
+}
+
+func (r *Root) logOpen(name string) {
+	if log := testlog.Logger(); log != nil {
+		// This won't be right if r's name has changed since it was opened,
+		// but it's the best we can do.
+		log.Open(joinPath(r.Name(), name))
+	}
+}
+
+func (r *Root) logStat(name string) {
+	if log := testlog.Logger(); log != nil {
+		log.Stat(joinPath(r.Name(), name))
+	}
+}
+
+// splitPathInRoot splits a path name relative to a Root into its
+// components, leaving out empty and "." components. It returns an
+// error if the path is absolute.
+func splitPathInRoot(name string) ([]string, error) {
+	if name == "" {
+		return nil, syscall.ENOENT
+	}
+	// On Windows, a colon may introduce a volume name or an alternate
+	// data stream, neither of which is part of the tree under the root.
+	if IsPathSeparator(name[0]) || runtime.GOOS == "windows" && containsAny(name, ":") {
+		return nil, errPathEscapes
+	}
+	var parts []string
+	i := 0
+	for j := 0; j <= len(name); j++ {
+		if j < len(name) && !IsPathSeparator(name[j]) {
+			continue
+		}
+		if part := name[i:j]; part != "" && part != "." {
+			parts = append(parts, part)
+		}
+		i = j + 1
+	}
+	return parts, nil
+}
+
+// FS returns a file system (an fs.FS) for the tree of files in the root.
+//
+// The result implements fs.StatFS.
+func (r *Root) FS() fs.FS {
+	return (*rootFS)(r)
+}
+
+type rootFS Root
+
+func (rfs *rootFS) Open(name string) (fs.File, error) {
+	r := (*Root)(rfs)
+	if !isValidRootFSPath(name) {
+		return nil, &PathError{Op: "open", Path: name, Err: ErrInvalid}
+	}
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err // nil fs.File
+	}
+	return f, nil
+}
+
+func (rfs *rootFS) Stat(name string) (FileInfo, error) {
+	r := (*Root)(rfs)
+	if !isValidRootFSPath(name) {
+		return nil, &PathError{Op: "stat", Path: name, Err: ErrInvalid}
+	}
+	return r.Stat(name)
+}
+
+// isValidRootFSPath reports whether name is a valid fs.FS path that
+// can be passed on to a Root method.
+func isValidRootFSPath(name string) bool {
+	return fs.ValidPath(name) && !(runtime.GOOS == "windows" && containsAny(name, `\:`))
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"internal/syscall/unix"
+	"sync/atomic"
+	"syscall"
+)
+
+// openat2Unsupported is set when the kernel turns out not to support
+// the openat2 system call.
+var openat2Unsupported int32
+
+// doInRootFast is like doInRoot, but opens the directory containing
+// the final path component with a single openat2 system call. It
+// reports whether it handled the operation; if not, the caller falls
+// back to resolving the path one component at a time.
+func doInRootFast(rootfd int, parts []string, f func(dirfd int, base string) error) (handled bool, err error) {
+	if len(parts) == 0 || parts[len(parts)-1] == ".." || atomic.LoadInt32(&openat2Unsupported) != 0 {
+		return false, nil
+	}
+	dir := "."
+	for i, part := range parts[:len(parts)-1] {
+		if i == 0 {
+			dir = part
+		} else {
+			dir += "/" + part
+		}
+	}
+	fd, err := unix.Openat2(rootfd, dir, &unix.OpenHow{
+		Flags:   syscall.O_RDONLY | syscall.O_DIRECTORY | syscall.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	})
+	switch err {
+	case nil:
+	case syscall.ENOSYS, syscall.EPERM:
+		// Kernels before 5.6 don't have openat2,
+		// and some seccomp filters reject it with EPERM.
+		atomic.StoreInt32(&openat2Unsupported, 1)
+		return false, nil
+	case syscall.EAGAIN, syscall.EINTR:
+		// The kernel gives up on RESOLVE_BENEATH when a rename
+		// races with the lookup; the slow path does not.
+		return false, nil
+	case syscall.EXDEV:
+		return true, errPathEscapes
+	default:
+		return true, err
+	}
+	defer syscall.Close(fd)
+	err = f(fd, parts[len(parts)-1])
+	if _, ok := err.(errSymlink); ok {
+		return false, nil
+	}
+	return true, err
+}
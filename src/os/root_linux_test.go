@@ -0,0 +1,20 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	. "os"
+	"testing"
+)
+
+// TestRootWithoutOpenat2 runs the Root tests on the openat fallback
+// used by kernels without openat2.
+func TestRootWithoutOpenat2(t *testing.T) {
+	defer DisableOpenat2()()
+	t.Run("Open", TestRootOpen)
+	t.Run("CreateMkdirRemove", TestRootCreateMkdirRemove)
+	t.Run("Stat", TestRootStat)
+	t.Run("FS", TestRootFS)
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build aix js plan9 solaris windows
+
+package os
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+)
+
+// On these platforms the path is resolved by name, one component at a
+// time, checking each component before it is used.
+
+var errTooManySymlinks = errors.New("too many levels of symbolic links")
+
+type root struct {
+	name string
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (r *root) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *root) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+func openRootNolog(name string) (*Root, error) {
+	fi, err := statNolog(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &PathError{Op: "open", Path: name, Err: syscall.ENOTDIR}
+	}
+	return &Root{&root{name: name}}, nil
+}
+
+// errSymlink is returned by the function passed to doInRoot when the
+// final path component is a symbolic link that should be followed.
+// Its value is the link target.
+type errSymlink string
+
+func (e errSymlink) Error() string { return "symlink to " + string(e) }
+
+// doInRoot resolves name within r to the name of a file, and calls f
+// with it. All intermediate symbolic links are followed; f may request
+// that the final component be followed too by returning an errSymlink.
+func doInRoot(r *Root, name string, f func(name string) error) error {
+	if r.root.isClosed() {
+		return ErrClosed
+	}
+	parts, err := splitPathInRoot(name)
+	if err != nil {
+		return err
+	}
+
+	// Directories below the root, innermost last.
+	var dirs []string
+	dir := func() string {
+		if len(dirs) == 0 {
+			return r.root.name
+		}
+		return dirs[len(dirs)-1]
+	}
+	links := 0
+	followLink := func(target string, rest []string) ([]string, error) {
+		links++
+		if links > maxRootSymlinks {
+			return nil, errTooManySymlinks
+		}
+		linkParts, err := splitPathInRoot(target)
+		if err != nil {
+			return nil, err
+		}
+		return append(linkParts, rest...), nil
+	}
+
+	for len(parts) > 0 {
+		part := parts[0]
+		if part == ".." {
+			if len(dirs) == 0 {
+				return errPathEscapes
+			}
+			dirs = dirs[:len(dirs)-1]
+			parts = parts[1:]
+			continue
+		}
+		next := joinPath(dir(), part)
+		if len(parts) == 1 {
+			err := f(next)
+			if target, ok := err.(errSymlink); ok {
+				if parts, err = followLink(string(target), nil); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		fi, err := Lstat(next)
+		if err != nil {
+			return underlyingError(err)
+		}
+		if fi.Mode()&ModeSymlink != 0 {
+			target, err := Readlink(next)
+			if err != nil {
+				return underlyingError(err)
+			}
+			if parts, err = followLink(target, parts[1:]); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fi.IsDir() {
+			return syscall.ENOTDIR
+		}
+		dirs = append(dirs, next)
+		parts = parts[1:]
+	}
+	return f(dir())
+}
+
+// followSymlink returns an errSymlink if name is a symbolic link.
+func followSymlink(name string) error {
+	fi, err := Lstat(name)
+	if err != nil || fi.Mode()&ModeSymlink == 0 {
+		return nil
+	}
+	target, err := Readlink(name)
+	if err != nil {
+		return underlyingError(err)
+	}
+	return errSymlink(target)
+}
+
+func rootOpenFileNolog(r *Root, name string, flag int, perm FileMode) (*File, error) {
+	var f *File
+	err := doInRoot(r, name, func(name string) error {
+		if flag&(O_CREATE|O_EXCL) != O_CREATE|O_EXCL {
+			if err := followSymlink(name); err != nil {
+				return err
+			}
+		}
+		var err error
+		f, err = openFileNolog(name, flag, perm)
+		return underlyingError(err)
+	})
+	if err != nil {
+		return nil, &PathError{Op: "openat", Path: name, Err: err}
+	}
+	return f, nil
+}
+
+func rootMkdir(r *Root, name string, perm FileMode) error {
+	err := doInRoot(r, name, func(name string) error {
+		return underlyingError(Mkdir(name, perm))
+	})
+	if err != nil {
+		return &PathError{Op: "mkdirat", Path: name, Err: err}
+	}
+	return nil
+}
+
+func rootRemove(r *Root, name string) error {
+	err := doInRoot(r, name, func(name string) error {
+		return underlyingError(Remove(name))
+	})
+	if err != nil {
+		return &PathError{Op: "removeat", Path: name, Err: err}
+	}
+	return nil
+}
+
+func rootStat(r *Root, name string, lstat bool) (FileInfo, error) {
+	var fi FileInfo
+	err := doInRoot(r, name, func(name string) error {
+		if !lstat {
+			if err := followSymlink(name); err != nil {
+				return err
+			}
+		}
+		var err error
+		fi, err = lstatNolog(name)
+		return underlyingError(err)
+	})
+	if err != nil {
+		return nil, &PathError{Op: "statat", Path: name, Err: err}
+	}
+	return fi, nil
+}
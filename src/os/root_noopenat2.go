@@ -0,0 +1,12 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package os
+
+// doInRootFast is only implemented on Linux.
+func doInRootFast(rootfd int, parts []string, f func(dirfd int, base string) error) (handled bool, err error) {
+	return false, nil
+}
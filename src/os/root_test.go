@@ -0,0 +1,307 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"errors"
+	"internal/testenv"
+	"io"
+	"io/fs"
+	. "os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// makeRootTree creates a directory tree for the Root tests, with a file
+// outside the root next to it, and returns the root directory.
+func makeRootTree(t *testing.T, symlinks bool) string {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	for _, d := range []string{"a/b"} {
+		if err := MkdirAll(filepath.Join(root, d), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"file":     "root file",
+		"a/b/file": "b file",
+		"../out":   "outside",
+	}
+	for name, data := range files {
+		if err := WriteFile(filepath.Join(root, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !symlinks {
+		return root
+	}
+	links := map[string]string{
+		"a/up":     "../file",
+		"dirlink":  "a",
+		"escape":   "../out",
+		"dangling": "../new",
+		"abs":      filepath.Join(dir, "out"),
+		"loop":     "loop",
+	}
+	for name, target := range links {
+		if err := Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+var rootOpenTests = []struct {
+	name    string
+	symlink bool   // test requires symlinks
+	want    string // file contents, or "" if an error is expected
+	escapes bool   // error must be errPathEscapes
+}{
+	{name: "file", want: "root file"},
+	{name: "a/b/file", want: "b file"},
+	{name: "a/../file", want: "root file"},
+	{name: "./a/./b/../b//file", want: "b file"},
+	{name: "a/b/../../file", want: "root file"},
+	{name: "a/up", symlink: true, want: "root file"},
+	{name: "dirlink/b/file", symlink: true, want: "b file"},
+	{name: "dirlink/../file", symlink: true, want: "root file"},
+	{name: "../out", escapes: true},
+	{name: "a/../../out", escapes: true},
+	{name: "a/b/../../../root/file", escapes: true},
+	{name: "/file", escapes: true},
+	{name: "escape", symlink: true, escapes: true},
+	{name: "abs", symlink: true, escapes: true},
+	{name: "dirlink/../../out", symlink: true, escapes: true},
+	{name: "loop", symlink: true},
+	{name: ""},
+	{name: "missing"},
+	{name: "file/x"},
+}
+
+func isPathEscapes(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "path escapes from parent")
+}
+
+func TestRootOpen(t *testing.T) {
+	symlinks := testenv.HasSymlink()
+	root, err := OpenRoot(makeRootTree(t, symlinks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	for _, tt := range rootOpenTests {
+		if tt.symlink && !symlinks {
+			continue
+		}
+		f, err := root.Open(tt.name)
+		if tt.want == "" {
+			if err == nil {
+				f.Close()
+				t.Errorf("Open(%q) succeeded; want error", tt.name)
+			} else if tt.escapes && !isPathEscapes(err) {
+				t.Errorf("Open(%q) = %v; want path escapes error", tt.name, err)
+			} else if _, ok := err.(*PathError); !ok {
+				t.Errorf("Open(%q) returned %T; want *PathError", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Open(%q): %v", tt.name, err)
+			continue
+		}
+		b, err := io.ReadAll(f)
+		f.Close()
+		if err != nil || string(b) != tt.want {
+			t.Errorf("reading %q = %q, %v; want %q", tt.name, b, err, tt.want)
+		}
+	}
+}
+
+func TestRootCreateMkdirRemove(t *testing.T) {
+	symlinks := testenv.HasSymlink()
+	dir := makeRootTree(t, symlinks)
+	root, err := OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+
+	if err := root.Mkdir("a/new", 0777); err != nil {
+		t.Fatal(err)
+	}
+	f, err := root.Create("a/new/../new/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("created"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if b, err := ReadFile(filepath.Join(dir, "a/new/f")); err != nil || string(b) != "created" {
+		t.Errorf("created file = %q, %v; want %q", b, err, "created")
+	}
+	if _, err := root.OpenFile("a/new/f", O_CREATE|O_EXCL|O_WRONLY, 0666); !IsExist(err) {
+		t.Errorf("exclusive create of existing file = %v; want exist error", err)
+	}
+	if err := root.Mkdir("a/new", 0777); !IsExist(err) {
+		t.Errorf("Mkdir of existing directory = %v; want exist error", err)
+	}
+	if err := root.Remove("a/new"); err == nil {
+		t.Errorf("Remove of non-empty directory succeeded")
+	}
+	if err := root.Remove("a/new/f"); err != nil {
+		t.Error(err)
+	}
+	if err := root.Remove("a/new"); err != nil {
+		t.Error(err)
+	}
+	if _, err := Stat(filepath.Join(dir, "a/new")); !IsNotExist(err) {
+		t.Errorf("removed directory still exists: %v", err)
+	}
+
+	for _, name := range []string{"../new", "a/../../new", "/new"} {
+		if err := root.Mkdir(name, 0777); !isPathEscapes(err) {
+			t.Errorf("Mkdir(%q) = %v; want path escapes error", name, err)
+		}
+		if f, err := root.Create(name); !isPathEscapes(err) {
+			if err == nil {
+				f.Close()
+			}
+			t.Errorf("Create(%q) = %v; want path escapes error", name, err)
+		}
+		if err := root.Remove(name); !isPathEscapes(err) {
+			t.Errorf("Remove(%q) = %v; want path escapes error", name, err)
+		}
+	}
+	if _, err := root.OpenFile("f", O_CREATE|O_WRONLY, 01777); err == nil {
+		t.Errorf("OpenFile with sticky bit succeeded")
+	}
+
+	if !symlinks {
+		return
+	}
+	if f, err := root.Create("dangling"); !isPathEscapes(err) {
+		if err == nil {
+			f.Close()
+		}
+		t.Errorf("Create through escaping symlink = %v; want path escapes error", err)
+	}
+	if _, err := Stat(filepath.Join(dir, "../new")); !IsNotExist(err) {
+		t.Errorf("file created outside root: %v", err)
+	}
+	if f, err := root.Create("a/up"); err != nil {
+		t.Errorf("Create through symlink in root: %v", err)
+	} else {
+		f.Close()
+	}
+	// Remove does not follow the final symlink.
+	if err := root.Remove("dirlink"); err != nil {
+		t.Error(err)
+	}
+	if _, err := Stat(filepath.Join(dir, "a/b/file")); err != nil {
+		t.Errorf("Remove of symlink removed its target: %v", err)
+	}
+}
+
+func TestRootStat(t *testing.T) {
+	symlinks := testenv.HasSymlink()
+	root, err := OpenRoot(makeRootTree(t, symlinks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+
+	for _, name := range []string{".", "a", "a/b/..", "a/b/../.."} {
+		fi, err := root.Stat(name)
+		if err != nil || !fi.IsDir() {
+			t.Errorf("Stat(%q) = %v, %v; want directory", name, fi, err)
+		}
+	}
+	fi, err := root.Stat("a/b/file")
+	if err != nil || fi.Name() != "file" || fi.Size() != int64(len("b file")) {
+		t.Errorf("Stat(a/b/file) = %v, %v", fi, err)
+	}
+	if _, err := root.Stat(".."); !isPathEscapes(err) {
+		t.Errorf("Stat(..) = %v; want path escapes error", err)
+	}
+
+	if !symlinks {
+		return
+	}
+	fi, err = root.Stat("dirlink")
+	if err != nil || !fi.IsDir() {
+		t.Errorf("Stat(dirlink) = %v, %v; want directory", fi, err)
+	}
+	fi, err = root.Lstat("dirlink")
+	if err != nil || fi.Mode()&ModeSymlink == 0 {
+		t.Errorf("Lstat(dirlink) = %v, %v; want symlink", fi, err)
+	}
+	if _, err := root.Stat("escape"); !isPathEscapes(err) {
+		t.Errorf("Stat(escape) = %v; want path escapes error", err)
+	}
+	fi, err = root.Lstat("escape")
+	if err != nil || fi.Mode()&ModeSymlink == 0 {
+		t.Errorf("Lstat(escape) = %v, %v; want symlink", fi, err)
+	}
+}
+
+func TestRootClose(t *testing.T) {
+	root, err := OpenRoot(makeRootTree(t, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := root.Open("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Files opened from the root stay open.
+	if b, err := io.ReadAll(f); err != nil || string(b) != "root file" {
+		t.Errorf("reading file after Close = %q, %v", b, err)
+	}
+	f.Close()
+	if _, err := root.Open("file"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Open after Close = %v; want ErrClosed", err)
+	}
+	if err := root.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+	if root.Name() == "" {
+		t.Errorf("Name after Close is empty")
+	}
+}
+
+func TestOpenRootErrors(t *testing.T) {
+	dir := makeRootTree(t, false)
+	if _, err := OpenRoot(filepath.Join(dir, "missing")); !IsNotExist(err) {
+		t.Errorf("OpenRoot of missing directory = %v; want not exist error", err)
+	}
+	if _, err := OpenRoot(filepath.Join(dir, "file")); err == nil {
+		t.Errorf("OpenRoot of file succeeded")
+	}
+}
+
+func TestRootFS(t *testing.T) {
+	root, err := OpenRoot(makeRootTree(t, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	fsys := root.FS()
+	if err := fstest.TestFS(fsys, "file", "a/b/file"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Open("../out"); err == nil {
+		t.Errorf("Open(../out) succeeded")
+	}
+	fi, err := fs.Stat(fsys, "a/b/file")
+	if err != nil || fi.Name() != "file" {
+		t.Errorf("Stat(a/b/file) = %v, %v", fi, err)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package os
+
+import (
+	"internal/syscall/unix"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+type root struct {
+	name string
+
+	// mu is held for reading while an operation uses fd,
+	// and for writing by Close.
+	mu     sync.RWMutex
+	fd     int
+	closed bool
+}
+
+func (r *root) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	runtime.SetFinalizer(r, nil)
+	return syscall.Close(r.fd)
+}
+
+func openRootNolog(name string) (*Root, error) {
+	var fd int
+	err := ignoringEINTR(func() error {
+		var err error
+		fd, err = syscall.Open(name, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+		return err
+	})
+	if err != nil {
+		return nil, &PathError{Op: "open", Path: name, Err: err}
+	}
+	if !supportsCloseOnExec {
+		syscall.CloseOnExec(fd)
+	}
+	r := &root{name: name, fd: fd}
+	runtime.SetFinalizer(r, (*root).Close)
+	return &Root{r}, nil
+}
+
+// errSymlink is returned by the function passed to doInRoot when the
+// final path component is a symbolic link that should be followed.
+// Its value is the link target.
+type errSymlink string
+
+func (e errSymlink) Error() string { return "symlink to " + string(e) }
+
+// doInRoot resolves name within r to a directory and a final path
+// component within it, and calls f with that directory and component.
+// All intermediate symbolic links are followed; f may request that the
+// final component be followed too by returning an errSymlink.
+//
+// The component passed to f is never "..": a path ending in ".." is
+// resolved to the directory itself, and f is called with ".".
+func doInRoot(r *Root, name string, f func(dirfd int, base string) error) error {
+	r.root.mu.RLock()
+	defer r.root.mu.RUnlock()
+	if r.root.closed {
+		return ErrClosed
+	}
+	parts, err := splitPathInRoot(name)
+	if err != nil {
+		return err
+	}
+	if handled, err := doInRootFast(r.root.fd, parts, f); handled {
+		return err
+	}
+
+	// Directories opened below the root, innermost last.
+	var dirs []int
+	defer func() {
+		for _, fd := range dirs {
+			syscall.Close(fd)
+		}
+	}()
+	dirfd := func() int {
+		if len(dirs) == 0 {
+			return r.root.fd
+		}
+		return dirs[len(dirs)-1]
+	}
+	links := 0
+	followLink := func(target string, rest []string) ([]string, error) {
+		links++
+		if links > maxRootSymlinks {
+			return nil, syscall.ELOOP
+		}
+		linkParts, err := splitPathInRoot(target)
+		if err != nil {
+			return nil, err
+		}
+		return append(linkParts, rest...), nil
+	}
+
+	for len(parts) > 0 {
+		part := parts[0]
+		if part == ".." {
+			if len(dirs) == 0 {
+				return errPathEscapes
+			}
+			syscall.Close(dirs[len(dirs)-1])
+			dirs = dirs[:len(dirs)-1]
+			parts = parts[1:]
+			continue
+		}
+		if len(parts) == 1 {
+			err := f(dirfd(), part)
+			if target, ok := err.(errSymlink); ok {
+				if parts, err = followLink(string(target), nil); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		var fd int
+		err := ignoringEINTR(func() error {
+			var err error
+			fd, err = unix.Openat(dirfd(), part, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+			return err
+		})
+		if err != nil {
+			target, lerr := readlinkat(dirfd(), part)
+			if lerr != nil {
+				return err
+			}
+			if parts, err = followLink(target, parts[1:]); err != nil {
+				return err
+			}
+			continue
+		}
+		dirs = append(dirs, fd)
+		parts = parts[1:]
+	}
+	return f(dirfd(), ".")
+}
+
+// readlinkat returns the target of the symbolic link name in dirfd.
+func readlinkat(dirfd int, name string) (string, error) {
+	for n := 128; ; n *= 2 {
+		b := make([]byte, n)
+		var m int
+		err := ignoringEINTR(func() error {
+			var err error
+			m, err = unix.Readlinkat(dirfd, name, b)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		if m < n {
+			return string(b[:m]), nil
+		}
+	}
+}
+
+func rootOpenFileNolog(r *Root, name string, flag int, perm FileMode) (*File, error) {
+	// Creating a file exclusively must not follow a symbolic link.
+	follow := flag&(O_CREATE|O_EXCL) != O_CREATE|O_EXCL
+	var fd int
+	err := doInRoot(r, name, func(dirfd int, base string) error {
+		err := ignoringEINTR(func() error {
+			var err error
+			fd, err = unix.Openat(dirfd, base, flag|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, syscallMode(perm))
+			return err
+		})
+		if err != nil && follow {
+			if target, lerr := readlinkat(dirfd, base); lerr == nil {
+				return errSymlink(target)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, &PathError{Op: "openat", Path: name, Err: err}
+	}
+	if !supportsCloseOnExec {
+		syscall.CloseOnExec(fd)
+	}
+	return newFile(uintptr(fd), joinPath(r.Name(), name), kindOpenFile), nil
+}
+
+func rootMkdir(r *Root, name string, perm FileMode) error {
+	err := doInRoot(r, name, func(dirfd int, base string) error {
+		return ignoringEINTR(func() error {
+			return unix.Mkdirat(dirfd, base, syscallMode(perm))
+		})
+	})
+	if err != nil {
+		return &PathError{Op: "mkdirat", Path: name, Err: err}
+	}
+	return nil
+}
+
+func rootRemove(r *Root, name string) error {
+	err := doInRoot(r, name, func(dirfd int, base string) error {
+		// See the comment in Remove.
+		e := ignoringEINTR(func() error {
+			return unix.Unlinkat(dirfd, base, 0)
+		})
+		if e == nil {
+			return nil
+		}
+		e1 := ignoringEINTR(func() error {
+			return unix.Unlinkat(dirfd, base, unix.AT_REMOVEDIR)
+		})
+		if e1 == nil {
+			return nil
+		}
+		if e1 != syscall.ENOTDIR {
+			e = e1
+		}
+		return e
+	})
+	if err != nil {
+		return &PathError{Op: "removeat", Path: name, Err: err}
+	}
+	return nil
+}
+
+func rootStat(r *Root, name string, lstat bool) (FileInfo, error) {
+	var fs fileStat
+	err := doInRoot(r, name, func(dirfd int, base string) error {
+		err := ignoringEINTR(func() error {
+			return unix.Fstatat(dirfd, base, &fs.sys, unix.AT_SYMLINK_NOFOLLOW)
+		})
+		if err == nil && !lstat && fs.sys.Mode&syscall.S_IFMT == syscall.S_IFLNK {
+			target, err := readlinkat(dirfd, base)
+			if err != nil {
+				return err
+			}
+			return errSymlink(target)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, &PathError{Op: "statat", Path: name, Err: err}
+	}
+	fillFileStatFromSys(&fs, name)
+	return &fs, nil
+}
@@ -5,6 +5,9 @@
 package debug
 
 import (
+	"fmt"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -15,15 +18,37 @@ func modinfo() string
 // in the running binary. The information is available only
 // in binaries built with module support.
 func ReadBuildInfo() (info *BuildInfo, ok bool) {
-	return readBuildInfo(modinfo())
+	data := modinfo()
+	if len(data) < 32 {
+		return nil, false
+	}
+	data = data[16 : len(data)-16]
+	bi, err := ParseBuildInfo(data)
+	if err != nil {
+		return nil, false
+	}
+
+	// The go version is stored separately from other build info, mostly for
+	// historical reasons. It is not part of the modinfo() string, and
+	// ParseBuildInfo does not recognize it. We inject it here to hide this
+	// awkwardness from the user.
+	bi.GoVersion = runtime.Version()
+
+	return bi, true
 }
 
-// BuildInfo represents the build information read from
-// the running binary.
+// BuildInfo represents the build information read from a Go binary.
 type BuildInfo struct {
+	// GoVersion is the version of the Go toolchain that built the binary
+	// (for example, "go1.16.4").
+	GoVersion string
+
 	Path string    // The main package path
 	Main Module    // The module containing the main package
 	Deps []*Module // Module dependencies
+
+	// Settings describes the build settings used to build the binary.
+	Settings []BuildSetting
 }
 
 // Module represents a module.
@@ -34,22 +59,114 @@ type Module struct {
 	Replace *Module // replaced by this module
 }
 
-func readBuildInfo(data string) (*BuildInfo, bool) {
-	if len(data) < 32 {
-		return nil, false
+// A BuildSetting is a key-value pair describing one setting that influenced a build.
+//
+// Defined keys include:
+//
+//   - -compiler: the compiler toolchain flag used (typically "gc")
+//   - -asmflags, -gcflags, -gccgoflags, -ldflags: the corresponding build flags,
+//     when given on the command line (-ldflags is omitted with -trimpath)
+//   - -race, -msan, -trimpath: "true" when the corresponding flag was given
+//   - -tags: the comma-separated list of build tags
+//   - CGO_ENABLED: the effective CGO_ENABLED environment variable
+//   - CGO_CFLAGS, CGO_CPPFLAGS, CGO_CXXFLAGS, CGO_LDFLAGS: the effective cgo
+//     environment variables, when cgo is enabled
+//   - GOARCH: the architecture target
+//   - GOARM/GO386/GOMIPS/etc: the architecture feature level for GOARCH
+//   - GOOS: the operating system target
+//   - vcs: the version control system for the source tree where the build ran
+//   - vcs.revision: the revision identifier for the current commit or checkout
+//   - vcs.time: the modification time associated with vcs.revision, in RFC3339 format
+//   - vcs.modified: true or false indicating whether the source tree had local modifications
+type BuildSetting struct {
+	// Key and Value describe the build setting.
+	// Key must not contain an equals sign, space, tab, or newline.
+	// Value must not contain newlines ('\n').
+	Key, Value string
+}
+
+// quoteKey reports whether key is required to be quoted.
+func quoteKey(key string) bool {
+	return len(key) == 0 || strings.ContainsAny(key, "= \t\r\n\"`")
+}
+
+// quoteValue reports whether value is required to be quoted.
+func quoteValue(value string) bool {
+	return strings.ContainsAny(value, " \t\r\n\"`")
+}
+
+// String returns a string representation of a BuildInfo,
+// in the format printed by 'go version -m'.
+func (bi *BuildInfo) String() string {
+	buf := new(strings.Builder)
+	if bi.GoVersion != "" {
+		fmt.Fprintf(buf, "go\t%s\n", bi.GoVersion)
 	}
-	data = data[16 : len(data)-16]
+	if bi.Path != "" {
+		fmt.Fprintf(buf, "path\t%s\n", bi.Path)
+	}
+	var formatMod func(string, Module)
+	formatMod = func(word string, m Module) {
+		buf.WriteString(word)
+		buf.WriteByte('\t')
+		buf.WriteString(m.Path)
+		buf.WriteByte('\t')
+		buf.WriteString(m.Version)
+		if m.Replace != nil {
+			buf.WriteByte('\n')
+			formatMod("=>", *m.Replace)
+			return
+		}
+		buf.WriteByte('\t')
+		buf.WriteString(m.Sum)
+		buf.WriteByte('\n')
+	}
+	if bi.Main != (Module{}) {
+		formatMod("mod", bi.Main)
+	}
+	for _, dep := range bi.Deps {
+		formatMod("dep", *dep)
+	}
+	for _, s := range bi.Settings {
+		key := s.Key
+		if quoteKey(key) {
+			key = strconv.Quote(key)
+		}
+		value := s.Value
+		if quoteValue(value) {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(buf, "build\t%s=%s\n", key, value)
+	}
+
+	return buf.String()
+}
+
+// ParseBuildInfo parses the string returned by (*BuildInfo).String,
+// restoring the original BuildInfo,
+// except that the GoVersion field is not set.
+// Programs should normally not call this function,
+// but instead call ReadBuildInfo, debug/buildinfo.ReadFile,
+// or debug/buildinfo.Read.
+func ParseBuildInfo(data string) (bi *BuildInfo, err error) {
+	lineNum := 1
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("could not parse Go build info: line %d: %w", lineNum, err)
+		}
+	}()
 
 	const (
-		pathLine = "path\t"
-		modLine  = "mod\t"
-		depLine  = "dep\t"
-		repLine  = "=>\t"
+		pathLine  = "path\t"
+		modLine   = "mod\t"
+		depLine   = "dep\t"
+		repLine   = "=>\t"
+		buildLine = "build\t"
 	)
 
-	readEntryFirstLine := func(elem []string) (Module, bool) {
+	readModuleLine := func(elem []string) (Module, error) {
 		if len(elem) != 2 && len(elem) != 3 {
-			return Module{}, false
+			return Module{}, fmt.Errorf("expected 2 or 3 columns; got %d", len(elem))
 		}
 		sum := ""
 		if len(elem) == 3 {
@@ -59,16 +176,15 @@ func readBuildInfo(data string) (*BuildInfo, bool) {
 			Path:    elem[0],
 			Version: elem[1],
 			Sum:     sum,
-		}, true
+		}, nil
 	}
 
+	bi = new(BuildInfo)
 	var (
-		info = &BuildInfo{}
 		last *Module
 		line string
-		ok   bool
 	)
-	// Reverse of cmd/go/internal/modload.PackageBuildInfo
+	// Reverse of BuildInfo.String(), except for go version.
 	for len(data) > 0 {
 		i := strings.IndexByte(data, '\n')
 		if i < 0 {
@@ -77,30 +193,29 @@ func readBuildInfo(data string) (*BuildInfo, bool) {
 		line, data = data[:i], data[i+1:]
 		switch {
 		case strings.HasPrefix(line, pathLine):
-			elem := line[len(pathLine):]
-			info.Path = elem
+			bi.Path = line[len(pathLine):]
 		case strings.HasPrefix(line, modLine):
 			elem := strings.Split(line[len(modLine):], "\t")
-			last = &info.Main
-			*last, ok = readEntryFirstLine(elem)
-			if !ok {
-				return nil, false
+			last = &bi.Main
+			*last, err = readModuleLine(elem)
+			if err != nil {
+				return nil, err
 			}
 		case strings.HasPrefix(line, depLine):
 			elem := strings.Split(line[len(depLine):], "\t")
 			last = new(Module)
-			info.Deps = append(info.Deps, last)
-			*last, ok = readEntryFirstLine(elem)
-			if !ok {
-				return nil, false
+			bi.Deps = append(bi.Deps, last)
+			*last, err = readModuleLine(elem)
+			if err != nil {
+				return nil, err
 			}
 		case strings.HasPrefix(line, repLine):
 			elem := strings.Split(line[len(repLine):], "\t")
 			if len(elem) != 3 {
-				return nil, false
+				return nil, fmt.Errorf("expected 3 columns for replacement; got %d", len(elem))
 			}
 			if last == nil {
-				return nil, false
+				return nil, fmt.Errorf("replacement with no module on previous line")
 			}
 			last.Replace = &Module{
 				Path:    elem[0],
@@ -108,7 +223,95 @@ func readBuildInfo(data string) (*BuildInfo, bool) {
 				Sum:     elem[2],
 			}
 			last = nil
+		case strings.HasPrefix(line, buildLine):
+			s, err := parseBuildSetting(line[len(buildLine):])
+			if err != nil {
+				return nil, err
+			}
+			bi.Settings = append(bi.Settings, s)
+		}
+		lineNum++
+	}
+	return bi, nil
+}
+
+// parseBuildSetting parses the key=value part of a "build" line,
+// reversing the quoting done by BuildInfo.String.
+func parseBuildSetting(kv string) (BuildSetting, error) {
+	if len(kv) < 1 {
+		return BuildSetting{}, fmt.Errorf("build line missing '='")
+	}
+
+	var key, rawValue string
+	switch kv[0] {
+	case '=':
+		return BuildSetting{}, fmt.Errorf("build line with missing key")
+
+	case '`', '"':
+		rawKey, ok := quotedPrefix(kv)
+		if !ok {
+			return BuildSetting{}, fmt.Errorf("invalid quoted key in build line")
+		}
+		if len(kv) == len(rawKey) {
+			return BuildSetting{}, fmt.Errorf("build line missing '=' after quoted key")
+		}
+		if c := kv[len(rawKey)]; c != '=' {
+			return BuildSetting{}, fmt.Errorf("unexpected character after quoted key: %q", c)
+		}
+		key, _ = strconv.Unquote(rawKey)
+		rawValue = kv[len(rawKey)+1:]
+
+	default:
+		i := strings.IndexByte(kv, '=')
+		if i < 0 {
+			return BuildSetting{}, fmt.Errorf("build line missing '=' after key")
+		}
+		key, rawValue = kv[:i], kv[i+1:]
+		if quoteKey(key) {
+			return BuildSetting{}, fmt.Errorf("unquoted key %q must be quoted", key)
+		}
+	}
+
+	var value string
+	if len(rawValue) > 0 {
+		switch rawValue[0] {
+		case '`', '"':
+			var err error
+			value, err = strconv.Unquote(rawValue)
+			if err != nil {
+				return BuildSetting{}, fmt.Errorf("invalid quoted value in build line")
+			}
+
+		default:
+			value = rawValue
+			if quoteValue(value) {
+				return BuildSetting{}, fmt.Errorf("unquoted value %q must be quoted", value)
+			}
+		}
+	}
+
+	return BuildSetting{Key: key, Value: value}, nil
+}
+
+// quotedPrefix returns the quoted string (as understood by strconv.Unquote)
+// at the prefix of s, and reports whether one was found.
+func quotedPrefix(s string) (string, bool) {
+	if len(s) == 0 {
+		return "", false
+	}
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == quote:
+			if _, err := strconv.Unquote(s[:i+1]); err != nil {
+				return "", false
+			}
+			return s[:i+1], true
+		case c == '\\' && quote == '"':
+			i++ // skip escaped character
+		case c == '\n':
+			return "", false
 		}
 	}
-	return info, true
+	return "", false
 }
@@ -0,0 +1,115 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"reflect"
+	. "runtime/debug"
+	"strings"
+	"testing"
+)
+
+// strip removes two leading tabs after each newline of s.
+func strip(s string) string {
+	replaced := strings.ReplaceAll(s, "\n\t\t", "\n")
+	if len(replaced) > 0 && replaced[0] == '\n' {
+		replaced = replaced[1:]
+	}
+	return replaced
+}
+
+var buildInfoTests = []string{
+	// Main module only.
+	strip(`
+		path	rsc.io/fortune
+		mod	rsc.io/fortune	v1.0.0	h1:gZTv/dLvDldYbfr5i1VFoNTx9NnKuM3B9ALQdhrPv4E=
+		`),
+
+	// Dependencies, including a replacement with a local directory.
+	strip(`
+		path	example.com/m
+		mod	example.com/m	(devel)	
+		dep	golang.org/x/text	v0.3.0	h1:g61tztE5qeGQ89tm6NTjjM9VPIm088od1l6aSorWRWg=
+		dep	rsc.io/quote	v1.5.2
+		=>	../quote		
+		`),
+
+	// Build settings, including values that must be quoted.
+	strip(`
+		path	example.com/m
+		mod	example.com/m	(devel)	
+		build	-compiler=gc
+		build	-ldflags="-X main.version=1 -s"
+		build	"key with space"=value
+		build	"a=b"=
+		build	CGO_ENABLED=0
+		build	GOARCH=amd64
+		build	GOOS=linux
+		build	vcs=git
+		build	vcs.revision=4e5a8c8b7a6c9e3f1d2b0a9c8e7f6d5c4b3a2f1e
+		build	vcs.time=2021-10-18T12:00:00Z
+		build	vcs.modified=false
+		`),
+}
+
+func TestParseBuildInfoRoundTrip(t *testing.T) {
+	for _, s := range buildInfoTests {
+		bi, err := ParseBuildInfo(s)
+		if err != nil {
+			t.Errorf("ParseBuildInfo(%q): %v", s, err)
+			continue
+		}
+		if got := bi.String(); got != s {
+			t.Errorf("ParseBuildInfo(%q).String() = %q, want original", s, got)
+		}
+		bi2, err := ParseBuildInfo(bi.String())
+		if err != nil {
+			t.Errorf("ParseBuildInfo(%q) round trip: %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(bi, bi2) {
+			t.Errorf("ParseBuildInfo(%q) round trip:\n\t%#v\nwant\n\t%#v", s, bi2, bi)
+		}
+	}
+}
+
+func TestParseBuildInfoSettings(t *testing.T) {
+	bi, err := ParseBuildInfo(buildInfoTests[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BuildSetting{
+		{"-compiler", "gc"},
+		{"-ldflags", "-X main.version=1 -s"},
+		{"key with space", "value"},
+		{"a=b", ""},
+		{"CGO_ENABLED", "0"},
+		{"GOARCH", "amd64"},
+		{"GOOS", "linux"},
+		{"vcs", "git"},
+		{"vcs.revision", "4e5a8c8b7a6c9e3f1d2b0a9c8e7f6d5c4b3a2f1e"},
+		{"vcs.time", "2021-10-18T12:00:00Z"},
+		{"vcs.modified", "false"},
+	}
+	if !reflect.DeepEqual(bi.Settings, want) {
+		t.Errorf("Settings = %q, want %q", bi.Settings, want)
+	}
+}
+
+func TestParseBuildInfoErrors(t *testing.T) {
+	for _, s := range []string{
+		"build\tkey\n",                // missing '='
+		"build\t=value\n",             // missing key
+		"build\t\"key\"value\n",       // junk after quoted key
+		"build\tkey=two words\n",      // value must be quoted
+		"build\tkey=\"unterminated\n", // bad quoted value
+		"mod\tonly-one-column\n",
+		"=>\texample.com/r\tv1.0.0\th1:x\n", // replacement without module
+	} {
+		if _, err := ParseBuildInfo(s); err == nil {
+			t.Errorf("ParseBuildInfo(%q) succeeded unexpectedly", s)
+		}
+	}
+}
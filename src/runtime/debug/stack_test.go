@@ -5,8 +5,13 @@
 package debug_test
 
 import (
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"path/filepath"
 	. "runtime/debug"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -63,3 +68,55 @@ func check(t *testing.T, line, has string) {
 		t.Errorf("expected %q in %q", has, line)
 	}
 }
+
+func TestSetCrashOutput(t *testing.T) {
+	if mode := os.Getenv("GO_RUNTIME_DEBUG_CRASH_OUTPUT"); mode != "" {
+		f, err := os.Create(os.Getenv("GO_RUNTIME_DEBUG_CRASH_FILE"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := SetCrashOutput(f); err != nil {
+			t.Fatal(err)
+		}
+		// The runtime holds its own copy of the descriptor.
+		f.Close()
+		switch mode {
+		case "panic":
+			panic("crash output test")
+		case "throw":
+			var mu sync.Mutex
+			mu.Unlock()
+		}
+		t.Fatalf("unknown mode %q", mode)
+	}
+	testenv.MustHaveExec(t)
+
+	for _, tc := range []struct {
+		mode string
+		want string
+	}{
+		{"panic", "panic: crash output test"},
+		{"throw", "fatal error: sync: unlock of unlocked mutex"},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			crashFile := filepath.Join(t.TempDir(), "crash.txt")
+			cmd := exec.Command(os.Args[0], "-test.run=^TestSetCrashOutput$")
+			cmd.Env = append(os.Environ(),
+				"GO_RUNTIME_DEBUG_CRASH_OUTPUT="+tc.mode,
+				"GO_RUNTIME_DEBUG_CRASH_FILE="+crashFile)
+			stderr, err := cmd.CombinedOutput()
+			if err == nil {
+				t.Fatalf("child process did not crash; output:\n%s", stderr)
+			}
+			crash, err := os.ReadFile(crashFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, out := range []string{string(stderr), string(crash)} {
+				if !strings.Contains(out, tc.want) || !strings.Contains(out, "goroutine ") {
+					t.Errorf("crash output does not contain %q and a traceback:\n%s", tc.want, out)
+				}
+			}
+		})
+	}
+}
@@ -109,9 +109,16 @@ const (
 	SEGV_MAPERR = C.SEGV_MAPERR & 0xFFFF
 	SEGV_ACCERR = C.SEGV_ACCERR & 0xFFFF
 
+	SI_KERNEL = C.SI_KERNEL
+	SI_TIMER  = C.SI_TIMER
+
 	ITIMER_REAL    = C.ITIMER_REAL
 	ITIMER_PROF    = C.ITIMER_PROF
 	ITIMER_VIRTUAL = C.ITIMER_VIRTUAL
+
+	CLOCK_THREAD_CPUTIME_ID = C.CLOCK_THREAD_CPUTIME_ID
+
+	SIGEV_THREAD_ID = C.SIGEV_THREAD_ID
 )
 
 type Timespec C.struct_timespec
@@ -119,6 +126,7 @@ type StackT C.stack_t
 type Sigcontext C.struct_sigcontext
 type Ucontext C.struct_ucontext
 type Timeval C.struct_timeval
+type Itimerspec C.struct_itimerspec
 type Itimerval C.struct_itimerval
 type Siginfo C.struct_xsiginfo
 type Sigaction C.struct_xsigaction
@@ -4,6 +4,8 @@
 
 package runtime
 
+import "unsafe"
+
 // Constants
 const (
 	_EINTR  = 0x4
@@ -72,6 +74,8 @@ const (
 	_BUS_OBJERR     = 0x3
 	_SEGV_MAPERR    = 0x1
 	_SEGV_ACCERR    = 0x2
+	_SI_KERNEL      = 0x80
+	_SI_TIMER       = -0x2
 	_ITIMER_REAL    = 0
 	_ITIMER_PROF    = 0x2
 	_ITIMER_VIRTUAL = 0x1
@@ -79,6 +83,10 @@ const (
 	_O_NONBLOCK     = 0x800
 	_O_CLOEXEC      = 0x80000
 
+	_CLOCK_THREAD_CPUTIME_ID = 0x3
+
+	_SIGEV_THREAD_ID = 0x4
+
 	_EPOLLIN       = 0x1
 	_EPOLLOUT      = 0x4
 	_EPOLLERR      = 0x8
@@ -153,11 +161,31 @@ func (tv *timeval) set_usec(x int32) {
 	tv.tv_usec = x
 }
 
+type itimerspec struct {
+	it_interval timespec
+	it_value    timespec
+}
+
 type itimerval struct {
 	it_interval timeval
 	it_value    timeval
 }
 
+type sigeventFields struct {
+	value  uintptr
+	signo  int32
+	notify int32
+	// below here is a union; sigev_notify_thread_id is the only field we use
+	sigev_notify_thread_id int32
+}
+
+type sigevent struct {
+	sigeventFields
+
+	// Pad struct to the max size in the kernel.
+	_ [_sigev_max_size - unsafe.Sizeof(sigeventFields{})]byte
+}
+
 type siginfo struct {
 	si_signo int32
 	si_errno int32
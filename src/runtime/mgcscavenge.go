@@ -194,7 +194,7 @@ func wakeScavenger() {
 		// In the case that we're racing with there's the low chance that
 		// we experience a spurious wake-up of the scavenger, but that's
 		// totally safe.
-		stopTimer(scavenge.timer)
+		deltimer(scavenge.timer)
 
 		// Unpark the goroutine and tell it that there may have been a pacing
 		// change. Note that we skip the scheduler's runnext slot because we
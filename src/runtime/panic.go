@@ -1105,15 +1105,17 @@ func sync_throw(s string) {
 
 //go:nosplit
 func throw(s string) {
+	// Mark the M as throwing before printing anything so that the
+	// message is also copied to the crash output file, if any.
+	gp := getg()
+	if gp.m.throwing == 0 {
+		gp.m.throwing = 1
+	}
 	// Everything throw does should be recursively nosplit so it
 	// can be called even when it's unsafe to grow the stack.
 	systemstack(func() {
 		print("fatal error: ", s, "\n")
 	})
-	gp := getg()
-	if gp.m.throwing == 0 {
-		gp.m.throwing = 1
-	}
 	fatalthrow()
 	*(*int)(nil) = 0 // not reached
 }
@@ -1127,6 +1129,30 @@ var runningPanicDefers uint32
 // panicking is incremented and decremented atomically.
 var panicking uint32
 
+// crashFD is an optional file descriptor to use for fatal panics, as
+// set by debug.SetCrashOutput. If it is a valid fd (not all ones),
+// writeErr also writes crash output to it, in addition to standard error.
+var crashFD = ^uintptr(0)
+
+//go:linkname setCrashFD runtime/debug.setCrashFD
+func setCrashFD(fd uintptr) uintptr {
+	return atomic.Xchguintptr(&crashFD, fd)
+}
+
+// writeCrashOutput writes a copy of b to the crash output file set by
+// debug.SetCrashOutput, if any, but only while the program is crashing.
+//
+//go:nosplit
+func writeCrashOutput(b []byte) {
+	gp := getg()
+	if gp != nil && gp.m != nil && (gp.m.dying > 0 || gp.m.throwing > 0) ||
+		gp == nil && atomic.Load(&panicking) > 0 {
+		if fd := atomic.Loaduintptr(&crashFD); fd != ^uintptr(0) {
+			write(fd, unsafe.Pointer(&b[0]), int32(len(b)))
+		}
+	}
+}
+
 // paniclk is held while printing the panic information and stack trace,
 // so that two concurrent panics don't overlap their output.
 var paniclk mutex
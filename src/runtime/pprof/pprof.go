@@ -760,16 +760,42 @@ var cpu struct {
 // being done by the main program.
 func StartCPUProfile(w io.Writer) error {
 	// The runtime routines allow a variable profiling rate,
-	// but in practice operating systems cannot trigger signals
+	// but historically operating systems could not trigger signals
 	// at more than about 500 Hz, and our processing of the
 	// signal is not cheap (mostly getting the stack trace).
 	// 100 Hz is a reasonable choice: it is frequent enough to
 	// produce useful data, rare enough not to bog down the
 	// system, and a nice round number to make it easy to
-	// convert sample counts to seconds. Instead of requiring
-	// each client to specify the frequency, we hard code it.
-	const hz = 100
+	// convert sample counts to seconds. Clients that need a
+	// different frequency can use StartCPUProfileWithRate.
+	return startCPUProfile(w, defaultCPUProfileRate)
+}
+
+// defaultCPUProfileRate is the sampling rate, in Hz, used by StartCPUProfile.
+const defaultCPUProfileRate = 100
+
+// StartCPUProfileWithRate is like StartCPUProfile but samples the
+// program hz times per second of CPU time instead of the default 100 Hz.
+// It returns an error if hz is not positive or if profiling is already
+// enabled.
+//
+// Unlike calling runtime.SetCPUProfileRate before StartCPUProfile, the
+// rate is set and profiling is started as one step, so the rate recorded
+// in the profile always matches the one used to collect it.
+//
+// Higher rates give more detailed profiles of short runs at the cost of
+// more overhead in the profiled program. On Linux, each thread is sampled
+// by its own timer, so rates well above 100 Hz remain accurate for
+// programs using many cores. Other systems may be unable to deliver
+// profiling signals much faster than a few hundred times per second.
+func StartCPUProfileWithRate(w io.Writer, hz int) error {
+	if hz <= 0 {
+		return fmt.Errorf("invalid cpu profiling rate %d", hz)
+	}
+	return startCPUProfile(w, hz)
+}
 
+func startCPUProfile(w io.Writer, hz int) error {
 	cpu.Lock()
 	defer cpu.Unlock()
 	if cpu.done == nil {
@@ -103,6 +103,85 @@ func TestCPUProfileMultithreaded(t *testing.T) {
 	})
 }
 
+func TestCPUProfileWithRate(t *testing.T) {
+	if err := StartCPUProfileWithRate(io.Discard, 0); err == nil {
+		StopCPUProfile()
+		t.Fatalf("StartCPUProfileWithRate with rate 0 succeeded")
+	}
+
+	const hz = 500
+	var prof bytes.Buffer
+	if err := StartCPUProfileWithRate(&prof, hz); err != nil {
+		t.Fatal(err)
+	}
+	if err := StartCPUProfile(io.Discard); err == nil {
+		t.Errorf("StartCPUProfile succeeded while profiling at %d Hz", hz)
+	}
+	cpuHogger(cpuHog1, &salt1, 100*time.Millisecond)
+	StopCPUProfile()
+
+	p := parseProfile(t, prof.Bytes(), func(uintptr, []*profile.Location, map[string][]string) {})
+	if want := int64(time.Second / hz); p.Period != want {
+		t.Errorf("profile period = %d, want %d", p.Period, want)
+	}
+}
+
+// TestCPUProfileMultithreadMagnitude checks that the CPU profile of a program
+// using many threads at once accounts for roughly all of the CPU time that
+// the operating system reports, rather than being capped by the delivery
+// limits of a process-wide profiling timer.
+func TestCPUProfileMultithreadMagnitude(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("issue 35057 is only confirmed on Linux")
+	}
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	parallelism := runtime.GOMAXPROCS(0)
+	if parallelism < 2 {
+		t.Skip("skipping on single-CPU system")
+	}
+	if parallelism > 8 {
+		parallelism = 8
+	}
+
+	var prof bytes.Buffer
+	if err := StartCPUProfile(&prof); err != nil {
+		t.Fatal(err)
+	}
+	user, system, ok := diffCPUTime(func() {
+		var wg sync.WaitGroup
+		salts := make([]int, parallelism)
+		for i := range salts {
+			wg.Add(1)
+			go func(salt *int) {
+				defer wg.Done()
+				cpuHogger(cpuHog1, salt, time.Second)
+			}(&salts[i])
+		}
+		wg.Wait()
+	})
+	StopCPUProfile()
+	if !ok {
+		t.Skip("CPU time not available")
+	}
+
+	var samples uintptr
+	p := parseProfile(t, prof.Bytes(), func(count uintptr, _ []*profile.Location, _ map[string][]string) {
+		samples += count
+	})
+	profiled := time.Duration(int64(samples) * p.Period)
+	osTime := user + system
+	t.Logf("profile reports %v of CPU time, OS reports %v", profiled, osTime)
+
+	// Allow generous slack for sampling noise and for CPU time spent
+	// outside of the hogs, but catch the multi-fold undercounting of a
+	// process-wide timer.
+	if profiled < osTime*7/10 {
+		t.Errorf("profile reports %v of CPU time, want at least 70%% of the %v reported by the OS", profiled, osTime)
+	}
+}
+
 // containsInlinedCall reports whether the function body for the function f is
 // known to contain an inlined function call within the first maxBytes bytes.
 func containsInlinedCall(f interface{}, maxBytes int) bool {
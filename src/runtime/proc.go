@@ -920,6 +920,21 @@ func casgstatus(gp *g, oldval, newval uint32) {
 			nextYield = nanotime() + yieldDelay/2
 		}
 	}
+
+	if sg := gp.syncGroup; sg != nil {
+		systemstack(func() {
+			sg.changegstatus(gp, oldval, newval)
+		})
+	}
+}
+
+// casGToWaiting transitions gp from old to _Gwaiting, and sets the wait reason.
+//
+// Use this over casgstatus when possible to ensure that a waitreason is set,
+// since the wait reason is consulted for goroutines in a synctest bubble.
+func casGToWaiting(gp *g, old uint32, reason waitReason) {
+	gp.waitreason = reason
+	casgstatus(gp, old, _Gwaiting)
 }
 
 // casgstatus(gp, oldstatus, Gcopystack), assuming oldstatus is Gwaiting or Grunnable.
@@ -992,7 +1007,7 @@ func stopTheWorld(reason string) {
 		// must have preempted all goroutines, including any attempting
 		// to scan our stack, in which case, any stack shrinking will
 		// have already completed by the time we exit.
-		casgstatus(gp, _Grunning, _Gwaiting)
+		casGToWaiting(gp, _Grunning, waitReasonStoppingTheWorld)
 		stopTheWorldWithSema()
 		casgstatus(gp, _Gwaiting, _Grunning)
 	})
@@ -3300,6 +3315,17 @@ func park_m(gp *g) {
 		traceGoPark(_g_.m.waittraceev, _g_.m.waittraceskip)
 	}
 
+	sg := gp.syncGroup
+	if sg != nil {
+		// Keep the synctest group active until the unlock function
+		// has run, so that the group is not considered idle while
+		// this goroutine is only part way through blocking.
+		sg.incActive()
+		if raceenabled && gp.waitreason.isIdleInSynctest() {
+			racereleasemergeg(gp, sg.raceaddr())
+		}
+	}
+
 	casgstatus(gp, _Grunning, _Gwaiting)
 	dropg()
 
@@ -3312,9 +3338,15 @@ func park_m(gp *g) {
 				traceGoUnpark(gp, 2)
 			}
 			casgstatus(gp, _Gwaiting, _Grunnable)
+			if sg != nil {
+				sg.decActive()
+			}
 			execute(gp, true) // Schedule it back, never returns.
 		}
 	}
+	if sg != nil {
+		sg.decActive()
+	}
 	schedule()
 }
 
@@ -3420,10 +3452,14 @@ func goexit1() {
 func goexit0(gp *g) {
 	_g_ := getg()
 
+	if raceenabled && gp.syncGroup != nil {
+		racereleasemergeg(gp, gp.syncGroup.raceaddr())
+	}
 	casgstatus(gp, _Grunning, _Gdead)
 	if isSystemGoroutine(gp, false) {
 		atomic.Xadd(&sched.ngsys, -1)
 	}
+	gp.syncGroup = nil
 	gp.m = nil
 	locked := gp.lockedm != 0
 	gp.lockedm = 0
@@ -4117,6 +4153,9 @@ func newproc1(fn *funcval, argp unsafe.Pointer, narg int32, callergp *g, callerp
 	}
 	if isSystemGoroutine(newg, false) {
 		atomic.Xadd(&sched.ngsys, +1)
+	} else {
+		// Only user goroutines inherit the synctest bubble.
+		newg.syncGroup = callergp.syncGroup
 	}
 	casgstatus(newg, _Gdead, _Grunnable)
 
@@ -470,6 +470,7 @@ type g struct {
 	labels         unsafe.Pointer // profiler labels
 	timer          *timer         // cached timer for time.Sleep
 	selectDone     uint32         // are we participating in a select and did someone win the race?
+	syncGroup      *synctestGroup // synctest bubble containing this goroutine, if any
 
 	// Per-G GC state
 
@@ -1015,6 +1016,9 @@ const (
 	waitReasonGCWorkerIdle                            // "GC worker (idle)"
 	waitReasonPreempted                               // "preempted"
 	waitReasonDebugCall                               // "debug call"
+	waitReasonStoppingTheWorld                        // "stopping the world"
+	waitReasonSynctestRun                             // "synctest.Run"
+	waitReasonSynctestWait                            // "synctest.Wait"
 )
 
 var waitReasonStrings = [...]string{
@@ -1045,6 +1049,9 @@ var waitReasonStrings = [...]string{
 	waitReasonGCWorkerIdle:          "GC worker (idle)",
 	waitReasonPreempted:             "preempted",
 	waitReasonDebugCall:             "debug call",
+	waitReasonStoppingTheWorld:      "stopping the world",
+	waitReasonSynctestRun:           "synctest.Run",
+	waitReasonSynctestWait:          "synctest.Wait",
 }
 
 func (w waitReason) String() string {
@@ -1054,6 +1061,26 @@ func (w waitReason) String() string {
 	return waitReasonStrings[w]
 }
 
+// isIdleInSynctest reports whether a goroutine blocked for reason w is
+// durably blocked within a synctest bubble: it can only be unblocked by
+// another goroutine in the bubble, or by the bubble's fake clock.
+func (w waitReason) isIdleInSynctest() bool {
+	switch w {
+	case waitReasonChanReceiveNilChan,
+		waitReasonChanSendNilChan,
+		waitReasonSelect,
+		waitReasonSelectNoCases,
+		waitReasonChanReceive,
+		waitReasonChanSend,
+		waitReasonSleep,
+		waitReasonSyncCondWait,
+		waitReasonSynctestRun,
+		waitReasonSynctestWait:
+		return true
+	}
+	return false
+}
+
 var (
 	allm       *m
 	gomaxprocs int32
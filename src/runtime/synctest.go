@@ -0,0 +1,350 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+// A synctestGroup is a group of goroutines started by synctest.Run,
+// sharing a fake clock. Goroutines started by a goroutine in the group
+// join the group.
+//
+// The group is idle when every goroutine in it is durably blocked:
+// blocked in a way that only another goroutine in the group, or the
+// group's fake clock, can unblock it (see waitReason.isIdleInSynctest).
+// When the group becomes idle, the goroutine blocked in synctest.Wait,
+// if any, is woken. Otherwise the root goroutine is woken to advance
+// the fake clock to the next timer, or to report a deadlock if there
+// is no such timer.
+type synctestGroup struct {
+	now int64 // current fake time; accessed atomically
+
+	mu      mutex
+	timers  *timer // pending timers, sorted by when; linked by bubbleNext
+	root    *g     // goroutine that called synctest.Run
+	waiter  *g     // goroutine blocked in synctest.Wait, if any
+	waiting bool   // true if a goroutine is calling synctest.Wait
+	total   int    // total goroutines in the group
+	running int    // goroutines in the group that are not durably blocked
+	active  int    // other sources of activity that keep the group from idling
+}
+
+// synctestBaseTime is the initial value of a group's fake clock:
+// midnight UTC, January 1, 2000.
+const synctestBaseTime = 946684800000000000
+
+// nanotime returns the group's current fake time.
+func (sg *synctestGroup) nanotime() int64 {
+	return int64(atomic.Load64((*uint64)(unsafe.Pointer(&sg.now))))
+}
+
+// raceaddr returns the address used to record happens-before
+// relationships created by the group. A goroutine that durably blocks
+// or exits releases it; a goroutine resumed by the group becoming idle,
+// or woken by the group's clock, acquires it.
+func (sg *synctestGroup) raceaddr() unsafe.Pointer {
+	return unsafe.Pointer(sg)
+}
+
+// timeNow returns the group's current fake time in the form of time.now.
+func (sg *synctestGroup) timeNow() (sec int64, nsec int32, mono int64) {
+	now := sg.nanotime()
+	return now / 1e9, int32(now % 1e9), now
+}
+
+// time_runtimeNano returns the current value of the runtime clock,
+// as observed by package time. Within a synctest bubble, it is the
+// bubble's fake clock.
+//go:linkname time_runtimeNano time.runtimeNano
+func time_runtimeNano() int64 {
+	if sg := getg().syncGroup; sg != nil {
+		return sg.nanotime()
+	}
+	return nanotime()
+}
+
+// changegstatus is called when the status of gp, a goroutine in the
+// group, changes from oldval to newval. It updates the group's count of
+// running goroutines and wakes a goroutine if the group is now idle.
+func (sg *synctestGroup) changegstatus(gp *g, oldval, newval uint32) {
+	// The stack copying states are transient and are entered and left
+	// without going through casgstatus on some paths; ignore them.
+	if oldval == _Gcopystack || newval == _Gcopystack {
+		return
+	}
+
+	totalDelta := 0
+	wasRunning := true
+	switch oldval {
+	case _Gdead:
+		wasRunning = false
+		totalDelta++
+	case _Gwaiting:
+		if gp.waitreason.isIdleInSynctest() {
+			wasRunning = false
+		}
+	}
+	isRunning := true
+	switch newval {
+	case _Gdead:
+		isRunning = false
+		totalDelta--
+	case _Gwaiting:
+		if gp.waitreason.isIdleInSynctest() {
+			isRunning = false
+		}
+	}
+	if wasRunning == isRunning && totalDelta == 0 {
+		return
+	}
+
+	lock(&sg.mu)
+	sg.total += totalDelta
+	if wasRunning != isRunning {
+		if isRunning {
+			sg.running++
+		} else {
+			sg.running--
+		}
+	}
+	wake := sg.maybeWakeLocked()
+	unlock(&sg.mu)
+	if wake != nil {
+		goready(wake, 0)
+	}
+}
+
+// incActive increments the group's active count.
+// While the active count is non-zero, the group is not idle.
+func (sg *synctestGroup) incActive() {
+	lock(&sg.mu)
+	sg.active++
+	unlock(&sg.mu)
+}
+
+// decActive decrements the group's active count,
+// waking a goroutine if the group is now idle.
+func (sg *synctestGroup) decActive() {
+	lock(&sg.mu)
+	sg.active--
+	if sg.active < 0 {
+		throw("synctest: active < 0")
+	}
+	wake := sg.maybeWakeLocked()
+	unlock(&sg.mu)
+	if wake != nil {
+		goready(wake, 0)
+	}
+}
+
+// maybeWakeLocked returns the goroutine to wake if the group is idle,
+// or nil. The caller must hold sg.mu, and must ready the returned
+// goroutine after releasing it.
+func (sg *synctestGroup) maybeWakeLocked() *g {
+	if sg.running > 0 || sg.active > 0 {
+		return nil
+	}
+	// Mark the group active on behalf of the goroutine being woken,
+	// which decrements the count when it resumes. Until then, the
+	// group cannot become idle again and wake a second goroutine.
+	sg.active++
+	if gp := sg.waiter; gp != nil {
+		return gp
+	}
+	return sg.root
+}
+
+// addtimer adds t to the group's timers. Timers are kept sorted by
+// when, and timers with the same when fire in the order they were added.
+func (sg *synctestGroup) addtimer(t *timer) {
+	lock(&sg.mu)
+	sg.addtimerLocked(t)
+	unlock(&sg.mu)
+}
+
+func (sg *synctestGroup) addtimerLocked(t *timer) {
+	pt := &sg.timers
+	for *pt != nil && (*pt).when <= t.when {
+		pt = &(*pt).bubbleNext
+	}
+	t.bubbleNext = *pt
+	*pt = t
+	t.status = timerWaiting
+}
+
+// deltimer removes t from the group's timers.
+// It reports whether t was removed before it was run.
+func (sg *synctestGroup) deltimer(t *timer) bool {
+	lock(&sg.mu)
+	removed := sg.deltimerLocked(t)
+	unlock(&sg.mu)
+	return removed
+}
+
+func (sg *synctestGroup) deltimerLocked(t *timer) bool {
+	if t.status != timerWaiting {
+		return false
+	}
+	for pt := &sg.timers; *pt != nil; pt = &(*pt).bubbleNext {
+		if *pt == t {
+			*pt = t.bubbleNext
+			t.bubbleNext = nil
+			t.status = timerNoStatus
+			return true
+		}
+	}
+	return false
+}
+
+// modtimer modifies t, adding it to the group's timers if necessary.
+// It reports whether t was modified before it was run.
+func (sg *synctestGroup) modtimer(t *timer, when, period int64, f func(interface{}, uintptr), arg interface{}, seq uintptr) bool {
+	lock(&sg.mu)
+	pending := sg.deltimerLocked(t)
+	t.when = when
+	t.period = period
+	t.f = f
+	t.arg = arg
+	t.seq = seq
+	sg.addtimerLocked(t)
+	unlock(&sg.mu)
+	return pending
+}
+
+// runTimers runs the group's timers that are due at the current fake
+// time. It is called by the root goroutine.
+func (sg *synctestGroup) runTimers() {
+	for {
+		lock(&sg.mu)
+		t := sg.timers
+		if t == nil || t.when > sg.now {
+			unlock(&sg.mu)
+			return
+		}
+		sg.timers = t.bubbleNext
+		t.bubbleNext = nil
+		t.status = timerNoStatus
+		if t.period > 0 {
+			// Leave in the list, but adjust next time to fire.
+			t.when += t.period * (1 + (sg.now-t.when)/t.period)
+			if t.when < 0 { // check for overflow.
+				t.when = maxWhen
+			}
+			sg.addtimerLocked(t)
+		}
+		f, arg, seq := t.f, t.arg, t.seq
+		unlock(&sg.mu)
+		if raceenabled {
+			raceacquire(unsafe.Pointer(t))
+		}
+		f(arg, seq)
+	}
+}
+
+//go:linkname synctestRun internal/synctest.Run
+func synctestRun(f func()) {
+	gp := getg()
+	if gp.syncGroup != nil {
+		panic("synctest.Run called from within a synctest bubble")
+	}
+	sg := &synctestGroup{
+		now:     synctestBaseTime,
+		root:    gp,
+		total:   1,
+		running: 1,
+	}
+	gp.syncGroup = sg
+	defer func() {
+		gp.syncGroup = nil
+		// Goroutines left blocked in the group must not try
+		// to wake this goroutine once Run has returned.
+		lock(&sg.mu)
+		sg.root = nil
+		unlock(&sg.mu)
+	}()
+
+	go f()
+
+	for {
+		sg.runTimers()
+
+		// Park until the group is idle. maybeWakeLocked marks the
+		// group active on our behalf when it wakes us.
+		gopark(nil, nil, waitReasonSynctestRun, traceEvGoBlock, 0)
+
+		if raceenabled {
+			// Make the effects of the now-blocked goroutines visible
+			// to the timers run by this goroutine, and to the
+			// goroutines those timers wake.
+			raceacquire(sg.raceaddr())
+			racereleasemerge(sg.raceaddr())
+		}
+
+		lock(&sg.mu)
+		sg.active--
+		if sg.active < 0 {
+			throw("synctest: active < 0")
+		}
+		if sg.total == 1 {
+			// Every goroutine other than this one has exited.
+			unlock(&sg.mu)
+			return
+		}
+		if sg.timers == nil {
+			// Every goroutine is durably blocked,
+			// and no timer will ever wake any of them.
+			unlock(&sg.mu)
+			panic("deadlock: all goroutines in bubble are blocked")
+		}
+		// Advance the clock to the next timer.
+		// The clock never moves backwards.
+		if next := sg.timers.when; next > sg.now {
+			atomic.Store64((*uint64)(unsafe.Pointer(&sg.now)), uint64(next))
+		}
+		unlock(&sg.mu)
+	}
+}
+
+//go:linkname synctestWait internal/synctest.Wait
+func synctestWait() {
+	gp := getg()
+	sg := gp.syncGroup
+	if sg == nil {
+		panic("goroutine is not in a bubble")
+	}
+	lock(&sg.mu)
+	// Use sg.waiting rather than sg.waiter to detect simultaneous calls
+	// to Wait, since sg.waiter is not set until we have parked.
+	if sg.waiting {
+		unlock(&sg.mu)
+		panic("wait already in progress")
+	}
+	sg.waiting = true
+	unlock(&sg.mu)
+
+	gopark(synctestwait_c, unsafe.Pointer(sg), waitReasonSynctestWait, traceEvGoBlock, 0)
+	if raceenabled {
+		raceacquire(sg.raceaddr())
+	}
+
+	lock(&sg.mu)
+	sg.active--
+	if sg.active < 0 {
+		throw("synctest: active < 0")
+	}
+	sg.waiter = nil
+	sg.waiting = false
+	unlock(&sg.mu)
+}
+
+func synctestwait_c(gp *g, sgp unsafe.Pointer) bool {
+	sg := (*synctestGroup)(sgp)
+	lock(&sg.mu)
+	sg.waiter = gp
+	unlock(&sg.mu)
+	return true
+}
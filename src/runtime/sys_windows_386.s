@@ -497,7 +497,7 @@ useQPC:
 	JMP	runtime·nanotimeQPC(SB)
 	RET
 
-TEXT time·now(SB),NOSPLIT,$0-20
+TEXT runtime·nowasm(SB),NOSPLIT,$0-20
 	CMPB	runtime·useQPCTime(SB), $0
 	JNE	useQPC
 loop:
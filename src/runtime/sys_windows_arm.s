@@ -523,7 +523,7 @@ useQPC:
 	B	runtime·nanotimeQPC(SB)		// tail call
 	RET
 
-TEXT time·now(SB),NOSPLIT,$0-20
+TEXT runtime·nowasm(SB),NOSPLIT,$0-20
 	MOVW    $0, R0
 	MOVB    runtime·useQPCTime(SB), R0
 	CMP	$0, R0
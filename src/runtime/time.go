@@ -36,6 +36,12 @@ type timer struct {
 
 	// The status field holds one of the values below.
 	status uint32
+
+	// If the timer was started within a synctest bubble, the bubble
+	// whose fake clock it uses, and the next timer in the bubble's list.
+	// Such timers are never placed in a P's heap.
+	bubble     *synctestGroup
+	bubbleNext *timer
 }
 
 // Code outside this file has to be careful in using a timer value.
@@ -179,6 +185,20 @@ func timeSleep(ns int64) {
 	}
 
 	gp := getg()
+	if sg := gp.syncGroup; sg != nil {
+		// Sleep on the bubble's fake clock. Use a fresh timer rather
+		// than gp.timer, which must stay usable outside the bubble.
+		t := &timer{f: goroutineReady, arg: gp, bubble: sg}
+		t.nextwhen = sg.nanotime() + ns
+		if t.nextwhen < 0 { // check for overflow.
+			t.nextwhen = maxWhen
+		}
+		gopark(resetForSleep, unsafe.Pointer(t), waitReasonSleep, traceEvGoSleep, 1)
+		if raceenabled {
+			raceacquire(sg.raceaddr())
+		}
+		return
+	}
 	t := gp.timer
 	if t == nil {
 		t = new(timer)
@@ -199,6 +219,10 @@ func timeSleep(ns int64) {
 // timer function, goroutineReady, before the goroutine has been parked.
 func resetForSleep(gp *g, ut unsafe.Pointer) bool {
 	t := (*timer)(ut)
+	if t.bubble != nil {
+		t.bubble.modtimer(t, t.nextwhen, t.period, t.f, t.arg, t.seq)
+		return true
+	}
 	resettimer(t, t.nextwhen)
 	return true
 }
@@ -209,6 +233,12 @@ func startTimer(t *timer) {
 	if raceenabled {
 		racerelease(unsafe.Pointer(t))
 	}
+	// A timer started within a synctest bubble uses the bubble's fake clock.
+	if sg := getg().syncGroup; sg != nil {
+		t.bubble = sg
+		sg.addtimer(t)
+		return
+	}
 	addtimer(t)
 }
 
@@ -216,6 +246,9 @@ func startTimer(t *timer) {
 // It reports whether t was stopped before being run.
 //go:linkname stopTimer time.stopTimer
 func stopTimer(t *timer) bool {
+	if t.bubble != nil {
+		return t.bubble.deltimer(t)
+	}
 	return deltimer(t)
 }
 
@@ -226,15 +259,39 @@ func resetTimer(t *timer, when int64) bool {
 	if raceenabled {
 		racerelease(unsafe.Pointer(t))
 	}
+	checkTimerBubble(t)
+	if t.bubble != nil {
+		return t.bubble.modtimer(t, when, t.period, t.f, t.arg, t.seq)
+	}
 	return resettimer(t, when)
 }
 
 // modTimer modifies an existing timer.
 //go:linkname modTimer time.modTimer
 func modTimer(t *timer, when, period int64, f func(interface{}, uintptr), arg interface{}, seq uintptr) {
+	checkTimerBubble(t)
+	if t.bubble != nil {
+		t.bubble.modtimer(t, when, period, f, arg, seq)
+		return
+	}
 	modtimer(t, when, period, f, arg, seq)
 }
 
+// checkTimerBubble panics if t is being reset by a goroutine outside the
+// synctest bubble t was started in, or if t was started outside a bubble
+// and is being reset from within one. Such a timer would keep running on
+// the wrong clock.
+func checkTimerBubble(t *timer) {
+	sg := getg().syncGroup
+	if t.bubble == sg {
+		return
+	}
+	if t.bubble != nil {
+		panic("synctest: timer created in a bubble reset from outside it")
+	}
+	panic("synctest: timer created outside a bubble reset from within one")
+}
+
 // Go runtime.
 
 // Ready the goroutine arg.
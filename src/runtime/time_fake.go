@@ -7,11 +7,11 @@
 
 // Faketime isn't currently supported on Windows. This would require:
 //
-// 1. Shadowing time_now, which is implemented in assembly on Windows.
-//    Since that's exported directly to the time package from runtime
-//    assembly, this would involve moving it from sys_windows_*.s into
-//    its own assembly files build-tagged with !faketime and using the
-//    implementation of time_now from timestub.go in faketime mode.
+// 1. Shadowing nowasm, which time_now calls on Windows.
+//    Since that's implemented in runtime assembly, this would involve
+//    moving it from sys_windows_*.s into its own assembly files
+//    build-tagged with !faketime and using the implementation of
+//    time_now from timestub.go in faketime mode.
 //
 // 2. Modifying syscall.Write to call syscall.faketimeWrite,
 //    translating the Stdout and Stderr handles into FDs 1 and 2.
@@ -11,4 +11,12 @@ package runtime
 import _ "unsafe"
 
 //go:linkname time_now time.now
-func time_now() (sec int64, nsec int32, mono int64)
+func time_now() (sec int64, nsec int32, mono int64) {
+	if sg := getg().syncGroup; sg != nil {
+		return sg.timeNow()
+	}
+	return nowasm()
+}
+
+// nowasm is implemented in assembly.
+func nowasm() (sec int64, nsec int32, mono int64)
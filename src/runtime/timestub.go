@@ -13,6 +13,9 @@ import _ "unsafe" // for go:linkname
 
 //go:linkname time_now time.now
 func time_now() (sec int64, nsec int32, mono int64) {
+	if sg := getg().syncGroup; sg != nil {
+		return sg.timeNow()
+	}
 	sec, nsec = walltime()
 	return sec, nsec, nanotime()
 }
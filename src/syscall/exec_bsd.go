@@ -261,3 +261,7 @@ childerror:
 		RawSyscall(SYS_EXIT, 253, 0, 0)
 	}
 }
+
+// forkAndExecFailureCleanup cleans up after an exec failure reported
+// by the child process. There is nothing to clean up on this system.
+func forkAndExecFailureCleanup(attr *ProcAttr, sys *SysProcAttr) {}
@@ -285,3 +285,7 @@ childerror:
 		exit(253)
 	}
 }
+
+// forkAndExecFailureCleanup cleans up after an exec failure reported
+// by the child process. There is nothing to clean up on this system.
+func forkAndExecFailureCleanup(attr *ProcAttr, sys *SysProcAttr) {}
@@ -259,3 +259,7 @@ childerror:
 		rawSyscall(funcPC(libc_exit_trampoline), 253, 0, 0)
 	}
 }
+
+// forkAndExecFailureCleanup cleans up after an exec failure reported
+// by the child process. There is nothing to clean up on this system.
+func forkAndExecFailureCleanup(attr *ProcAttr, sys *SysProcAttr) {}
@@ -55,6 +55,42 @@ type SysProcAttr struct {
 	// users this should be set to false for mappings work.
 	GidMappingsEnableSetgroups bool
 	AmbientCaps                []uintptr // Ambient capabilities (Linux only)
+	// PidFD, if not nil, asks for the child to be created with
+	// CLONE_PIDFD; *PidFD is set to -1 before the child is started
+	// and, on success, to a pidfd (a file descriptor referring to the
+	// child process) owned by the caller. Kernels before Linux 5.2
+	// ignore CLONE_PIDFD, so the child may start without a pidfd:
+	// callers must check for *PidFD == -1.
+	PidFD *int
+	// UseCgroupFD asks for the child to be created directly in the
+	// cgroup (v2) whose directory is open as file descriptor CgroupFD,
+	// so that it is never run outside of it. This uses clone3 with
+	// CLONE_INTO_CGROUP and requires Linux 5.7 or later; on older
+	// kernels the process fails to start with ENOTSUP.
+	UseCgroupFD bool
+	CgroupFD    int // Cgroup directory fd, if UseCgroupFD.
+}
+
+// Defined in linux/sched.h.
+const (
+	_CLONE_PIDFD       = 0x1000      // Linux 5.2
+	_CLONE_INTO_CGROUP = 0x200000000 // Linux 5.7
+)
+
+// cloneArgs holds the arguments of the clone3 system call.
+// See struct clone_args in linux/sched.h.
+type cloneArgs struct {
+	flags      uint64 // Flags bit mask
+	pidFD      uint64 // Where to store the pidfd (int *)
+	childTID   uint64 // Where to store child TID, in child's memory (pid_t *)
+	parentTID  uint64 // Where to store child TID, in parent's memory (pid_t *)
+	exitSignal uint64 // Signal to deliver to parent on child termination
+	stack      uint64 // Pointer to lowest byte of stack
+	stackSize  uint64 // Size of stack
+	tls        uint64 // Location of new TLS
+	setTID     uint64 // Pointer to a pid_t array (since Linux 5.5)
+	setTIDSize uint64 // Number of elements in set_tid (since Linux 5.5)
+	cgroup     uint64 // File descriptor for target cgroup of child (since Linux 5.7)
 }
 
 var (
@@ -78,18 +114,31 @@ func runtime_AfterForkInChild()
 // functions that do not grow the stack.
 //go:norace
 func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr *ProcAttr, sys *SysProcAttr, pipe int) (pid int, err Errno) {
+	if sys.PidFD != nil {
+		*sys.PidFD = -1
+	}
+
 	// Set up and fork. This returns immediately in the parent or
 	// if there's an error.
-	r1, err1, p, locked := forkAndExecInChild1(argv0, argv, envv, chroot, dir, attr, sys, pipe)
+	upid, pidfd, err1, p, locked := forkAndExecInChild1(argv0, argv, envv, chroot, dir, attr, sys, pipe)
 	if locked {
 		runtime_AfterFork()
 	}
 	if err1 != 0 {
+		if sys.UseCgroupFD && (err1 == ENOSYS || err1 == E2BIG) {
+			// clone3 is missing (before Linux 5.3) or
+			// does not know about the cgroup field
+			// (before Linux 5.7).
+			err1 = ENOTSUP
+		}
 		return 0, err1
 	}
 
 	// parent; return PID
-	pid = int(r1)
+	pid = int(upid)
+	if sys.PidFD != nil {
+		*sys.PidFD = int(pidfd)
+	}
 
 	if sys.UidMappings != nil || sys.GidMappings != nil {
 		Close(p[0])
@@ -141,7 +190,7 @@ func capToMask(cap uintptr) uint32 { return 1 << uint(cap&31) }
 //
 //go:noinline
 //go:norace
-func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr *ProcAttr, sys *SysProcAttr, pipe int) (r1 uintptr, err1 Errno, p [2]int, locked bool) {
+func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr *ProcAttr, sys *SysProcAttr, pipe int) (pid uintptr, pidfd int32, err1 Errno, p [2]int, locked bool) {
 	// Defined in linux/prctl.h starting with Linux 4.3.
 	const (
 		PR_CAP_AMBIENT       = 0x2f
@@ -157,6 +206,9 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 	// declarations require heap allocation (e.g., err1).
 	var (
 		err2                      Errno
+		r1                        uintptr
+		flags                     uintptr
+		clone3                    *cloneArgs
 		nextfd                    int
 		i                         int
 		caps                      caps
@@ -207,25 +259,60 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
-	var hasRawVforkSyscall bool
+	flags = sys.Cloneflags
+	pidfd = -1
+	if sys.PidFD != nil {
+		flags |= _CLONE_PIDFD
+	}
+
+	var useVfork bool
 	switch runtime.GOARCH {
 	case "amd64", "arm64", "ppc64", "riscv64", "s390x":
-		hasRawVforkSyscall = true
+		useVfork = sys.Cloneflags&CLONE_NEWUSER == 0 && sys.Unshareflags&CLONE_NEWUSER == 0
+	}
+
+	if sys.UseCgroupFD {
+		clone3 = &cloneArgs{
+			flags:      uint64(flags) | _CLONE_INTO_CGROUP,
+			exitSignal: uint64(SIGCHLD),
+			cgroup:     uint64(sys.CgroupFD),
+		}
+		if useVfork {
+			clone3.flags |= CLONE_VFORK | CLONE_VM
+		}
 	}
 
 	// About to call fork.
 	// No more allocation or calls of non-assembly functions.
 	runtime_BeforeFork()
 	locked = true
+	// The third argument of clone is where the kernel stores the
+	// pidfd for CLONE_PIDFD. On Linux/s390, the first two arguments
+	// of clone are swapped. For clone3 the pidfd location is part of
+	// the arguments; it is filled in only now because our stack can
+	// not move anymore.
 	switch {
-	case hasRawVforkSyscall && (sys.Cloneflags&CLONE_NEWUSER == 0 && sys.Unshareflags&CLONE_NEWUSER == 0):
-		r1, err1 = rawVforkSyscall(SYS_CLONE, uintptr(SIGCHLD|CLONE_VFORK|CLONE_VM)|sys.Cloneflags)
+	case clone3 != nil:
+		if flags&_CLONE_PIDFD != 0 {
+			clone3.pidFD = uint64(uintptr(unsafe.Pointer(&pidfd)))
+		}
+		if useVfork {
+			pid, err1 = rawVforkSyscall(_SYS_clone3, uintptr(unsafe.Pointer(clone3)), unsafe.Sizeof(*clone3), 0)
+		} else {
+			pid, _, err1 = RawSyscall(_SYS_clone3, uintptr(unsafe.Pointer(clone3)), unsafe.Sizeof(*clone3), 0)
+		}
+	case useVfork:
+		if runtime.GOARCH == "s390x" {
+			pid, err1 = rawVforkSyscall(SYS_CLONE, 0, uintptr(SIGCHLD|CLONE_VFORK|CLONE_VM)|flags, uintptr(unsafe.Pointer(&pidfd)))
+		} else {
+			pid, err1 = rawVforkSyscall(SYS_CLONE, uintptr(SIGCHLD|CLONE_VFORK|CLONE_VM)|flags, 0, uintptr(unsafe.Pointer(&pidfd)))
+		}
 	case runtime.GOARCH == "s390x":
-		r1, _, err1 = RawSyscall6(SYS_CLONE, 0, uintptr(SIGCHLD)|sys.Cloneflags, 0, 0, 0, 0)
+		pid, _, err1 = RawSyscall6(SYS_CLONE, 0, uintptr(SIGCHLD)|flags, uintptr(unsafe.Pointer(&pidfd)), 0, 0, 0)
 	default:
-		r1, _, err1 = RawSyscall6(SYS_CLONE, uintptr(SIGCHLD)|sys.Cloneflags, 0, 0, 0, 0, 0)
+		pid, _, err1 = RawSyscall6(SYS_CLONE, uintptr(SIGCHLD)|flags, 0, uintptr(unsafe.Pointer(&pidfd)), 0, 0, 0)
 	}
-	if err1 != 0 || r1 != 0 {
+	if err1 != 0 || pid != 0 {
 		// If we're in the parent, we must return immediately
 		// so we're not in the same stack frame as the child.
 		// This can at most use the return PC, which the child
@@ -553,6 +640,15 @@ childerror:
 	}
 }
 
+// forkAndExecFailureCleanup cleans up after an exec failure reported
+// by the child process.
+func forkAndExecFailureCleanup(attr *ProcAttr, sys *SysProcAttr) {
+	if sys.PidFD != nil && *sys.PidFD != -1 {
+		Close(*sys.PidFD)
+		*sys.PidFD = -1
+	}
+}
+
 // Try to open a pipe with O_CLOEXEC set on both file descriptors.
 func forkExecPipe(p []int) (err error) {
 	err = Pipe2(p, O_CLOEXEC)
@@ -7,6 +7,7 @@
 package syscall_test
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"internal/testenv"
@@ -656,3 +657,118 @@ func testAmbientCaps(t *testing.T, userns bool) {
 		t.Fatal(err.Error())
 	}
 }
+
+// prepareCgroupFD creates a new cgroup below the one of the current
+// process and returns a file descriptor for its directory and its name
+// relative to the current cgroup. It skips the test if cgroup v2 is
+// not available or can't be modified.
+func prepareCgroupFD(t *testing.T) (int, string) {
+	selfCg, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		t.Skipf("reading /proc/self/cgroup: %v", err)
+	}
+	// Look for the cgroup v2 line, which has hierarchy ID 0
+	// and no controllers, for example
+	//   0::/user.slice/user-1000.slice/session-1.scope
+	var cg string
+	for _, line := range strings.Split(string(selfCg), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			cg = strings.TrimPrefix(line, "0::")
+			break
+		}
+	}
+	if cg == "" {
+		t.Skip("cgroup v2 not available")
+	}
+	// The cgroup v2 hierarchy is mounted on /sys/fs/cgroup,
+	// or on /sys/fs/cgroup/unified in hybrid setups.
+	prefix := "/sys/fs/cgroup"
+	if _, err := os.Stat(prefix + "/cgroup.controllers"); err != nil {
+		prefix += "/unified"
+	}
+	subCgroup, err := os.MkdirTemp(prefix+cg, "subcg-")
+	if err != nil {
+		t.Skipf("can't create cgroup: %v", err)
+	}
+	t.Cleanup(func() { syscall.Rmdir(subCgroup) })
+	cgroupFD, err := syscall.Open(subCgroup, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		t.Fatal(&os.PathError{Op: "open", Path: subCgroup, Err: err})
+	}
+	t.Cleanup(func() { syscall.Close(cgroupFD) })
+	return cgroupFD, filepath.Base(subCgroup)
+}
+
+// TestUseCgroupFDHelper isn't a real test. It's used as a helper process
+// for TestUseCgroupFD.
+func TestUseCgroupFDHelper(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+	// Write the cgroup v2 path of the current process to stdout.
+	selfCg, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, line := range strings.Split(string(selfCg), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			fmt.Print(strings.TrimPrefix(line, "0::"))
+		}
+	}
+}
+
+func TestUseCgroupFD(t *testing.T) {
+	fd, suffix := prepareCgroupFD(t)
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestUseCgroupFDHelper")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		UseCgroupFD: true,
+		CgroupFD:    fd,
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			t.Skipf("skipping: %v", err)
+		}
+		t.Fatal(err)
+	}
+	// NB: this wouldn't work with cgroupns.
+	if !strings.HasSuffix(string(out), "/"+suffix) {
+		t.Fatalf("got: %q, want: a line that ends with %q", out, "/"+suffix)
+	}
+}
+
+func TestPidFDPreset(t *testing.T) {
+	// *PidFD must be overwritten whether or not the kernel honors
+	// CLONE_PIDFD, so that callers can tell that they have no pidfd.
+	const stale = 1 << 20
+	fd := stale
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.SysProcAttr = &syscall.SysProcAttr{PidFD: &fd}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if fd == stale {
+		t.Fatalf("SysProcAttr.PidFD was not set")
+	}
+	if fd == -1 {
+		t.Logf("kernel ignored CLONE_PIDFD")
+	} else if err := syscall.Close(fd); err != nil {
+		t.Errorf("Close(pidfd): %v", err)
+	}
+
+	// A child that fails to start leaves *PidFD at -1.
+	fd = stale
+	cmd = exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Dir = filepath.Join(t.TempDir(), "nonexistent")
+	cmd.SysProcAttr = &syscall.SysProcAttr{PidFD: &fd}
+	if err := cmd.Run(); err == nil {
+		t.Fatal("Run succeeded in nonexistent directory")
+	}
+	if fd != -1 {
+		t.Errorf("SysProcAttr.PidFD = %d after failed start, want -1", fd)
+	}
+}
@@ -5,6 +5,7 @@
 package fstest
 
 import (
+	"errors"
 	"io"
 	"io/fs"
 	"path"
@@ -30,6 +31,10 @@ import (
 // Another implication is that opening or reading a directory requires
 // iterating over the entire map, so a MapFS should typically be used with not more
 // than a few hundred entries or directory reads.
+//
+// MapFS also implements the write interfaces defined by package fs
+// (fs.CreateFS, fs.WriteFileFS, fs.MkdirFS, fs.RemoveFS, fs.RenameFS,
+// fs.ChmodFS and fs.SymlinkFS), which operate by editing the map.
 type MapFS map[string]*MapFile
 
 // A MapFile describes a single file in a MapFS.
@@ -42,6 +47,7 @@ type MapFile struct {
 
 var _ fs.FS = MapFS(nil)
 var _ fs.File = (*openMapFile)(nil)
+var _ fs.WriterFile = (*openMapWriter)(nil)
 
 // Open opens the named file.
 func (fsys MapFS) Open(name string) (fs.File, error) {
@@ -142,6 +148,186 @@ func (fsys MapFS) Sub(dir string) (fs.FS, error) {
 	return fs.Sub(noSub{fsys}, dir)
 }
 
+// isDir reports whether name is a directory in fsys,
+// either listed explicitly or synthesized for the files it contains.
+func (fsys MapFS) isDir(name string) bool {
+	if name == "." {
+		return true
+	}
+	if file := fsys[name]; file != nil {
+		return file.Mode&fs.ModeDir != 0
+	}
+	prefix := name + "/"
+	for fname := range fsys {
+		if strings.HasPrefix(fname, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// exists reports whether name is a file or directory in fsys.
+func (fsys MapFS) exists(name string) bool {
+	return fsys[name] != nil || fsys.isDir(name)
+}
+
+// keepParent adds an explicit entry for the parent directory of name
+// if it is only synthesized, so that removing name from the map
+// does not also remove its parent.
+func (fsys MapFS) keepParent(name string) {
+	if dir := path.Dir(name); dir != "." && fsys[dir] == nil {
+		fsys[dir] = &MapFile{Mode: fs.ModeDir}
+	}
+}
+
+// checkNew checks that name can be added to fsys as a new entry.
+func (fsys MapFS) checkNew(op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if fsys.exists(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrExist}
+	}
+	if !fsys.isDir(path.Dir(name)) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// Create creates or truncates the named file.
+func (fsys MapFS) Create(name string, perm fs.FileMode) (fs.WriterFile, error) {
+	if !fs.ValidPath(name) || fsys.isDir(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	file := fsys[name]
+	if file == nil {
+		if err := fsys.checkNew("open", name); err != nil {
+			return nil, err
+		}
+		file = &MapFile{Mode: perm & fs.ModePerm}
+		fsys[name] = file
+	}
+	file.Data = nil
+	file.ModTime = time.Now()
+	return &openMapWriter{openMapFile{name, mapFileInfo{path.Base(name), file}, 0}}, nil
+}
+
+func (fsys MapFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	file, err := fsys.Create(name, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
+
+// Mkdir adds an explicit entry for the named directory.
+func (fsys MapFS) Mkdir(name string, perm fs.FileMode) error {
+	if err := fsys.checkNew("mkdir", name); err != nil {
+		return err
+	}
+	fsys[name] = &MapFile{Mode: fs.ModeDir | perm&fs.ModePerm, ModTime: time.Now()}
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (fsys MapFS) Remove(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	if !fsys.exists(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if fsys.isDir(name) {
+		prefix := name + "/"
+		for fname := range fsys {
+			if strings.HasPrefix(fname, prefix) {
+				return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+			}
+		}
+	}
+	fsys.keepParent(name)
+	delete(fsys, name)
+	return nil
+}
+
+// Rename moves the named file or directory, along with any files
+// the directory contains, to newname.
+func (fsys MapFS) Rename(oldname, newname string) error {
+	if !fs.ValidPath(oldname) || !fs.ValidPath(newname) || oldname == "." || newname == "." ||
+		strings.HasPrefix(newname, oldname+"/") {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrInvalid}
+	}
+	if !fsys.exists(oldname) {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrNotExist}
+	}
+	if oldname == newname {
+		return nil
+	}
+	if fsys.exists(newname) && (fsys.isDir(oldname) || fsys.isDir(newname)) {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrExist}
+	}
+	if !fsys.isDir(path.Dir(newname)) {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrNotExist}
+	}
+	fsys.keepParent(oldname)
+	prefix := oldname + "/"
+	var names []string
+	for fname := range fsys {
+		if fname == oldname || strings.HasPrefix(fname, prefix) {
+			names = append(names, fname)
+		}
+	}
+	if fsys[oldname] == nil {
+		// Keep a synthesized directory in existence at its new name.
+		fsys[oldname] = &MapFile{Mode: fs.ModeDir}
+		names = append(names, oldname)
+	}
+	for _, fname := range names {
+		file := fsys[fname]
+		delete(fsys, fname)
+		fsys[newname+fname[len(oldname):]] = file
+	}
+	return nil
+}
+
+// chmodMask is the set of mode bits that Chmod may change.
+const chmodMask = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// Chmod changes the mode of the named file or directory.
+// MapFS does not follow symbolic links, so if the file is a
+// symbolic link, Chmod changes the mode of the link itself.
+func (fsys MapFS) Chmod(name string, mode fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrInvalid}
+	}
+	file := fsys[name]
+	if file == nil {
+		if !fsys.isDir(name) {
+			return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+		}
+		file = &MapFile{Mode: fs.ModeDir}
+		fsys[name] = file
+	}
+	file.Mode = file.Mode&^chmodMask | mode&chmodMask
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+// The link is stored as a file with mode fs.ModeSymlink whose data
+// is the link target; MapFS does not follow symbolic links when
+// opening files.
+func (fsys MapFS) Symlink(oldname, newname string) error {
+	if err := fsys.checkNew("symlink", newname); err != nil {
+		return err
+	}
+	fsys[newname] = &MapFile{Data: []byte(oldname), Mode: fs.ModeSymlink | fs.ModePerm, ModTime: time.Now()}
+	return nil
+}
+
 // A mapFileInfo implements fs.FileInfo and fs.DirEntry for a given map file.
 type mapFileInfo struct {
 	name string
@@ -207,6 +393,32 @@ func (f *openMapFile) ReadAt(b []byte, offset int64) (int, error) {
 	return n, nil
 }
 
+// An openMapWriter is a regular file open for reading and writing,
+// as returned by MapFS.Create.
+type openMapWriter struct {
+	openMapFile
+}
+
+func (f *openMapWriter) Write(b []byte) (int, error) {
+	if f.offset < 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.path, Err: fs.ErrInvalid}
+	}
+	data := f.f.Data
+	end := f.offset + int64(len(b))
+	if end > int64(len(data)) {
+		if end > int64(cap(data)) {
+			d := make([]byte, len(data), 2*end)
+			copy(d, data)
+			data = d
+		}
+		data = data[:end]
+	}
+	copy(data[f.offset:], b)
+	f.f.Data = data
+	f.offset = end
+	return len(b), nil
+}
+
 // A mapDir is a directory fs.File (so also an fs.ReadDirFile) open for reading.
 type mapDir struct {
 	path string
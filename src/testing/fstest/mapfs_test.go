@@ -17,3 +17,24 @@ func TestMapFS(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestMapFSWrite(t *testing.T) {
+	m := MapFS{
+		"hello": {Data: []byte("hello, world\n")},
+	}
+	if err := TestWriteFS(m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 || m["hello"] == nil {
+		t.Fatalf("TestWriteFS left map with %d entries, want only hello", len(m))
+	}
+
+	// Removing the last file in a synthesized directory keeps the directory.
+	m = MapFS{"dir/file": {}}
+	if err := m.Remove("dir/file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TestFS(m, "dir"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fstest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// TestWriteFS tests the write operations of a file system implementation.
+// It creates a scratch directory named "fstest.tmp" in fsys, exercises
+// each of the write interfaces defined by package fs that fsys implements
+// (fs.CreateFS, fs.WriteFileFS, fs.MkdirFS, fs.RemoveFS, fs.RenameFS,
+// fs.ChmodFS and fs.SymlinkFS) within it, checks the resulting tree
+// as TestFS does, and removes the directory again.
+// The file system must implement at least fs.MkdirFS and fs.RemoveFS,
+// and the scratch directory must not already exist.
+// No other changes may be made to fsys concurrently with TestWriteFS.
+//
+// If TestWriteFS finds any misbehaviors, it returns an error reporting all of them.
+// The error text spans multiple lines, one per detected misbehavior.
+func TestWriteFS(fsys fs.FS) error {
+	mfs, ok := fsys.(fs.MkdirFS)
+	if !ok {
+		return fmt.Errorf("TestWriteFS: %T does not implement fs.MkdirFS", fsys)
+	}
+	rfs, ok := fsys.(fs.RemoveFS)
+	if !ok {
+		return fmt.Errorf("TestWriteFS: %T does not implement fs.RemoveFS", fsys)
+	}
+	const dir = "fstest.tmp"
+	if _, err := fs.Stat(fsys, dir); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("TestWriteFS: scratch directory %s: Stat = %v, want not exist error", dir, err)
+	}
+	if err := mfs.Mkdir(dir, 0777); err != nil {
+		return fmt.Errorf("TestWriteFS: %v", err)
+	}
+	t := writeTester{fsTester: fsTester{fsys: fsys}, dir: dir}
+	t.checkMkdir(mfs)
+	t.checkWrite()
+	t.checkChmod()
+	t.checkRename()
+
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		t.errorf("fs.Sub(fsys, %s): %v", dir, err)
+	} else if err := testFS(sub, t.expected...); err != nil {
+		t.errorf("testing fs.Sub(fsys, %s): %v", dir, err)
+	}
+
+	t.checkSymlink()
+	t.checkRemove(rfs)
+	if len(t.errText) == 0 {
+		return nil
+	}
+	return errors.New("TestWriteFS found errors:\n" + string(t.errText))
+}
+
+// A writeTester holds state for running TestWriteFS.
+type writeTester struct {
+	fsTester
+	dir      string
+	expected []string // files in dir expected by the final TestFS check
+	wrote    bool     // dir/file was written
+}
+
+// checkContent checks that the named file contains data.
+func (t *writeTester) checkContent(name string, data []byte) {
+	got, err := fs.ReadFile(t.fsys, name)
+	if err != nil {
+		t.errorf("%s: ReadFile: %v", name, err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		t.errorf("%s: ReadFile = %q, want %q", name, got, data)
+	}
+	info, err := fs.Stat(t.fsys, name)
+	if err != nil {
+		t.errorf("%s: Stat: %v", name, err)
+		return
+	}
+	if !info.Mode().IsRegular() || info.Size() != int64(len(data)) {
+		t.errorf("%s: Stat: mode %v size %d, want regular file of size %d", name, info.Mode(), info.Size(), len(data))
+	}
+}
+
+func (t *writeTester) checkMkdir(mfs fs.MkdirFS) {
+	name := t.dir + "/sub"
+	if err := mfs.Mkdir(name, 0777); err != nil {
+		t.errorf("%s: Mkdir: %v", name, err)
+		return
+	}
+	if info, err := fs.Stat(t.fsys, name); err != nil || !info.IsDir() {
+		t.errorf("%s: Stat after Mkdir = %v, %v, want directory", name, info, err)
+	}
+	if err := mfs.Mkdir(name, 0777); !errors.Is(err, fs.ErrExist) {
+		t.errorf("%s: second Mkdir = %v, want exist error", name, err)
+	}
+	if err := mfs.Mkdir(t.dir+"/missing/sub", 0777); err == nil {
+		t.errorf("%s/missing/sub: Mkdir with missing parent succeeded", t.dir)
+	}
+	if err := fs.MkdirAll(t.fsys, t.dir+"/sub/a/b", 0777); err != nil {
+		t.errorf("%s/sub/a/b: MkdirAll: %v", t.dir, err)
+	}
+	if info, err := fs.Stat(t.fsys, t.dir+"/sub/a/b"); err != nil || !info.IsDir() {
+		t.errorf("%s/sub/a/b: Stat after MkdirAll = %v, %v, want directory", t.dir, info, err)
+	}
+	t.expected = append(t.expected, "sub/a/b")
+}
+
+func (t *writeTester) checkWrite() {
+	cfs, canCreate := t.fsys.(fs.CreateFS)
+	_, canWrite := t.fsys.(fs.WriteFileFS)
+	if !canCreate && !canWrite {
+		return
+	}
+	name := t.dir + "/file"
+	if canCreate {
+		f, err := cfs.Create(name, 0666)
+		if err != nil {
+			t.errorf("%s: Create: %v", name, err)
+			return
+		}
+		data := []byte("hello, world\n")
+		if n, err := f.Write(data); n != len(data) || err != nil {
+			t.errorf("%s: Write = %d, %v, want %d, nil", name, n, err, len(data))
+		}
+		if err := f.Close(); err != nil {
+			t.errorf("%s: Close: %v", name, err)
+		}
+		t.checkContent(name, data)
+	}
+	// Overwriting a file must truncate it.
+	data := []byte("goodbye\n")
+	if err := fs.WriteFile(t.fsys, name, data, 0666); err != nil {
+		t.errorf("%s: WriteFile: %v", name, err)
+		return
+	}
+	t.checkContent(name, data)
+	if err := fs.WriteFile(t.fsys, t.dir+"/sub/a/file", nil, 0666); err != nil {
+		t.errorf("%s/sub/a/file: WriteFile: %v", t.dir, err)
+	}
+	t.checkContent(t.dir+"/sub/a/file", nil)
+	if err := fs.WriteFile(t.fsys, t.dir+"/missing/file", nil, 0666); err == nil {
+		t.errorf("%s/missing/file: WriteFile with missing parent succeeded", t.dir)
+	}
+	t.expected = append(t.expected, "file", "sub/a/file")
+	t.wrote = true
+}
+
+func (t *writeTester) checkChmod() {
+	cfs, ok := t.fsys.(fs.ChmodFS)
+	if !ok || !t.wrote {
+		return
+	}
+	name := t.dir + "/file"
+	// Only check the owner write bit, which all file systems can represent.
+	for _, mode := range []fs.FileMode{0444, 0666} {
+		if err := cfs.Chmod(name, mode); err != nil {
+			t.errorf("%s: Chmod(%v): %v", name, mode, err)
+			return
+		}
+		info, err := fs.Stat(t.fsys, name)
+		if err != nil {
+			t.errorf("%s: Stat after Chmod(%v): %v", name, mode, err)
+			return
+		}
+		if info.Mode()&0200 != mode&0200 {
+			t.errorf("%s: Stat after Chmod(%v): mode %v", name, mode, info.Mode())
+		}
+	}
+	if err := cfs.Chmod(t.dir+"/missing", 0666); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s/missing: Chmod = %v, want not exist error", t.dir, err)
+	}
+}
+
+func (t *writeTester) checkRename() {
+	rfs, ok := t.fsys.(fs.RenameFS)
+	if !ok {
+		return
+	}
+	if t.wrote {
+		oldname, newname := t.dir+"/file", t.dir+"/sub/renamed"
+		data, err := fs.ReadFile(t.fsys, oldname)
+		if err != nil {
+			t.errorf("%s: ReadFile: %v", oldname, err)
+			return
+		}
+		if err := rfs.Rename(oldname, newname); err != nil {
+			t.errorf("%s: Rename to %s: %v", oldname, newname, err)
+			return
+		}
+		if _, err := fs.Stat(t.fsys, oldname); !errors.Is(err, fs.ErrNotExist) {
+			t.errorf("%s: Stat after Rename = %v, want not exist error", oldname, err)
+		}
+		t.checkContent(newname, data)
+		t.renameExpected("file", "sub/renamed")
+	}
+	oldname, newname := t.dir+"/sub/a", t.dir+"/sub/moved"
+	if err := rfs.Rename(oldname, newname); err != nil {
+		t.errorf("%s: Rename to %s: %v", oldname, newname, err)
+		return
+	}
+	if info, err := fs.Stat(t.fsys, newname+"/b"); err != nil || !info.IsDir() {
+		t.errorf("%s/b: Stat after Rename = %v, %v, want directory", newname, info, err)
+	}
+	t.renameExpected("sub/a", "sub/moved")
+	if err := rfs.Rename(t.dir+"/missing", t.dir+"/other"); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s/missing: Rename = %v, want not exist error", t.dir, err)
+	}
+}
+
+// renameExpected updates the expected files after
+// oldname in the scratch directory is renamed to newname.
+func (t *writeTester) renameExpected(oldname, newname string) {
+	for i, name := range t.expected {
+		if name == oldname {
+			t.expected[i] = newname
+		} else if strings.HasPrefix(name, oldname+"/") {
+			t.expected[i] = newname + name[len(oldname):]
+		}
+	}
+}
+
+func (t *writeTester) checkSymlink() {
+	sfs, ok := t.fsys.(fs.SymlinkFS)
+	if !ok {
+		return
+	}
+	name := t.dir + "/link"
+	if err := sfs.Symlink("sub", name); err != nil {
+		t.errorf("%s: Symlink: %v", name, err)
+		return
+	}
+	list, err := fs.ReadDir(t.fsys, t.dir)
+	if err != nil {
+		t.errorf("%s: ReadDir: %v", t.dir, err)
+		return
+	}
+	found := false
+	for _, d := range list {
+		if d.Name() == "link" {
+			found = true
+			if d.Type() != fs.ModeSymlink {
+				t.errorf("%s: ReadDir entry has type %v, want %v", name, d.Type(), fs.ModeSymlink)
+			}
+		}
+	}
+	if !found {
+		t.errorf("%s: Symlink: link not found in ReadDir", name)
+	}
+	if err := sfs.Symlink("sub", name); !errors.Is(err, fs.ErrExist) {
+		t.errorf("%s: second Symlink = %v, want exist error", name, err)
+	}
+}
+
+func (t *writeTester) checkRemove(rfs fs.RemoveFS) {
+	if err := rfs.Remove(t.dir + "/sub"); err == nil {
+		t.errorf("%s/sub: Remove of non-empty directory succeeded", t.dir)
+	}
+	if err := rfs.Remove(t.dir + "/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s/missing: Remove = %v, want not exist error", t.dir, err)
+	}
+	if err := fs.RemoveAll(t.fsys, t.dir); err != nil {
+		t.errorf("%s: RemoveAll: %v", t.dir, err)
+	}
+	if _, err := fs.Stat(t.fsys, t.dir); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s: Stat after RemoveAll = %v, want not exist error", t.dir, err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package synctest provides support for testing concurrent code.
+//
+// A test using this package runs the code under test in an isolated
+// "bubble" of goroutines with a fake clock. Time in the bubble advances
+// only when every goroutine in the bubble is durably blocked, so tests
+// of timeouts, tickers and retry loops run instantly and deterministically.
+package synctest
+
+import (
+	"internal/synctest"
+)
+
+// Run executes f in a new goroutine.
+//
+// The new goroutine and any goroutines transitively started by it form
+// an isolated "bubble".
+// Run waits for all goroutines in the bubble to exit before returning.
+//
+// Goroutines in the bubble use a synthetic time implementation.
+// The initial time is midnight UTC 2000-01-01.
+//
+// Time advances when every goroutine in the bubble is blocked.
+// For example, a call to time.Sleep will block until all other
+// goroutines are blocked and return after the bubble's clock has
+// advanced. See Wait for the specific definition of blocked.
+//
+// If every goroutine is blocked and there are no timers scheduled,
+// Run panics.
+//
+// Timers and tickers created within the bubble, including those
+// created by time.After, time.AfterFunc and context.WithTimeout, use
+// the bubble's clock, as do time.Now and time.Since.
+// Calling Reset on a timer or ticker created within a bubble from
+// outside it, or on one created outside any bubble from within one,
+// panics. Stop may be called from anywhere.
+//
+// Run may not be called from within a bubble.
+func Run(f func()) {
+	synctest.Run(f)
+}
+
+// Wait blocks until every goroutine within the current bubble,
+// other than the current goroutine, is durably blocked.
+// It panics if called from a non-bubbled goroutine,
+// or if two goroutines in the same bubble call Wait at the same time.
+//
+// A goroutine is durably blocked if it can only be unblocked by another
+// goroutine in its bubble, or by the bubble's clock. The following
+// operations durably block a goroutine:
+//   - a send or receive on a channel, including a nil channel
+//   - a select statement where every case is a channel operation
+//   - sync.Cond.Wait
+//   - time.Sleep
+//
+// A goroutine executing a system call or waiting for an external event
+// such as a network operation is not durably blocked. Neither is a
+// goroutine blocked acquiring a sync.Mutex or sync.RWMutex, since such
+// blocking is expected to be brief.
+//
+// A goroutine in a bubble blocked on a channel operation may be
+// unblocked by a goroutine outside the bubble. Such operations are
+// not detected, and should be avoided.
+func Wait() {
+	synctest.Wait()
+}
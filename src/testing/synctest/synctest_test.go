@@ -0,0 +1,323 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package synctest_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestNow(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).In(time.Local)
+	synctest.Run(func() {
+		// Time starts at 2000-1-1 00:00:00.
+		if got, want := time.Now(), start; !got.Equal(want) {
+			t.Errorf("at start: time.Now = %v, want %v", got, want)
+		}
+		go func() {
+			// New goroutines see the same fake clock.
+			if got, want := time.Now(), start; !got.Equal(want) {
+				t.Errorf("time.Now = %v, want %v", got, want)
+			}
+		}()
+		// Time advances after a sleep.
+		time.Sleep(1 * time.Second)
+		if got, want := time.Now(), start.Add(1*time.Second); !got.Equal(want) {
+			t.Errorf("after sleep: time.Now = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRunEmpty(t *testing.T) {
+	synctest.Run(func() {
+	})
+}
+
+func TestSimpleWait(t *testing.T) {
+	synctest.Run(func() {
+		synctest.Wait()
+	})
+}
+
+func TestGoroutineWait(t *testing.T) {
+	synctest.Run(func() {
+		go func() {}()
+		synctest.Wait()
+	})
+}
+
+// TestWait starts a collection of goroutines.
+// It checks that synctest.Wait waits for all goroutines to exit before returning.
+func TestWait(t *testing.T) {
+	synctest.Run(func() {
+		done := false
+		ch := make(chan int)
+		var f func()
+		f = func() {
+			count := <-ch
+			if count == 0 {
+				done = true
+			} else {
+				go f()
+				ch <- count - 1
+			}
+		}
+		go f()
+		ch <- 100
+		synctest.Wait()
+		if !done {
+			t.Fatalf("done = false, want true")
+		}
+	})
+}
+
+func TestMallocs(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		synctest.Run(func() {
+			done := false
+			ch := make(chan []byte)
+			var f func()
+			f = func() {
+				b := <-ch
+				if len(b) == 0 {
+					done = true
+				} else {
+					go f()
+					ch <- make([]byte, len(b)-1)
+				}
+			}
+			go f()
+			ch <- make([]byte, 100)
+			synctest.Wait()
+			if !done {
+				t.Fatalf("done = false, want true")
+			}
+		})
+	}
+}
+
+func TestTimerReadBeforeDeadline(t *testing.T) {
+	synctest.Run(func() {
+		start := time.Now()
+		tm := time.NewTimer(5 * time.Second)
+		<-tm.C
+		if got, want := time.Since(start), 5*time.Second; got != want {
+			t.Errorf("after sleep: time.Since(start) = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestTimerStop(t *testing.T) {
+	synctest.Run(func() {
+		tm := time.NewTimer(5 * time.Second)
+		if !tm.Stop() {
+			t.Errorf("tm.Stop() = false, want true")
+		}
+		if tm.Stop() {
+			t.Errorf("second tm.Stop() = true, want false")
+		}
+		time.Sleep(10 * time.Second)
+		select {
+		case <-tm.C:
+			t.Errorf("stopped timer fired")
+		default:
+		}
+	})
+}
+
+func TestTimerReset(t *testing.T) {
+	synctest.Run(func() {
+		start := time.Now()
+		tm := time.NewTimer(5 * time.Second)
+		time.Sleep(1 * time.Second)
+		if !tm.Reset(10 * time.Second) {
+			t.Errorf("tm.Reset() = false, want true")
+		}
+		<-tm.C
+		if got, want := time.Since(start), 11*time.Second; got != want {
+			t.Errorf("time.Since(start) = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestAfterFunc(t *testing.T) {
+	synctest.Run(func() {
+		start := time.Now()
+		var fired time.Duration
+		time.AfterFunc(3*time.Second, func() {
+			fired = time.Since(start)
+		})
+		time.Sleep(5 * time.Second)
+		if got, want := fired, 3*time.Second; got != want {
+			t.Errorf("AfterFunc ran at %v, want %v", got, want)
+		}
+	})
+}
+
+func TestTicker(t *testing.T) {
+	synctest.Run(func() {
+		start := time.Now()
+		tk := time.NewTicker(2 * time.Second)
+		defer tk.Stop()
+		var got []time.Duration
+		for i := 0; i < 3; i++ {
+			<-tk.C
+			got = append(got, time.Since(start))
+		}
+		want := []time.Duration{2 * time.Second, 4 * time.Second, 6 * time.Second}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ticks at %v, want %v", got, want)
+		}
+	})
+}
+
+func TestTimersFireInOrder(t *testing.T) {
+	synctest.Run(func() {
+		var mu sync.Mutex
+		var got []int
+		for _, d := range []int{3, 1, 2, 1} {
+			d := d
+			time.AfterFunc(time.Duration(d)*time.Second, func() {
+				mu.Lock()
+				got = append(got, d)
+				mu.Unlock()
+			})
+		}
+		time.Sleep(5 * time.Second)
+		if want := []int{1, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("timers fired in order %v, want %v", got, want)
+		}
+	})
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	synctest.Run(func() {
+		const timeout = 5 * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// Wait just less than the timeout.
+		time.Sleep(timeout - time.Nanosecond)
+		synctest.Wait()
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("before timeout, ctx.Err() = %v; want nil", err)
+		}
+
+		// Wait the rest of the way until the timeout.
+		time.Sleep(time.Nanosecond)
+		synctest.Wait()
+		if err := ctx.Err(); err != context.DeadlineExceeded {
+			t.Fatalf("after timeout, ctx.Err() = %v; want DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestCondWait(t *testing.T) {
+	synctest.Run(func() {
+		var mu sync.Mutex
+		cond := sync.NewCond(&mu)
+		ready := false
+		go func() {
+			time.Sleep(time.Second)
+			mu.Lock()
+			ready = true
+			cond.Signal()
+			mu.Unlock()
+		}()
+		mu.Lock()
+		for !ready {
+			cond.Wait()
+		}
+		mu.Unlock()
+	})
+}
+
+func TestDeadlockRoot(t *testing.T) {
+	defer wantPanic(t, "deadlock: all goroutines in bubble are blocked")
+	synctest.Run(func() {
+		select {}
+	})
+}
+
+func TestDeadlockChild(t *testing.T) {
+	defer wantPanic(t, "deadlock: all goroutines in bubble are blocked")
+	synctest.Run(func() {
+		go func() {
+			select {}
+		}()
+	})
+}
+
+func TestWaitFromOutsideBubble(t *testing.T) {
+	defer wantPanic(t, "goroutine is not in a bubble")
+	synctest.Wait()
+}
+
+func TestRunFromInsideBubble(t *testing.T) {
+	synctest.Run(func() {
+		defer wantPanic(t, "synctest.Run called from within a synctest bubble")
+		synctest.Run(func() {})
+	})
+}
+
+func TestTimerResetFromOutsideBubble(t *testing.T) {
+	var tm *time.Timer
+	var tk *time.Ticker
+	synctest.Run(func() {
+		tm = time.NewTimer(time.Second)
+		tk = time.NewTicker(time.Second)
+		tm.Stop()
+		tk.Stop()
+	})
+	func() {
+		defer wantPanic(t, "synctest: timer created in a bubble reset from outside it")
+		tm.Reset(time.Second)
+	}()
+	func() {
+		defer wantPanic(t, "synctest: timer created in a bubble reset from outside it")
+		tk.Reset(time.Second)
+	}()
+}
+
+func TestTimerResetFromInsideBubble(t *testing.T) {
+	tm := time.NewTimer(time.Hour)
+	defer tm.Stop()
+	tk := time.NewTicker(time.Hour)
+	defer tk.Stop()
+	synctest.Run(func() {
+		func() {
+			defer wantPanic(t, "synctest: timer created outside a bubble reset from within one")
+			tm.Reset(time.Second)
+		}()
+		func() {
+			defer wantPanic(t, "synctest: timer created outside a bubble reset from within one")
+			tk.Reset(time.Second)
+		}()
+		// Stopping a timer is permitted from anywhere.
+		tm.Stop()
+	})
+}
+
+func TestTimeOutsideBubble(t *testing.T) {
+	// The fake clock must not leak out of the bubble.
+	synctest.Run(func() {})
+	if time.Now().Year() == 2000 {
+		t.Errorf("time.Now outside bubble returned fake time %v", time.Now())
+	}
+}
+
+func wantPanic(t *testing.T, want string) {
+	if e := recover(); e != nil {
+		if got := e.(string); got != want {
+			t.Errorf("got panic message %q, want %q", got, want)
+		}
+	} else {
+		t.Errorf("got no panic, want one")
+	}
+}
@@ -4,6 +4,8 @@
 
 package time
 
+import "unsafe"
+
 // Sleep pauses the current goroutine for at least the duration d.
 // A negative or zero duration causes Sleep to return immediately.
 func Sleep(d Duration)
@@ -19,6 +21,9 @@ type runtimeTimer struct {
 	seq      uintptr
 	nextwhen int64
 	status   uint32
+
+	bubble     unsafe.Pointer
+	bubbleNext unsafe.Pointer
 }
 
 // when is a helper function for setting the 'when' field of a runtimeTimer.
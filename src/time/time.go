@@ -1051,7 +1051,7 @@ func daysSinceEpoch(year int) uint64 {
 func now() (sec int64, nsec int32, mono int64)
 
 // runtimeNano returns the current value of the runtime clock in nanoseconds.
-//go:linkname runtimeNano runtime.nanotime
+// Provided by package runtime.
 func runtimeNano() int64
 
 // Monotonic times are reported as offsets from startNano.